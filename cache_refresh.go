@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+
+	"motd/config"
+	"motd/display"
+	"motd/media"
+	"motd/system"
+)
+
+// startMediaCacheRefresh re-runs motd as a detached `refresh-media-cache`
+// process so stale media results are updated after this login has finished.
+func startMediaCacheRefresh(cfg config.Config, configPath string, debug bool) {
+	args := []string{"refresh-media-cache"}
+	if configPath != "" {
+		args = append(args, "-config", configPath)
+	}
+	if err := media.StartMediaCacheRefresh(cfg, func() error { return spawnDetached(args...) }); err != nil {
+		display.DebugLog(debug, "Media cache refresh not started: %v", err)
+	}
+}
+
+// startUpdatesCacheRefresh re-runs motd as a detached
+// `refresh-updates-cache` process so a slow package manager never holds up
+// the banner.
+func startUpdatesCacheRefresh(debug bool) {
+	if err := system.StartUpdatesCacheRefresh(func() error { return spawnDetached("refresh-updates-cache") }); err != nil {
+		display.DebugLog(debug, "Updates cache refresh not started: %v", err)
+	}
+}
+
+// spawnDetached starts motd with args in its own session and does not wait
+// for it.
+func spawnDetached(args ...string) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command(exe, args...)
+	detachProcess(cmd)
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	return cmd.Process.Release()
+}
+
+func handleRefreshMediaCache(args []string) {
+	fs := flagSet("refresh-media-cache")
+	configPath := fs.String("config", "", "Load config from a specific JSON file")
+	if err := fs.Parse(args); err != nil {
+		os.Exit(2)
+	}
+	cfg, err := config.Load(*configPath, false, nil)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading configuration: %v\n", err)
+		os.Exit(1)
+	}
+	ctx, cancel := budgetContext(cfg, 0, false)
+	defer cancel()
+	media.RefreshMediaCache(ctx, cfg, newHTTPClient(), false)
+}
+
+func handleRefreshUpdatesCache(args []string) {
+	fs := flagSet("refresh-updates-cache")
+	if err := fs.Parse(args); err != nil {
+		os.Exit(2)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), system.UpdatesRefreshTimeout)
+	defer cancel()
+	if err := system.RefreshUpdatesCache(ctx); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,294 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"motd/config"
+	"motd/display"
+	"motd/media"
+	"motd/system"
+)
+
+// Monitoring-plugin exit codes.
+const (
+	checkExitOK       = 0
+	checkExitWarning  = 1
+	checkExitCritical = 2
+	checkExitUnknown  = 3
+)
+
+var perfLabelInvalid = regexp.MustCompile(`[^a-z0-9/]+`)
+
+// pluginCheck accumulates graded readings into one monitoring-plugin result.
+type pluginCheck struct {
+	severity display.Severity
+	checks   int
+	problems []string
+	perfdata []string
+}
+
+// add records one graded reading. Readings without a severity only add perfdata.
+func (c *pluginCheck) add(severity display.Severity, summary string) {
+	if severity == "" {
+		return
+	}
+	c.checks++
+	c.severity = display.WorstSeverity(c.severity, severity)
+	if severity != display.SeverityOK {
+		c.problems = append(c.problems, fmt.Sprintf("%s (%s)", summary, severity))
+	}
+}
+
+func (c *pluginCheck) perf(label, value, uom string, levels config.Threshold, bounds ...float64) {
+	fields := []string{value + uom, formatPerfLevel(levels.Warn), formatPerfLevel(levels.Critical)}
+	for _, bound := range bounds {
+		fields = append(fields, formatPerfValue(bound))
+	}
+	c.perfdata = append(c.perfdata, perfLabel(label)+"="+strings.TrimRight(strings.Join(fields, ";"), ";"))
+}
+
+// Line renders the single plugin output line, perfdata included.
+func (c *pluginCheck) Line() string {
+	severity := c.severity
+	if severity == "" {
+		severity = display.SeverityOK
+	}
+	summary := fmt.Sprintf("%d checks ok", c.checks)
+	if len(c.problems) > 0 {
+		summary = strings.Join(c.problems, ", ")
+	}
+	line := fmt.Sprintf("MOTD %s - %s", strings.ToUpper(string(severity)), summary)
+	if len(c.perfdata) > 0 {
+		line += " | " + strings.Join(c.perfdata, " ")
+	}
+	return line
+}
+
+// ExitCode maps the overall severity to the plugin exit status.
+func (c *pluginCheck) ExitCode() int {
+	switch c.severity {
+	case "", display.SeverityOK:
+		return checkExitOK
+	case display.SeverityWarning:
+		return checkExitWarning
+	case display.SeverityCritical:
+		return checkExitCritical
+	default:
+		return checkExitUnknown
+	}
+}
+
+// evaluateCheck grades the snapshot and media statuses. A configured
+// container status agent that returns nothing, and any collector that ran
+// out of time, counts as unknown.
+func evaluateCheck(cfg config.Config, snapshot system.SystemSnapshot, statuses []media.MediaStatus) *pluginCheck {
+	th := cfg.Thresholds
+	check := &pluginCheck{}
+
+	for _, name := range snapshot.TimedOut {
+		check.add(display.SeverityUnknown, name+" timed out")
+	}
+
+	if updates := snapshot.Updates; updates != nil {
+		check.add(updates.Severity, "updates "+updates.Summary())
+		if updates.Pending != nil {
+			check.perf("updates", strconv.Itoa(*updates.Pending), "", config.Threshold{}, 0)
+		}
+		if updates.Security != nil {
+			check.perf("security_updates", strconv.Itoa(*updates.Security), "", config.Threshold{}, 0)
+		}
+	}
+	if load := snapshot.Load; load != nil {
+		if perCore, ok := load.PerCore(); ok {
+			value := formatPerfValue(perCore)
+			check.add(load.Severity, "load "+value+"/core")
+			check.perf("load_per_core", value, "", th.LoadPerCoreLevels(), 0)
+		}
+		if load.CPUPercent != nil {
+			check.perf("cpu", formatPerfValue(*load.CPUPercent), "%", config.Threshold{}, 0, 100)
+		}
+		var usage []string
+		if load.IOWaitPercent != nil {
+			value := formatPerfValue(*load.IOWaitPercent)
+			usage = append(usage, "iowait "+value+"%")
+			check.perf("iowait", value, "%", th.IOWaitLevels(), 0, 100)
+		}
+		if load.StealPercent != nil {
+			value := formatPerfValue(*load.StealPercent)
+			usage = append(usage, "steal "+value+"%")
+			check.perf("steal", value, "%", th.StealLevels(), 0, 100)
+		}
+		if len(usage) > 0 {
+			check.add(load.UsageSeverity, "cpu "+strings.Join(usage, ", "))
+		}
+	}
+	if memory := snapshot.Memory; memory != nil {
+		check.add(memory.Severity, fmt.Sprintf("memory %.0f%%", memory.UsedPercent))
+		check.perf("memory", formatPerfValue(memory.UsedPercent), "%", th.MemoryLevels(), 0, 100)
+		if memory.SwapTotalBytes > 0 {
+			check.perf("swap", formatPerfValue(float64(memory.SwapUsedBytes)/float64(memory.SwapTotalBytes)*100), "%", config.Threshold{}, 0, 100)
+		}
+		if memory.OOMKills != nil {
+			check.perf("oom_kills", strconv.FormatUint(*memory.OOMKills, 10), "c", config.Threshold{})
+		}
+	}
+	if pressure := snapshot.Pressure; pressure != nil {
+		for _, resource := range pressure.Resources() {
+			value := formatPerfValue(resource.Stall.Some.Avg10)
+			check.add(resource.Stall.Severity, "pressure "+resource.Name+" "+value+"%")
+			check.perf("pressure "+resource.Name, value, "%", th.PressureLevels(), 0, 100)
+		}
+	}
+	for _, iface := range snapshot.Interfaces {
+		check.add(iface.Severity, "interface "+iface.Name+" "+iface.State)
+	}
+	if failed := snapshot.FailedLogins; failed != nil {
+		check.perf("failed_logins", strconv.Itoa(failed.Count), "", config.Threshold{}, 0)
+	}
+	for _, disk := range snapshot.Disks {
+		check.add(disk.Severity, fmt.Sprintf("disk %s %.0f%%", disk.Path, disk.UsedPercent))
+		check.perf("disk "+disk.Path, formatPerfValue(disk.UsedPercent), "%", th.DiskLevels(disk.Path), 0, 100)
+		if disk.InodesTotal > 0 {
+			check.add(disk.InodeSeverity, fmt.Sprintf("inodes %s %.0f%%", disk.Path, disk.InodesUsedPercent))
+			check.perf("inodes "+disk.Path, formatPerfValue(disk.InodesUsedPercent), "%", th.InodeLevels(), 0, 100)
+		}
+	}
+	for _, pool := range snapshot.ZFSPools {
+		check.add(pool.Severity, fmt.Sprintf("zfs %s %s %.0f%%", pool.Name, pool.Health, pool.CapacityPercent))
+		check.perf("zfs "+pool.Name, formatPerfValue(pool.CapacityPercent), "%", th.ZFSCapacityLevels(), 0, 100)
+	}
+	for _, array := range snapshot.RAIDArrays {
+		summary := "md " + array.Name + " " + array.State
+		if array.Degraded {
+			summary = "md " + array.Name + " degraded"
+		}
+		check.add(array.Severity, summary)
+	}
+	for _, filesystem := range snapshot.Btrfs {
+		check.add(filesystem.Severity, "btrfs "+filesystem.Name()+" "+btrfsSummary(filesystem))
+	}
+	if drives := snapshot.SMARTDrives; len(drives) > 0 {
+		check.add(drives.Severity(), "drives "+drives.Summary())
+		for _, drive := range drives {
+			if drive.PercentageUsed != nil {
+				check.perf("wear "+drive.Device, formatPerfValue(*drive.PercentageUsed), "%", th.DriveWearLevels(), 0, 100)
+			}
+		}
+	}
+	if temperature := snapshot.Temperature; temperature != nil && len(temperature.Sensors) > 0 {
+		for _, sensor := range temperature.Sensors {
+			check.add(sensor.Severity, fmt.Sprintf("%s %.0f°C", sensor.Name, sensor.Celsius))
+			check.perf("temperature "+sensor.Name, formatPerfValue(sensor.Celsius), "", sensor.Levels(th.TemperatureLevels()))
+		}
+	} else if temperature != nil {
+		check.add(temperature.Severity, fmt.Sprintf("temperature %.0f°C", temperature.Celsius))
+		check.perf("temperature", formatPerfValue(temperature.Celsius), "", th.TemperatureLevels())
+	}
+
+	if containers := snapshot.Containers; containers != nil {
+		if containers.Total > 0 {
+			check.add(containers.Severity(), "containers "+containers.Status)
+			check.perf("containers_online", strconv.Itoa(containers.Online), "", config.Threshold{}, 0, float64(containers.Total))
+		}
+	} else if cfg.System.ContainerStatus != nil && !slices.Contains(snapshot.TimedOut, "containers") {
+		check.add(display.SeverityUnknown, "container status unavailable")
+	}
+
+	if units := snapshot.Units; units != nil {
+		if units.Total > 0 {
+			check.add(units.WatchSeverity(), "units "+units.Status)
+			check.perf("units_active", strconv.Itoa(units.Active), "", config.Threshold{}, 0, float64(units.Total))
+		}
+		if len(units.Failed) > 0 {
+			check.add(display.SeverityWarning, "failed units "+units.FailedNames())
+		}
+		check.perf("failed_units", strconv.Itoa(len(units.Failed)), "", config.Threshold{}, 0)
+	}
+
+	for _, status := range statuses {
+		check.add(status.Severity, status.Name+" "+status.Text())
+		if status.Error != "" {
+			continue
+		}
+		result := status.Result
+		switch result.Kind {
+		case media.KindPlex, media.KindJellyfin:
+			check.perf(status.Name+" streams", strconv.Itoa(result.Streams), "", th.StreamsLevels(), 0)
+			check.perf(status.Name+" transcodes", strconv.Itoa(result.Transcodes), "", th.TranscodesLevels(), 0)
+		case media.KindSonarr, media.KindRadarr:
+			check.perf(status.Name+" missing", strconv.Itoa(result.Missing), "", th.MissingLevels(), 0)
+		case media.KindSeerr:
+			check.perf(status.Name+" pending", strconv.Itoa(result.Pending), "", th.PendingLevels(), 0)
+		}
+	}
+	return check
+}
+
+// btrfsSummary names the worst problem on a Btrfs filesystem.
+func btrfsSummary(filesystem system.BtrfsFilesystem) string {
+	var errors uint64
+	for _, device := range filesystem.Devices {
+		if device.Missing {
+			return "device missing"
+		}
+		errors += device.Errors()
+	}
+	return fmt.Sprintf("%d device errors", errors)
+}
+
+// perfLabel turns a display name such as "Plex (Main) streams" into a
+// perfdata label such as "plex_main_streams".
+func perfLabel(name string) string {
+	label := perfLabelInvalid.ReplaceAllString(strings.ToLower(name), "_")
+	return strings.Trim(label, "_")
+}
+
+func formatPerfValue(value float64) string {
+	return strconv.FormatFloat(math.Round(value*100)/100, 'f', -1, 64)
+}
+
+func formatPerfLevel(level *float64) string {
+	if level == nil {
+		return ""
+	}
+	return formatPerfValue(*level)
+}
+
+func handleCheck(args []string) {
+	fs := flagSet("check")
+	debug := fs.Bool("d", false, "Enable debug mode")
+	configPath := fs.String("config", "", "Load config from a specific JSON file")
+	noConfig := fs.Bool("no-config", false, "Skip config loading and check system readings only")
+	servicesFilter := fs.String("services", "", "Only check selected media services (comma-separated)")
+	timeout := fs.Duration("timeout", 0, "Time budget for the whole check (default: render_budget or 10s)")
+	if err := fs.Parse(args); err != nil {
+		os.Exit(checkExitUnknown)
+	}
+	display.SetColorEnabled(false)
+
+	cfg, err := config.Load(*configPath, *noConfig, func(msg string, args ...interface{}) {
+		display.DebugLog(*debug, msg, args...)
+	})
+	if err != nil {
+		fmt.Printf("MOTD UNKNOWN - configuration: %v\n", err)
+		os.Exit(checkExitUnknown)
+	}
+	serviceSet, err := parseServiceFilter(*servicesFilter)
+	if err != nil {
+		fmt.Printf("MOTD UNKNOWN - %v\n", err)
+		os.Exit(checkExitUnknown)
+	}
+
+	ctx, cancel := budgetContext(cfg, *timeout, *debug)
+	run := collectAll(ctx, cfg, serviceSet, newHTTPClient(), false, *debug)
+	cancel()
+	check := evaluateCheck(cfg, run.snapshot, run.statuses)
+	fmt.Println(check.Line())
+	os.Exit(check.ExitCode())
+}
@@ -1,12 +1,14 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"os"
 	"strings"
 
 	"motd/config"
+	"motd/daemon"
 	"motd/display"
 	"motd/media"
 	"motd/system"
@@ -86,7 +88,7 @@ func validateConfig(cfg config.Config) []configIssue {
 				issues = append(issues, configIssue{Level: "error", Message: err.Error()})
 			} else if !usable {
 				issues = append(issues, configIssue{Level: "warning", Message: fmt.Sprintf("container status socket %s does not exist", path)})
-			} else if _, ok := system.GetContainerStatus(system.ConfigAccessorFrom(cfg), false); !ok {
+			} else if _, ok := system.GetContainerStatus(context.Background(), system.ConfigAccessorFrom(cfg), false); !ok {
 				issues = append(issues, configIssue{Level: "error", Message: "container status socket exists but did not return a valid current status"})
 			}
 		}
@@ -96,6 +98,53 @@ func validateConfig(cfg config.Config) []configIssue {
 			issues = append(issues, configIssue{Level: "warning", Message: "tank_mount is set but is not a readable directory"})
 		}
 	}
+	if disks := cfg.System.Disks; disks != nil {
+		if err := system.ValidateDisksConfig(disks); err != nil {
+			issues = append(issues, configIssue{Level: "error", Message: err.Error()})
+		} else {
+			for _, mount := range disks.Mounts {
+				if info, err := os.Stat(mount.Path); err != nil || !info.IsDir() {
+					issues = append(issues, configIssue{Level: "warning", Message: fmt.Sprintf("disk mount %s is not a readable directory", mount.Path)})
+				}
+			}
+		}
+		if cfg.System.TankMount != "" {
+			issues = append(issues, configIssue{Level: "warning", Message: "tank_mount is ignored when disks is set"})
+		}
+	}
+	if err := system.ValidateNetworkConfig(cfg.System.Network); err != nil {
+		issues = append(issues, configIssue{Level: "error", Message: err.Error()})
+	}
+	if err := system.ValidateTemperatureConfig(cfg.System.Temperature); err != nil {
+		issues = append(issues, configIssue{Level: "error", Message: err.Error()})
+	}
+	if failed := cfg.System.FailedLogins; failed != nil {
+		if err := system.ValidateFailedLoginsConfig(failed); err != nil {
+			issues = append(issues, configIssue{Level: "error", Message: err.Error()})
+		} else if !system.FailedLoginsReadable() {
+			issues = append(issues, configIssue{Level: "warning", Message: "failed_logins is set but none of /var/log/btmp, /var/log/auth.log or /var/log/secure is readable"})
+		}
+	}
+	if err := system.ValidateUnitsConfig(cfg.System.Units); err != nil {
+		issues = append(issues, configIssue{Level: "error", Message: err.Error()})
+	} else if len(cfg.System.Units.Watch) > 0 && !system.SystemdAvailable() {
+		issues = append(issues, configIssue{Level: "warning", Message: "system.units.watch is set but systemd is not running"})
+	}
+	if err := system.ValidateUpdatesConfig(cfg.System.Updates); err != nil {
+		issues = append(issues, configIssue{Level: "error", Message: err.Error()})
+	}
+	if err := daemon.ValidateConfig(cfg.Daemon); err != nil {
+		issues = append(issues, configIssue{Level: "error", Message: err.Error()})
+	}
+	if err := media.ValidateCacheConfig(cfg); err != nil {
+		issues = append(issues, configIssue{Level: "error", Message: err.Error()})
+	}
+	if err := config.ValidateThresholds(cfg.Thresholds); err != nil {
+		issues = append(issues, configIssue{Level: "error", Message: err.Error()})
+	}
+	if _, err := config.ParseRenderBudget(cfg.RenderBudget); err != nil {
+		issues = append(issues, configIssue{Level: "error", Message: err.Error()})
+	}
 
 	return issues
 }
@@ -48,6 +48,44 @@ func TestValidateConfigAllowsLoopbackHTTP(t *testing.T) {
 	}
 }
 
+func TestValidateConfigRejectsInvertedThresholds(t *testing.T) {
+	warn, critical := 90.0, 80.0
+	cfg := config.Config{}
+	cfg.Thresholds.Memory = &config.Threshold{Warn: &warn, Critical: &critical}
+	issues := validateConfig(cfg)
+	found := false
+	for _, issue := range issues {
+		if issue.Level == "error" && strings.Contains(issue.Message, "thresholds.memory") {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("expected thresholds error, got %+v", issues)
+	}
+}
+
+func TestValidateConfigRejectsNegativeFailedLoginsTop(t *testing.T) {
+	cfg := config.Config{}
+	cfg.System.FailedLogins = &config.FailedLoginsConfig{Top: -1}
+	issues := validateConfig(cfg)
+	found := false
+	for _, issue := range issues {
+		if issue.Level == "error" && strings.Contains(issue.Message, "failed_logins.top") {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("expected failed_logins error, got %+v", issues)
+	}
+}
+
+func TestValidateConfigRejectsInvalidRenderBudget(t *testing.T) {
+	issues := validateConfig(config.Config{RenderBudget: "-2s"})
+	if !hasErrorIssue(issues) || !strings.Contains(issues[len(issues)-1].Message, "render_budget") {
+		t.Fatalf("expected render_budget error, got %+v", issues)
+	}
+}
+
 func TestValidateConfigTooManyEnabledServices(t *testing.T) {
 	cfg := config.Config{}
 	for i := 0; i < 33; i++ {
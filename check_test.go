@@ -0,0 +1,222 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"motd/config"
+	"motd/display"
+	"motd/media"
+	"motd/system"
+)
+
+func TestEvaluateCheckOK(t *testing.T) {
+	snapshot := system.SystemSnapshot{
+		Memory: &system.MemoryInfo{UsedPercent: 40, Severity: display.SeverityOK},
+		Disks:  system.DiskList{{Path: "/", UsedPercent: 50.123, Severity: display.SeverityOK}},
+	}
+	statuses := []media.MediaStatus{{Name: "Plex (Main)", Kind: media.KindPlex, Result: media.Result{Kind: media.KindPlex, Streams: 2}, Severity: display.SeverityOK}}
+
+	check := evaluateCheck(config.Config{}, snapshot, statuses)
+	if check.ExitCode() != checkExitOK {
+		t.Fatalf("expected OK exit code, got %d", check.ExitCode())
+	}
+	want := "MOTD OK - 3 checks ok | memory=40%;85;95;0;100 disk_/=50.12%;85;95;0;100 plex_main_streams=2;;;0 plex_main_transcodes=0;1;;0"
+	if got := check.Line(); got != want {
+		t.Fatalf("Line() = %q, want %q", got, want)
+	}
+}
+
+func TestEvaluateCheckWorstSeverityWins(t *testing.T) {
+	snapshot := system.SystemSnapshot{
+		Disks:      system.DiskList{{Path: "/mnt/tank", UsedPercent: 97, Severity: display.SeverityCritical}},
+		Containers: &system.ContainerStatus{Online: 4, Total: 5, Status: "4 of 5 online"},
+	}
+	statuses := []media.MediaStatus{{Name: "Sonarr", Kind: media.KindSonarr, Error: media.ErrorAuth, Detail: "401 unauthorized", Severity: display.SeverityWarning}}
+
+	check := evaluateCheck(config.Config{}, snapshot, statuses)
+	if check.ExitCode() != checkExitCritical {
+		t.Fatalf("expected CRITICAL exit code, got %d", check.ExitCode())
+	}
+	line := check.Line()
+	for _, want := range []string{
+		"MOTD CRITICAL - disk /mnt/tank 97% (critical)",
+		"containers 4 of 5 online (warning)",
+		"Sonarr unavailable (401 unauthorized) (warning)",
+		"containers_online=4;;;0;5",
+	} {
+		if !strings.Contains(line, want) {
+			t.Fatalf("expected %q in %q", want, line)
+		}
+	}
+	if strings.Contains(line, "sonarr_missing") {
+		t.Fatalf("expected no perfdata for a failed check: %q", line)
+	}
+}
+
+func TestEvaluateCheckMissingContainerStatusIsUnknown(t *testing.T) {
+	cfg := config.Config{}
+	cfg.System.ContainerStatus = &config.ContainerStatusConfig{SocketPath: "/run/agent.sock"}
+
+	check := evaluateCheck(cfg, system.SystemSnapshot{}, nil)
+	if check.ExitCode() != checkExitUnknown {
+		t.Fatalf("expected UNKNOWN exit code, got %d", check.ExitCode())
+	}
+	if got := check.Line(); got != "MOTD UNKNOWN - container status unavailable (unknown)" {
+		t.Fatalf("unexpected line: %q", got)
+	}
+}
+
+func TestEvaluateCheckTimedOutCollectorsAreUnknown(t *testing.T) {
+	cfg := config.Config{}
+	cfg.System.ContainerStatus = &config.ContainerStatusConfig{SocketPath: "/run/agent.sock"}
+	snapshot := system.SystemSnapshot{TimedOut: []string{"containers"}}
+	statuses := []media.MediaStatus{{Name: "Plex", Kind: media.KindPlex, Error: media.ErrorTimedOut}}
+
+	check := evaluateCheck(cfg, snapshot, media.EvaluateStatuses(statuses, cfg.Thresholds))
+	if check.ExitCode() != checkExitUnknown {
+		t.Fatalf("expected UNKNOWN exit code, got %d", check.ExitCode())
+	}
+	if got := check.Line(); got != "MOTD UNKNOWN - containers timed out (unknown), Plex timed out (unknown)" {
+		t.Fatalf("unexpected line: %q", got)
+	}
+}
+
+func TestEvaluateCheck(t *testing.T) {
+	sensorMax, sensorCrit := 81.85, 84.85
+	pendingSectors, wearUsed := uint64(12), 42.0
+	cpuBusy, cpuIOWait, cpuSteal, hostSteal := 37.5, 12.0, 0.0, 30.0
+	oomKills := uint64(2)
+	pendingUpdates, securityUpdates := 12, 3
+	tests := []struct {
+		name     string
+		snapshot system.SystemSnapshot
+		exit     int
+		want     string
+	}{
+		{
+			name: "listed interface down",
+			snapshot: system.SystemSnapshot{Interfaces: system.InterfaceList{
+				{Name: "eth0", State: system.LinkUp, Severity: display.SeverityOK},
+				{Name: "eth1", State: system.LinkDown, Severity: display.SeverityWarning},
+				{Name: "docker0", State: system.LinkDown},
+			}},
+			exit: checkExitWarning,
+			want: "MOTD WARNING - interface eth1 down (warning)",
+		},
+		{
+			name: "full inode table",
+			snapshot: system.SystemSnapshot{Disks: system.DiskList{{
+				Path: "/var/spool/mail", UsedPercent: 20, Severity: display.SeverityOK,
+				InodesTotal: 1000, InodesUsed: 970, InodesUsedPercent: 97, InodeSeverity: display.SeverityCritical,
+			}}},
+			exit: checkExitCritical,
+			want: "MOTD CRITICAL - inodes /var/spool/mail 97% (critical) | disk_/var/spool/mail=20%;85;95;0;100 inodes_/var/spool/mail=97%;85;95;0;100",
+		},
+		{
+			name:     "degraded zfs pool",
+			snapshot: system.SystemSnapshot{ZFSPools: system.ZFSPoolList{{Name: "tank", Health: "DEGRADED", CapacityPercent: 61, Severity: display.SeverityCritical}}},
+			exit:     checkExitCritical,
+			want:     "MOTD CRITICAL - zfs tank DEGRADED 61% (critical) | zfs_tank=61%;80;90;0;100",
+		},
+		{
+			name: "degraded array and btrfs errors",
+			snapshot: system.SystemSnapshot{
+				RAIDArrays: system.RAIDArrayList{
+					{Name: "md0", State: "active", Severity: display.SeverityOK},
+					{Name: "md1", State: "active", Degraded: true, Severity: display.SeverityCritical},
+				},
+				Btrfs: system.BtrfsFilesystemList{{UUID: "3c7e1f52-6a0d", Label: "data", Devices: []system.BtrfsDevice{{ID: "1", ReadErrors: 4}}, Severity: display.SeverityWarning}},
+			},
+			exit: checkExitCritical,
+			want: "MOTD CRITICAL - md md1 degraded (critical), btrfs data 4 device errors (warning)",
+		},
+		{
+			name: "sensor uses chip limits",
+			snapshot: system.SystemSnapshot{Temperature: &system.TemperatureInfo{
+				Celsius: 58,
+				Sensors: []system.TemperatureSensor{
+					{Name: "CPU Package", Chip: "coretemp", Celsius: 58, Severity: display.SeverityOK},
+					{Name: "nvme Composite", Chip: "nvme", Celsius: 83.85, MaxCelsius: &sensorMax, CritCelsius: &sensorCrit, Severity: display.SeverityWarning},
+				},
+				Severity: display.SeverityWarning,
+			}},
+			exit: checkExitWarning,
+			want: "MOTD WARNING - nvme Composite 84°C (warning) | temperature_cpu_package=58;70;85 temperature_nvme_composite=83.85;81.85;84.85",
+		},
+		{
+			name: "drive pending sectors",
+			snapshot: system.SystemSnapshot{SMARTDrives: system.SMARTDriveList{
+				{Device: "sdc", PendingSectors: &pendingSectors, Severity: display.SeverityWarning},
+				{Device: "nvme0", PercentageUsed: &wearUsed, Severity: display.SeverityOK},
+			}},
+			exit: checkExitWarning,
+			want: "MOTD WARNING - drives sdc: 12 pending sectors (warning) | wear_nvme0=42%;80;100;0;100",
+		},
+		{
+			name:     "sampled cpu",
+			snapshot: system.SystemSnapshot{Load: &system.LoadInfo{Averages: []float64{2, 1, 1}, Cores: 4, CPUPercent: &cpuBusy, IOWaitPercent: &cpuIOWait, StealPercent: &cpuSteal, Severity: display.SeverityOK, UsageSeverity: display.SeverityOK}},
+			exit:     checkExitOK,
+			want:     "MOTD OK - 2 checks ok | load_per_core=0.5;1;2;0 cpu=37.5%;;;0;100 iowait=12%;20;40;0;100 steal=0%;10;25;0;100",
+		},
+		{
+			name:     "cpu steal",
+			snapshot: system.SystemSnapshot{Load: &system.LoadInfo{CPUPercent: &cpuBusy, IOWaitPercent: &cpuIOWait, StealPercent: &hostSteal, UsageSeverity: display.SeverityCritical}},
+			exit:     checkExitCritical,
+			want:     "MOTD CRITICAL - cpu iowait 12%, steal 30% (critical) | cpu=37.5%;;;0;100 iowait=12%;20;40;0;100 steal=30%;10;25;0;100",
+		},
+		{
+			name: "memory pressure",
+			snapshot: system.SystemSnapshot{
+				Memory: &system.MemoryInfo{UsedPercent: 40, SwapTotalBytes: 4 << 30, SwapUsedBytes: 1 << 30, OOMKills: &oomKills, Severity: display.SeverityOK},
+				Pressure: &system.PressureInfo{
+					Memory: &system.PressureStall{Some: system.PressureAverages{Avg10: 31.5}, Severity: display.SeverityCritical},
+					IO:     &system.PressureStall{Some: system.PressureAverages{Avg10: 1.2}, Severity: display.SeverityOK},
+				},
+			},
+			exit: checkExitCritical,
+			want: "MOTD CRITICAL - pressure memory 31.5% (critical) | memory=40%;85;95;0;100 swap=25%;;;0;100 oom_kills=2c pressure_memory=31.5%;10;25;0;100 pressure_io=1.2%;10;25;0;100",
+		},
+		{
+			name: "failed logins are perfdata only",
+			snapshot: system.SystemSnapshot{
+				Memory:       &system.MemoryInfo{UsedPercent: 40, Severity: display.SeverityOK},
+				FailedLogins: &system.FailedLogins{Count: 37, SinceLastLogin: true},
+			},
+			exit: checkExitOK,
+			want: "MOTD OK - 1 checks ok | memory=40%;85;95;0;100 failed_logins=37;;;0",
+		},
+		{
+			name: "pending security updates",
+			snapshot: system.SystemSnapshot{
+				Updates: &system.UpdateInfo{Manager: system.PackageManagerAPT, Pending: &pendingUpdates, Security: &securityUpdates, RebootRequired: true, Severity: display.SeverityWarning},
+			},
+			exit: checkExitWarning,
+			want: "MOTD WARNING - updates 12 pending (3 security), reboot required (warning) | updates=12;;;0 security_updates=3;;;0",
+		},
+		{
+			name: "inactive and failed units",
+			snapshot: system.SystemSnapshot{
+				Units: &system.UnitStatus{
+					Active: 2,
+					Total:  3,
+					Status: "2 of 3 active",
+					Failed: []system.UnitState{{Name: "backup.service", LoadState: "loaded", ActiveState: "failed", SubState: "failed"}},
+				},
+			},
+			exit: checkExitWarning,
+			want: "MOTD WARNING - units 2 of 3 active (warning), failed units backup.service (warning) | units_active=2;;;0;3 failed_units=1;;;0",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check := evaluateCheck(config.Config{}, tt.snapshot, nil)
+			if check.ExitCode() != tt.exit {
+				t.Fatalf("expected exit code %d, got %d", tt.exit, check.ExitCode())
+			}
+			if got := check.Line(); got != tt.want {
+				t.Fatalf("Line() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
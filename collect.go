@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	"motd/config"
+	"motd/media"
+	"motd/system"
+	"motd/util"
+)
+
+// collection is one pass over every system collector and media check.
+type collection struct {
+	snapshot     system.SystemSnapshot
+	statuses     []media.MediaStatus
+	needsRefresh bool
+}
+
+// collectAll runs one collection pass. The system collectors and the media
+// checks share a worker pool, sized by the media concurrency limit, so slow
+// subprocesses, the agent socket and media requests overlap; each pass gets a
+// fresh pool because a collector abandoned at the deadline keeps its slot.
+// cached reads media results through the on-disk cache, and stale package
+// update counts are handed to a detached refresh before returning.
+func collectAll(ctx context.Context, cfg config.Config, serviceSet map[string]bool, client *http.Client, cached, debug bool) collection {
+	pool := util.NewPool(media.MaxConcurrentMediaChecks())
+	var result collection
+	var wg sync.WaitGroup
+	wg.Go(func() {
+		result.snapshot = system.CollectSnapshot(ctx, system.ConfigAccessorFrom(cfg), pool, debug)
+	})
+	wg.Go(func() {
+		if cached {
+			result.statuses, result.needsRefresh = media.CollectCachedMediaStatuses(ctx, cfg, serviceSet, client, pool, debug)
+			return
+		}
+		result.statuses = media.CollectMediaStatuses(ctx, cfg, serviceSet, client, pool, debug)
+	})
+	wg.Wait()
+	if updates := result.snapshot.Updates; updates != nil && updates.Stale {
+		startUpdatesCacheRefresh(debug)
+	}
+	return result
+}
@@ -9,17 +9,19 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"motd/display"
 	"motd/util"
 )
 
 type ServiceConfig struct {
-	Name    string `json:"name"`
-	URL     string `json:"url"`
-	APIKey  string `json:"api_key,omitempty"`
-	Token   string `json:"token,omitempty"`
-	Enabled bool   `json:"enabled"`
+	Name     string `json:"name"`
+	URL      string `json:"url"`
+	APIKey   string `json:"api_key,omitempty"`
+	Token    string `json:"token,omitempty"`
+	Enabled  bool   `json:"enabled"`
+	CacheTTL string `json:"cache_ttl,omitempty"`
 }
 
 type ContainerStatusConfig struct {
@@ -27,14 +29,84 @@ type ContainerStatusConfig struct {
 	MaxAge     string `json:"max_age,omitempty"`
 }
 
+// DaemonConfig points motd at a `motd daemon` instance. Interval is how often
+// the daemon collects and MaxAge is how old a snapshot may be before clients
+// fall back to live collection.
+type DaemonConfig struct {
+	SocketPath string `json:"socket_path,omitempty"`
+	Interval   string `json:"interval,omitempty"`
+	MaxAge     string `json:"max_age,omitempty"`
+}
+
+// MediaCacheConfig enables the on-disk media result cache. Results younger
+// than TTL are reused; results up to MaxStale old are shown with their age
+// while a background refresh runs.
+type MediaCacheConfig struct {
+	TTL      string `json:"ttl,omitempty"`
+	MaxStale string `json:"max_stale,omitempty"`
+}
+
+// NetworkConfig selects the interface for bandwidth accounting. Bandwidth
+// is "auto" (vnstat when installed, otherwise native), "vnstat" or "native";
+// StateFile is where native mode keeps its counters. Interfaces lists the
+// interfaces to report individually, or ["all"] for every non-loopback
+// interface, and replaces the single Interface bandwidth rows.
 type NetworkConfig struct {
-	Interface string `json:"interface,omitempty"`
+	Interface  string   `json:"interface,omitempty"`
+	Interfaces []string `json:"interfaces,omitempty"`
+	Bandwidth  string   `json:"bandwidth,omitempty"`
+	StateFile  string   `json:"state_file,omitempty"`
+}
+
+// DisksConfig replaces the root-plus-tank_mount disk rows. Mounts are
+// reported in order under their optional labels; Auto adds every real
+// filesystem found in /proc/self/mountinfo after them.
+type DisksConfig struct {
+	Auto   bool          `json:"auto,omitempty"`
+	Mounts []MountConfig `json:"mounts,omitempty"`
+}
+
+type MountConfig struct {
+	Path  string `json:"path"`
+	Label string `json:"label,omitempty"`
+}
+
+// TemperatureConfig picks the hwmon sensors shown in the banner, by chip
+// name, sensor label, "chip label" or "CPU Package". Without sensors the
+// banner shows the CPU package temperature alone.
+type TemperatureConfig struct {
+	Sensors []string `json:"sensors,omitempty"`
+}
+
+// FailedLoginsConfig turns on the failed login summary, which reads
+// /var/log/btmp or the sshd lines of the auth log and so needs root or the
+// adm group. Top is how many source addresses to name, 3 when unset.
+type FailedLoginsConfig struct {
+	Top int `json:"top,omitempty"`
+}
+
+// UpdatesConfig turns on the pending updates and reboot-required rows.
+// Package manager checks are slow, so their counts are cached on disk for
+// CacheTTL, 1h when unset, or until the package database changes.
+type UpdatesConfig struct {
+	CacheTTL string `json:"cache_ttl,omitempty"`
+}
+
+// UnitsConfig lists the systemd units to watch. Failed units are reported
+// whether or not they are watched.
+type UnitsConfig struct {
+	Watch []string `json:"watch,omitempty"`
 }
 
 type SystemConfig struct {
 	ContainerStatus *ContainerStatusConfig `json:"container_status,omitempty"`
 	TankMount       string                 `json:"tank_mount"`
+	Disks           *DisksConfig           `json:"disks,omitempty"`
 	Network         NetworkConfig          `json:"network,omitempty"`
+	Temperature     TemperatureConfig      `json:"temperature,omitzero"`
+	FailedLogins    *FailedLoginsConfig    `json:"failed_logins,omitempty"`
+	Updates         *UpdatesConfig         `json:"updates,omitempty"`
+	Units           UnitsConfig            `json:"units,omitzero"`
 }
 
 type Config struct {
@@ -45,7 +117,31 @@ type Config struct {
 		Radarr   []ServiceConfig `json:"radarr"`
 		Seerr    []ServiceConfig `json:"seerr"`
 	} `json:"services"`
-	System SystemConfig `json:"system"`
+	System     SystemConfig      `json:"system"`
+	Daemon     *DaemonConfig     `json:"daemon,omitempty"`
+	MediaCache *MediaCacheConfig `json:"media_cache,omitempty"`
+	Thresholds ThresholdsConfig  `json:"thresholds,omitzero"`
+
+	// RenderBudget bounds a whole run, every collector, HTTP call and
+	// subprocess included. The -timeout flag overrides it.
+	RenderBudget string `json:"render_budget,omitempty"`
+}
+
+// DefaultRenderBudget applies when neither render_budget nor -timeout is set.
+const DefaultRenderBudget = 10 * time.Second
+
+// ParseRenderBudget parses a render_budget value. An empty value means
+// DefaultRenderBudget.
+func ParseRenderBudget(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return DefaultRenderBudget, nil
+	}
+	budget, err := time.ParseDuration(value)
+	if err != nil || budget <= 0 {
+		return 0, fmt.Errorf("render_budget must be a positive duration")
+	}
+	return budget, nil
 }
 
 var ErrNoJSONConfig = errors.New("no JSON config files found")
@@ -6,6 +6,7 @@ import (
 	"runtime"
 	"strings"
 	"testing"
+	"time"
 )
 
 func noDebug(_ string, _ ...interface{}) {}
@@ -378,3 +379,24 @@ func TestDetectLegacyYAML_MatchesJSONDirectory(t *testing.T) {
 		t.Fatalf("expected matching user JSON path, got %s", legacyErr.RequiredPath)
 	}
 }
+
+func TestParseRenderBudget(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"", DefaultRenderBudget, false},
+		{" 3s ", 3 * time.Second, false},
+		{"1500ms", 1500 * time.Millisecond, false},
+		{"0s", 0, true},
+		{"-1s", 0, true},
+		{"soon", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseRenderBudget(tt.value)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Fatalf("ParseRenderBudget(%q) = %v, %v; want %v, error %v", tt.value, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
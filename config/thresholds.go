@@ -0,0 +1,214 @@
+package config
+
+import (
+	"fmt"
+
+	"motd/display"
+)
+
+// Threshold holds the warn and critical levels for one reading. A reading
+// at or above a level reaches that severity; a nil level is never reached.
+type Threshold struct {
+	Warn     *float64 `json:"warn,omitempty"`
+	Critical *float64 `json:"critical,omitempty"`
+}
+
+// DiskThresholds applies to every mount unless Mounts has an entry for it.
+type DiskThresholds struct {
+	Threshold
+	Mounts map[string]Threshold `json:"mounts,omitempty"`
+}
+
+// ThresholdsConfig overrides the built-in alert levels. Disk, inode and
+// memory levels are used percentages, load is the 1-minute average divided by the core count,
+// temperature is in °C, zfs_capacity is the percentage of a pool allocated,
+// drive_wear is the NVMe percentage used, pressure is the 10-second PSI "some"
+// stall percentage, iowait and steal are percentages of CPU time, and the
+// media levels are counts.
+type ThresholdsConfig struct {
+	Disk        *DiskThresholds `json:"disk,omitempty"`
+	Inodes      *Threshold      `json:"inodes,omitempty"`
+	Memory      *Threshold      `json:"memory,omitempty"`
+	LoadPerCore *Threshold      `json:"load_per_core,omitempty"`
+	Temperature *Threshold      `json:"temperature,omitempty"`
+	ZFSCapacity *Threshold      `json:"zfs_capacity,omitempty"`
+	DriveWear   *Threshold      `json:"drive_wear,omitempty"`
+	Pressure    *Threshold      `json:"pressure,omitempty"`
+	IOWait      *Threshold      `json:"iowait,omitempty"`
+	Steal       *Threshold      `json:"steal,omitempty"`
+	Streams     *Threshold      `json:"streams,omitempty"`
+	Transcodes  *Threshold      `json:"transcodes,omitempty"`
+	Missing     *Threshold      `json:"missing,omitempty"`
+	Pending     *Threshold      `json:"pending,omitempty"`
+}
+
+func levels(warn, critical float64) Threshold {
+	return Threshold{Warn: &warn, Critical: &critical}
+}
+
+func warnOnly(warn float64) Threshold {
+	return Threshold{Warn: &warn}
+}
+
+var (
+	defaultDiskThreshold        = levels(85, 95)
+	defaultInodesThreshold      = levels(85, 95)
+	defaultMemoryThreshold      = levels(85, 95)
+	defaultLoadPerCoreThreshold = levels(1, 2)
+	defaultTemperatureThreshold = levels(70, 85)
+	defaultZFSCapacityThreshold = levels(80, 90)
+	defaultDriveWearThreshold   = levels(80, 100)
+	defaultPressureThreshold    = levels(10, 25)
+	defaultIOWaitThreshold      = levels(20, 40)
+	defaultStealThreshold       = levels(10, 25)
+	defaultTranscodesThreshold  = warnOnly(1)
+	defaultMissingThreshold     = warnOnly(1)
+	defaultPendingThreshold     = warnOnly(1)
+)
+
+// Evaluate returns the severity value reaches under t.
+func (t Threshold) Evaluate(value float64) display.Severity {
+	if t.Critical != nil && value >= *t.Critical {
+		return display.SeverityCritical
+	}
+	if t.Warn != nil && value >= *t.Warn {
+		return display.SeverityWarning
+	}
+	return display.SeverityOK
+}
+
+func thresholdOr(t *Threshold, fallback Threshold) Threshold {
+	if t == nil {
+		return fallback
+	}
+	return *t
+}
+
+// DiskLevels returns the levels for the mount at path.
+func (c ThresholdsConfig) DiskLevels(path string) Threshold {
+	if c.Disk == nil {
+		return defaultDiskThreshold
+	}
+	if mount, ok := c.Disk.Mounts[path]; ok {
+		return mount
+	}
+	if c.Disk.Warn == nil && c.Disk.Critical == nil {
+		return defaultDiskThreshold
+	}
+	return c.Disk.Threshold
+}
+
+func (c ThresholdsConfig) InodeLevels() Threshold {
+	return thresholdOr(c.Inodes, defaultInodesThreshold)
+}
+
+func (c ThresholdsConfig) MemoryLevels() Threshold {
+	return thresholdOr(c.Memory, defaultMemoryThreshold)
+}
+
+func (c ThresholdsConfig) LoadPerCoreLevels() Threshold {
+	return thresholdOr(c.LoadPerCore, defaultLoadPerCoreThreshold)
+}
+
+func (c ThresholdsConfig) TemperatureLevels() Threshold {
+	return thresholdOr(c.Temperature, defaultTemperatureThreshold)
+}
+
+// ZFSCapacityLevels defaults below the disk levels because ZFS write
+// performance falls off as a pool fills.
+func (c ThresholdsConfig) ZFSCapacityLevels() Threshold {
+	return thresholdOr(c.ZFSCapacity, defaultZFSCapacityThreshold)
+}
+
+// DriveWearLevels grades the NVMe percentage used. Drives keep working past
+// 100%, but the vendor no longer vouches for them.
+func (c ThresholdsConfig) DriveWearLevels() Threshold {
+	return thresholdOr(c.DriveWear, defaultDriveWearThreshold)
+}
+
+// PressureLevels grades the share of the last 10 seconds in which some task
+// stalled on CPU, memory or I/O.
+func (c ThresholdsConfig) PressureLevels() Threshold {
+	return thresholdOr(c.Pressure, defaultPressureThreshold)
+}
+
+// IOWaitLevels grades the share of CPU time spent waiting for I/O, which
+// points at slow or saturated storage.
+func (c ThresholdsConfig) IOWaitLevels() Threshold {
+	return thresholdOr(c.IOWait, defaultIOWaitThreshold)
+}
+
+// StealLevels grades the share of CPU time the hypervisor gave to other
+// guests, which points at an oversubscribed VM host.
+func (c ThresholdsConfig) StealLevels() Threshold {
+	return thresholdOr(c.Steal, defaultStealThreshold)
+}
+
+// StreamsLevels has no default: active streams alone are not a problem.
+func (c ThresholdsConfig) StreamsLevels() Threshold {
+	return thresholdOr(c.Streams, Threshold{})
+}
+
+func (c ThresholdsConfig) TranscodesLevels() Threshold {
+	return thresholdOr(c.Transcodes, defaultTranscodesThreshold)
+}
+
+func (c ThresholdsConfig) MissingLevels() Threshold {
+	return thresholdOr(c.Missing, defaultMissingThreshold)
+}
+
+func (c ThresholdsConfig) PendingLevels() Threshold {
+	return thresholdOr(c.Pending, defaultPendingThreshold)
+}
+
+// ValidateThresholds reports the first threshold that is negative or has a
+// warn level above its critical level.
+func ValidateThresholds(c ThresholdsConfig) error {
+	check := func(name string, t *Threshold) error {
+		if t == nil {
+			return nil
+		}
+		if (t.Warn != nil && *t.Warn < 0) || (t.Critical != nil && *t.Critical < 0) {
+			return fmt.Errorf("thresholds.%s levels must not be negative", name)
+		}
+		if t.Warn != nil && t.Critical != nil && *t.Warn > *t.Critical {
+			return fmt.Errorf("thresholds.%s.warn must not exceed critical", name)
+		}
+		return nil
+	}
+
+	if c.Disk != nil {
+		if err := check("disk", &c.Disk.Threshold); err != nil {
+			return err
+		}
+		for mount, t := range c.Disk.Mounts {
+			if err := check(fmt.Sprintf("disk.mounts[%q]", mount), &t); err != nil {
+				return err
+			}
+		}
+	}
+	named := []struct {
+		name string
+		t    *Threshold
+	}{
+		{"inodes", c.Inodes},
+		{"memory", c.Memory},
+		{"load_per_core", c.LoadPerCore},
+		{"temperature", c.Temperature},
+		{"zfs_capacity", c.ZFSCapacity},
+		{"drive_wear", c.DriveWear},
+		{"pressure", c.Pressure},
+		{"iowait", c.IOWait},
+		{"steal", c.Steal},
+		{"streams", c.Streams},
+		{"transcodes", c.Transcodes},
+		{"missing", c.Missing},
+		{"pending", c.Pending},
+	}
+	for _, item := range named {
+		if err := check(item.name, item.t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
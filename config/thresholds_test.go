@@ -0,0 +1,70 @@
+package config
+
+import (
+	"testing"
+
+	"motd/display"
+)
+
+func TestDecodeJSONConfig_Thresholds(t *testing.T) {
+	cfg, err := DecodeJSONConfig([]byte(`{
+		"thresholds": {
+			"disk": {"warn": 80, "critical": 90, "mounts": {"/mnt/tank": {"warn": 95, "critical": 99}}},
+			"memory": {"warn": 70},
+			"transcodes": {"warn": 2, "critical": 4}
+		}
+	}`))
+	if err != nil {
+		t.Fatalf("DecodeJSONConfig failed: %v", err)
+	}
+
+	th := cfg.Thresholds
+	if got := th.DiskLevels("/").Evaluate(85); got != display.SeverityWarning {
+		t.Fatalf("expected root disk warning at 85%%, got %q", got)
+	}
+	if got := th.DiskLevels("/mnt/tank").Evaluate(96); got != display.SeverityWarning {
+		t.Fatalf("expected tank override warning at 96%%, got %q", got)
+	}
+	if got := th.DiskLevels("/mnt/tank").Evaluate(99); got != display.SeverityCritical {
+		t.Fatalf("expected tank override critical at 99%%, got %q", got)
+	}
+	if got := th.MemoryLevels().Evaluate(99); got != display.SeverityWarning {
+		t.Fatalf("expected warn-only memory threshold to stop at warning, got %q", got)
+	}
+	if got := th.TranscodesLevels().Evaluate(1); got != display.SeverityOK {
+		t.Fatalf("expected single transcode to be ok with warn=2, got %q", got)
+	}
+}
+
+func TestThresholdDefaults(t *testing.T) {
+	var th ThresholdsConfig
+	if got := th.DiskLevels("/").Evaluate(99); got != display.SeverityCritical {
+		t.Fatalf("expected default disk critical at 99%%, got %q", got)
+	}
+	if got := th.TemperatureLevels().Evaluate(35); got != display.SeverityOK {
+		t.Fatalf("expected 35°C to be ok by default, got %q", got)
+	}
+	if got := th.StealLevels().Evaluate(30); got != display.SeverityCritical {
+		t.Fatalf("expected 30%% steal to be critical by default, got %q", got)
+	}
+	if got := th.StreamsLevels().Evaluate(20); got != display.SeverityOK {
+		t.Fatalf("expected streams to have no default levels, got %q", got)
+	}
+	if got := th.TranscodesLevels().Evaluate(1); got != display.SeverityWarning {
+		t.Fatalf("expected a transcode to warn by default, got %q", got)
+	}
+}
+
+func TestValidateThresholds(t *testing.T) {
+	warn, critical := 90.0, 80.0
+	if err := ValidateThresholds(ThresholdsConfig{Memory: &Threshold{Warn: &warn, Critical: &critical}}); err == nil {
+		t.Fatal("expected warn above critical to fail")
+	}
+	negative := -1.0
+	if err := ValidateThresholds(ThresholdsConfig{Disk: &DiskThresholds{Mounts: map[string]Threshold{"/": {Warn: &negative}}}}); err == nil {
+		t.Fatal("expected negative mount level to fail")
+	}
+	if err := ValidateThresholds(ThresholdsConfig{}); err != nil {
+		t.Fatalf("expected empty thresholds to be valid, got %v", err)
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"motd/config"
+	"motd/daemon"
+	"motd/display"
+	"motd/media"
+	"motd/system"
+)
+
+// daemonSnapshot returns the `motd daemon` snapshot when a daemon is
+// configured and its snapshot is fresh. The per-user collectors run here,
+// severities are re-evaluated with the caller's thresholds and media is
+// narrowed to the selected services.
+func daemonSnapshot(ctx context.Context, cfg config.Config, serviceSet map[string]bool, debug bool) (system.SystemSnapshot, []media.MediaStatus, bool) {
+	if cfg.Daemon == nil {
+		return system.SystemSnapshot{}, nil, false
+	}
+	settings, err := daemon.SettingsFrom(cfg.Daemon)
+	if err != nil {
+		display.DebugLog(debug, "Daemon snapshot unavailable: %v", err)
+		return system.SystemSnapshot{}, nil, false
+	}
+	snap, err := daemon.Fetch(ctx, settings.SocketPath, settings.MaxAge)
+	if err != nil {
+		display.DebugLog(debug, "Daemon snapshot unavailable, collecting live: %v", err)
+		return system.SystemSnapshot{}, nil, false
+	}
+	display.DebugLog(debug, "Using daemon snapshot observed at %s", snap.ObservedAt.Format("15:04:05"))
+	system.CollectPerUser(ctx, system.ConfigAccessorFrom(cfg), &snap.System, debug)
+	snap.System.Evaluate(cfg.Thresholds)
+	return snap.System, media.EvaluateStatuses(daemon.FilterMedia(snap.Media, serviceSet), cfg.Thresholds), true
+}
+
+func handleDaemon(args []string) {
+	fs := flagSet("daemon")
+	debug := fs.Bool("d", false, "Enable debug mode")
+	configPath := fs.String("config", "", "Load config from a specific JSON file")
+	if err := fs.Parse(args); err != nil {
+		os.Exit(2)
+	}
+	display.SetColorEnabled(false)
+
+	cfg, err := config.Load(*configPath, false, func(msg string, args ...interface{}) {
+		display.DebugLog(*debug, msg, args...)
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading configuration: %v\n", err)
+		os.Exit(1)
+	}
+	settings, err := daemon.SettingsFrom(cfg.Daemon)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	client := newHTTPClient()
+	server := daemon.NewServer(settings, func() daemon.Snapshot {
+		passCtx, cancel := budgetContext(cfg, 0, *debug)
+		defer cancel()
+		run := collectAll(passCtx, cfg, nil, client, false, *debug)
+		return daemon.NewSnapshot(run.snapshot, run.statuses)
+	}, *debug)
+	fmt.Fprintf(os.Stderr, "Serving snapshots on %s every %s\n", settings.SocketPath, settings.Interval)
+	if err := server.Run(ctx); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
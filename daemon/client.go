@@ -0,0 +1,144 @@
+package daemon
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"motd/media"
+)
+
+// Fetch reads the daemon snapshot from socketPath. It rejects malformed,
+// future-dated and stale snapshots so callers can fall back to live
+// collection on any error.
+func Fetch(ctx context.Context, socketPath string, maxAge time.Duration) (Snapshot, error) {
+	if !filepath.IsAbs(socketPath) {
+		return Snapshot{}, fmt.Errorf("daemon socket path must be absolute")
+	}
+	info, err := os.Lstat(socketPath)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	if info.Mode()&os.ModeSocket == 0 {
+		return Snapshot{}, fmt.Errorf("daemon path is not a Unix socket")
+	}
+
+	transport := &http.Transport{
+		DisableKeepAlives: true,
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", socketPath)
+		},
+	}
+	client := &http.Client{Transport: transport, Timeout: requestTimeout, CheckRedirect: func(_ *http.Request, _ []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	defer transport.CloseIdleConnections()
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://motd-daemon"+snapshotPath, nil)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	request.Header.Set("Accept", "application/json")
+	response, err := client.Do(request)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(response.Body, responseLimit+1))
+	if err != nil {
+		return Snapshot{}, err
+	}
+	if len(body) > responseLimit {
+		return Snapshot{}, fmt.Errorf("daemon response exceeds 4 MiB")
+	}
+	if response.StatusCode != http.StatusOK {
+		return Snapshot{}, fmt.Errorf("daemon returned HTTP %d", response.StatusCode)
+	}
+	if mediaType, _, err := mime.ParseMediaType(response.Header.Get("Content-Type")); err != nil || mediaType != "application/json" {
+		return Snapshot{}, fmt.Errorf("daemon returned non-JSON content")
+	}
+	return decodeSnapshot(body, maxAge, time.Now())
+}
+
+func decodeSnapshot(body []byte, maxAge time.Duration, now time.Time) (Snapshot, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return Snapshot{}, fmt.Errorf("decode daemon snapshot: %w", err)
+	}
+	for _, field := range []string{"protocol_version", "observed_at", "system", "media"} {
+		value, ok := raw[field]
+		if !ok {
+			return Snapshot{}, fmt.Errorf("daemon snapshot missing %s", field)
+		}
+		if field != "media" && bytes.Equal(bytes.TrimSpace(value), []byte("null")) {
+			return Snapshot{}, fmt.Errorf("daemon snapshot contains a null required field")
+		}
+	}
+
+	var decoded Snapshot
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	if err := decoder.Decode(&decoded); err != nil {
+		return Snapshot{}, fmt.Errorf("decode daemon snapshot: %w", err)
+	}
+	var extra struct{}
+	if err := decoder.Decode(&extra); err != io.EOF {
+		return Snapshot{}, fmt.Errorf("daemon snapshot contains trailing data")
+	}
+	if decoded.ProtocolVersion != protocolVersion {
+		return Snapshot{}, fmt.Errorf("unsupported daemon protocol version %d", decoded.ProtocolVersion)
+	}
+	if decoded.ObservedAt.IsZero() || decoded.ObservedAt.After(now.Add(futureSkew)) {
+		return Snapshot{}, fmt.Errorf("daemon snapshot timestamp is invalid")
+	}
+	if now.Sub(decoded.ObservedAt) > maxAge {
+		return Snapshot{}, fmt.Errorf("daemon snapshot is stale")
+	}
+	for _, status := range decoded.Media {
+		if err := validateMediaStatus(status); err != nil {
+			return Snapshot{}, err
+		}
+	}
+	decoded.System = decoded.System.Shared()
+	decoded.System.Containers = decoded.Containers
+	decoded.System.Units = decoded.Units
+	return decoded, nil
+}
+
+func validateMediaStatus(status media.MediaStatus) error {
+	if strings.TrimSpace(status.Name) == "" {
+		return fmt.Errorf("daemon snapshot contains an unnamed media service")
+	}
+	switch status.Kind {
+	case media.KindPlex, media.KindJellyfin, media.KindSonarr, media.KindRadarr, media.KindSeerr:
+	default:
+		return fmt.Errorf("daemon snapshot contains unknown media kind %q", status.Kind)
+	}
+	if status.Error == "" && status.Result.Kind != status.Kind {
+		return fmt.Errorf("daemon snapshot result kind does not match %q", status.Name)
+	}
+	return nil
+}
+
+// FilterMedia keeps statuses whose kind is in selected; a nil set keeps all.
+func FilterMedia(statuses []media.MediaStatus, selected map[string]bool) []media.MediaStatus {
+	if len(selected) == 0 {
+		return statuses
+	}
+	filtered := make([]media.MediaStatus, 0, len(statuses))
+	for _, status := range statuses {
+		if selected[string(status.Kind)] {
+			filtered = append(filtered, status)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,102 @@
+// Package daemon serves pre-collected status snapshots over a Unix socket so
+// that login banners can render without waiting on /proc reads or media
+// servers.
+package daemon
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"motd/config"
+	"motd/media"
+	"motd/system"
+)
+
+const (
+	DefaultSocket   = "/var/run/motd/daemon.sock"
+	DefaultInterval = 30 * time.Second
+	DefaultMaxAge   = 2 * time.Minute
+
+	// protocolVersion is bumped when a snapshot field is removed or changes
+	// meaning. Clients ignore fields they do not know, so a daemon that
+	// only adds fields keeps the version and older clients still use it.
+	protocolVersion = 1
+	snapshotPath    = "/v1/snapshot"
+	requestTimeout  = time.Second
+	responseLimit   = 4 * 1024 * 1024
+	futureSkew      = 5 * time.Second
+)
+
+// Snapshot is one daemon collection pass.
+type Snapshot struct {
+	ProtocolVersion int                     `json:"protocol_version"`
+	ObservedAt      time.Time               `json:"observed_at"`
+	System          system.SystemSnapshot   `json:"system"`
+	Containers      *system.ContainerStatus `json:"containers,omitempty"`
+	Units           *system.UnitStatus      `json:"units,omitempty"`
+	Media           []media.MediaStatus     `json:"media"`
+}
+
+// Settings is a DaemonConfig with defaults applied and durations parsed.
+type Settings struct {
+	SocketPath string
+	Interval   time.Duration
+	MaxAge     time.Duration
+}
+
+// SettingsFrom resolves cfg, which may be nil, into Settings.
+func SettingsFrom(cfg *config.DaemonConfig) (Settings, error) {
+	settings := Settings{SocketPath: DefaultSocket, Interval: DefaultInterval, MaxAge: DefaultMaxAge}
+	if cfg == nil {
+		return settings, nil
+	}
+	if path := strings.TrimSpace(cfg.SocketPath); path != "" {
+		if !filepath.IsAbs(path) {
+			return Settings{}, fmt.Errorf("daemon.socket_path must be absolute")
+		}
+		settings.SocketPath = path
+	}
+	if value := strings.TrimSpace(cfg.Interval); value != "" {
+		interval, err := time.ParseDuration(value)
+		if err != nil || interval < time.Second {
+			return Settings{}, fmt.Errorf("daemon.interval must be a duration of at least 1s")
+		}
+		settings.Interval = interval
+	}
+	if value := strings.TrimSpace(cfg.MaxAge); value != "" {
+		maxAge, err := time.ParseDuration(value)
+		if err != nil || maxAge <= 0 {
+			return Settings{}, fmt.Errorf("daemon.max_age must be a positive duration")
+		}
+		settings.MaxAge = maxAge
+	}
+	if settings.MaxAge < settings.Interval {
+		return Settings{}, fmt.Errorf("daemon.max_age must not be shorter than daemon.interval")
+	}
+	return settings, nil
+}
+
+// ValidateConfig reports whether cfg resolves to usable Settings.
+func ValidateConfig(cfg *config.DaemonConfig) error {
+	_, err := SettingsFrom(cfg)
+	return err
+}
+
+// NewSnapshot packages one collection pass as a Snapshot. Containers and
+// units get their own fields because SystemSnapshot does not serialize them.
+// Per-user details are dropped; clients collect those themselves.
+func NewSnapshot(snap system.SystemSnapshot, statuses []media.MediaStatus) Snapshot {
+	if statuses == nil {
+		statuses = []media.MediaStatus{}
+	}
+	return Snapshot{
+		ProtocolVersion: protocolVersion,
+		ObservedAt:      time.Now().UTC(),
+		System:          snap.Shared(),
+		Containers:      snap.Containers,
+		Units:           snap.Units,
+		Media:           statuses,
+	}
+}
@@ -0,0 +1,226 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"motd/config"
+	"motd/media"
+	"motd/system"
+)
+
+func TestServerRoundTrip(t *testing.T) {
+	directory := tempSocketDir(t)
+	settings := Settings{SocketPath: filepath.Join(directory, "daemon.sock"), Interval: time.Hour, MaxAge: time.Hour}
+	server := NewServer(settings, func() Snapshot {
+		return Snapshot{
+			ProtocolVersion: protocolVersion,
+			ObservedAt:      time.Now().UTC(),
+			System:          system.SystemSnapshot{Memory: &system.MemoryInfo{TotalBytes: 100, UsedBytes: 25, UsedPercent: 25}},
+			Containers:      &system.ContainerStatus{ProtocolVersion: 1, Online: 1, Total: 1, Status: "All workloads online"},
+			Units:           &system.UnitStatus{Active: 1, Total: 2, Status: "1 of 2 active"},
+			Media: []media.MediaStatus{
+				{Name: "Plex", Kind: media.KindPlex, Result: media.Result{Kind: media.KindPlex, Streams: 2}},
+				{Name: "Sonarr", Kind: media.KindSonarr, Error: media.ErrorTimeout, Detail: "timeout"},
+			},
+		}
+	}, false)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- server.Run(ctx) }()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	var snap Snapshot
+	var err error
+	for attempt := 0; attempt < 50; attempt++ {
+		if snap, err = Fetch(context.Background(), settings.SocketPath, settings.MaxAge); err == nil {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+	if snap.System.Memory == nil || snap.System.Memory.UsedBytes != 25 {
+		t.Fatalf("unexpected system snapshot: %+v", snap.System)
+	}
+	if snap.System.Containers == nil || snap.System.Containers.Online != 1 {
+		t.Fatalf("expected containers to be restored into the system snapshot: %+v", snap.System.Containers)
+	}
+	if snap.System.Units == nil || snap.System.Units.Status != "1 of 2 active" {
+		t.Fatalf("expected units to be restored into the system snapshot: %+v", snap.System.Units)
+	}
+	if len(snap.Media) != 2 || snap.Media[0].Result.Streams != 2 || snap.Media[1].Error != media.ErrorTimeout {
+		t.Fatalf("unexpected media: %+v", snap.Media)
+	}
+}
+
+func TestSnapshotLeavesOutPerUserFields(t *testing.T) {
+	login := time.Date(2026, 10, 13, 9, 12, 0, 0, time.UTC)
+	snap := NewSnapshot(system.SystemSnapshot{Users: &system.UserInfo{
+		Count:     1,
+		Sessions:  []system.UserSession{{User: "alice", TTY: "pts/0", Host: "192.0.2.7", LoginTime: login}},
+		LastLogin: &system.LastLogin{User: "root", TTY: "pts/1", Host: "198.51.100.4", Time: login},
+	}, FailedLogins: &system.FailedLogins{Count: 3, TopSources: []system.FailedLoginSource{{Address: "203.0.113.9", Count: 3}}}}, nil)
+	body, err := json.Marshal(snap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(body), "192.0.2.7") || strings.Contains(string(body), "last_login") || strings.Contains(string(body), "failed_logins") {
+		t.Fatalf("expected per-user fields to be left out, got %s", body)
+	}
+
+	// A snapshot from an older daemon is stripped on decode as well.
+	leaky := fmt.Sprintf(`{"protocol_version":1,"observed_at":%q,"system":{"users":{"count":1,"sessions":[{"user":"alice","tty":"pts/0","host":"192.0.2.7","login_time":%q}],"last_login":{"user":"root","tty":"pts/1","time":%q,"logins_since":0}},"failed_logins":{"count":3,"since":%q,"since_last_login":true,"source":"/var/log/btmp","top_sources":[]}},"media":[]}`,
+		snap.ObservedAt.Format(time.RFC3339Nano), login.Format(time.RFC3339), login.Format(time.RFC3339), login.Format(time.RFC3339))
+	for _, payload := range [][]byte{body, []byte(leaky)} {
+		decoded, err := decodeSnapshot(payload, time.Minute, time.Now())
+		if err != nil {
+			t.Fatalf("decodeSnapshot failed: %v", err)
+		}
+		users := decoded.System.Users
+		if users == nil || users.Count != 1 || len(users.Sessions) != 1 {
+			t.Fatalf("expected the session count to survive, got %+v", users)
+		}
+		if users.LastLogin != nil || users.Sessions[0].Host != "" || decoded.System.FailedLogins != nil {
+			t.Fatalf("expected no per-user fields, got %+v, %+v", users, decoded.System.FailedLogins)
+		}
+	}
+}
+
+func TestDecodeSnapshotRejectsInvalidPayloads(t *testing.T) {
+	now := time.Date(2026, 8, 18, 12, 0, 0, 0, time.UTC)
+	fresh := now.Add(-10 * time.Second).Format(time.RFC3339Nano)
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"missing media", fmt.Sprintf(`{"protocol_version":1,"observed_at":%q,"system":{}}`, fresh)},
+		{"null system", fmt.Sprintf(`{"protocol_version":1,"observed_at":%q,"system":null,"media":[]}`, fresh)},
+		{"wrong protocol", fmt.Sprintf(`{"protocol_version":2,"observed_at":%q,"system":{},"media":[]}`, fresh)},
+		{"stale", `{"protocol_version":1,"observed_at":"2026-08-18T11:00:00Z","system":{},"media":[]}`},
+		{"future", `{"protocol_version":1,"observed_at":"2026-08-18T13:00:00Z","system":{},"media":[]}`},
+		{"trailing data", fmt.Sprintf(`{"protocol_version":1,"observed_at":%q,"system":{},"media":[]} {}`, fresh)},
+		{"unknown kind", fmt.Sprintf(`{"protocol_version":1,"observed_at":%q,"system":{},"media":[{"name":"X","kind":"emby","result":{"kind":"emby"}}]}`, fresh)},
+	}
+	for _, tt := range tests {
+		if _, err := decodeSnapshot([]byte(tt.body), time.Minute, now); err == nil {
+			t.Fatalf("%s: expected decode to fail", tt.name)
+		}
+	}
+
+	for _, valid := range []string{
+		fmt.Sprintf(`{"protocol_version":1,"observed_at":%q,"system":{},"media":[]}`, fresh),
+		fmt.Sprintf(`{"protocol_version":1,"observed_at":%q,"system":{"new_collector":{}},"media":[],"extra":1}`, fresh),
+	} {
+		if _, err := decodeSnapshot([]byte(valid), time.Minute, now); err != nil {
+			t.Fatalf("expected %s to decode, got %v", valid, err)
+		}
+	}
+}
+
+func TestFetchRejectsNonJSONContent(t *testing.T) {
+	directory := tempSocketDir(t)
+	socket := filepath.Join(directory, "daemon.sock")
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatalf("listen on test socket: %v", err)
+	}
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		_, _ = fmt.Fprint(w, "ok")
+	})}
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(func() { _ = server.Close() })
+
+	if _, err := Fetch(context.Background(), socket, time.Minute); err == nil || !strings.Contains(err.Error(), "non-JSON") {
+		t.Fatalf("expected non-JSON error, got %v", err)
+	}
+}
+
+func TestListenSocketRefusesRegularFile(t *testing.T) {
+	path := filepath.Join(tempSocketDir(t), "daemon.sock")
+	if err := os.WriteFile(path, []byte("keep"), 0o600); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	if _, err := listenSocket(path); err == nil {
+		t.Fatal("expected a regular file at the socket path to be refused")
+	}
+	if data, _ := os.ReadFile(path); string(data) != "keep" {
+		t.Fatal("expected the regular file to be left alone")
+	}
+}
+
+func TestListenSocketRefusesLiveDaemon(t *testing.T) {
+	path := filepath.Join(tempSocketDir(t), "daemon.sock")
+	running, err := listenSocket(path)
+	if err != nil {
+		t.Fatalf("listenSocket: %v", err)
+	}
+	defer running.Close()
+	if _, err := listenSocket(path); err == nil {
+		t.Fatal("expected a socket with a live daemon to be refused")
+	}
+	if _, err := os.Lstat(path); err != nil {
+		t.Fatalf("expected the live daemon's socket to be left alone: %v", err)
+	}
+}
+
+func TestListenSocketReplacesStaleSocket(t *testing.T) {
+	path := filepath.Join(tempSocketDir(t), "daemon.sock")
+	stale, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+
+	listener, err := listenSocket(path)
+	if err != nil {
+		t.Fatalf("expected the stale socket to be replaced: %v", err)
+	}
+	listener.Close()
+}
+
+func TestSettingsFrom(t *testing.T) {
+	settings, err := SettingsFrom(nil)
+	if err != nil || settings.SocketPath != DefaultSocket || settings.Interval != DefaultInterval || settings.MaxAge != DefaultMaxAge {
+		t.Fatalf("unexpected defaults: %+v %v", settings, err)
+	}
+	settings, err = SettingsFrom(&config.DaemonConfig{SocketPath: "/run/motd.sock", Interval: "10s", MaxAge: "45s"})
+	if err != nil || settings.SocketPath != "/run/motd.sock" || settings.Interval != 10*time.Second || settings.MaxAge != 45*time.Second {
+		t.Fatalf("unexpected settings: %+v %v", settings, err)
+	}
+	for _, cfg := range []config.DaemonConfig{
+		{SocketPath: "relative.sock"},
+		{Interval: "100ms"},
+		{MaxAge: "soon"},
+		{Interval: "1m", MaxAge: "30s"},
+	} {
+		if err := ValidateConfig(&cfg); err == nil {
+			t.Fatalf("expected %+v to be rejected", cfg)
+		}
+	}
+}
+
+func tempSocketDir(t *testing.T) string {
+	t.Helper()
+	directory, err := os.MkdirTemp("/tmp", "motd-daemon-test-")
+	if err != nil {
+		t.Fatalf("create test socket directory: %v", err)
+	}
+	t.Cleanup(func() { _ = os.RemoveAll(directory) })
+	return directory
+}
@@ -0,0 +1,141 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+
+	"motd/display"
+)
+
+// Server keeps the latest snapshot and serves it to motd clients.
+type Server struct {
+	settings Settings
+	collect  func() Snapshot
+	debug    bool
+
+	mu     sync.RWMutex
+	latest []byte
+}
+
+func NewServer(settings Settings, collect func() Snapshot, debug bool) *Server {
+	return &Server{settings: settings, collect: collect, debug: debug}
+}
+
+// Refresh collects a new snapshot and makes it the one served.
+func (s *Server) Refresh() error {
+	data, err := json.Marshal(s.collect())
+	if err != nil {
+		return fmt.Errorf("encode snapshot: %w", err)
+	}
+	s.mu.Lock()
+	s.latest = data
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+snapshotPath, func(w http.ResponseWriter, _ *http.Request) {
+		s.mu.RLock()
+		data := s.latest
+		s.mu.RUnlock()
+		if data == nil {
+			http.Error(w, "no snapshot collected yet", http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(data)
+	})
+	return mux
+}
+
+// Run listens on the configured socket, refreshes the snapshot every
+// interval and serves it until ctx is cancelled. The socket is world
+// connectable because the snapshot holds only what the banner already shows
+// to every user who logs in: it never contains credentials, and per-user
+// details such as the last login are left to each client.
+func (s *Server) Run(ctx context.Context) error {
+	listener, err := listenSocket(s.settings.SocketPath)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(s.settings.SocketPath)
+
+	server := &http.Server{Handler: s.Handler(), ReadHeaderTimeout: requestTimeout}
+	serveErr := make(chan error, 1)
+	go func() { serveErr <- server.Serve(listener) }()
+
+	if err := s.Refresh(); err != nil {
+		display.DebugLog(s.debug, "Daemon refresh failed: %v", err)
+	}
+	ticker := time.NewTicker(s.settings.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+			defer cancel()
+			_ = server.Shutdown(shutdownCtx)
+			return nil
+		case err := <-serveErr:
+			if errors.Is(err, http.ErrServerClosed) {
+				return nil
+			}
+			return err
+		case <-ticker.C:
+			if err := s.Refresh(); err != nil {
+				display.DebugLog(s.debug, "Daemon refresh failed: %v", err)
+			}
+		}
+	}
+}
+
+// listenSocket creates the Unix socket at path. A socket that still accepts
+// connections belongs to a running daemon and is left to it; a stale one left
+// by a daemon that exited is replaced, and any other kind of file is left
+// alone.
+func listenSocket(path string) (net.Listener, error) {
+	if !filepath.IsAbs(path) {
+		return nil, fmt.Errorf("daemon socket path must be absolute")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("create socket directory: %w", err)
+	}
+	if info, err := os.Lstat(path); err == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("%s exists and is not a socket", path)
+		}
+		conn, err := net.DialTimeout("unix", path, time.Second)
+		if err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("another daemon is already serving %s", path)
+		}
+		if !errors.Is(err, syscall.ECONNREFUSED) {
+			return nil, fmt.Errorf("check existing socket: %w", err)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("remove stale socket: %w", err)
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, 0o666); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("set socket permissions: %w", err)
+	}
+	return listener, nil
+}
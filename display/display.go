@@ -2,6 +2,7 @@ package display
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"os"
 	"regexp"
@@ -71,7 +72,9 @@ func shortHostname(hostname string) string {
 	return hostname
 }
 
-func PrintHeader() {
+// PrintHeader prints the hostname banner. figlet runs under ctx; when it
+// fails or is cut off, the boxed fallback is printed instead.
+func PrintHeader(ctx context.Context) {
 	fmt.Println()
 
 	hostname, err := os.Hostname()
@@ -81,7 +84,7 @@ func PrintHeader() {
 	hostname = shortHostname(hostname)
 
 	if hasFiglet() && safeHostnameRe.MatchString(hostname) {
-		cmd, err := util.SafeCommand("figlet", hostname)
+		cmd, err := util.SafeCommandContext(ctx, "figlet", hostname)
 		if err == nil {
 			output, err := cmd.Output()
 			if err == nil && len(output) > 0 {
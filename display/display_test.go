@@ -104,3 +104,21 @@ func TestPrintSectionFormat(t *testing.T) {
 		t.Fatal("test data should not contain section markers")
 	}
 }
+
+func TestWorstSeverity(t *testing.T) {
+	if got := WorstSeverity(SeverityOK, SeverityCritical, SeverityWarning); got != SeverityCritical {
+		t.Fatalf("expected critical, got %q", got)
+	}
+	if got := WorstSeverity(SeverityOK, SeverityUnknown); got != SeverityUnknown {
+		t.Fatalf("expected unknown to outrank ok, got %q", got)
+	}
+	if got := WorstSeverity(); got != "" {
+		t.Fatalf("expected empty severity without input, got %q", got)
+	}
+}
+
+func TestSeverityColor(t *testing.T) {
+	if SeverityOK.Color() != Green || SeverityWarning.Color() != Yellow || SeverityCritical.Color() != Red || Severity("").Color() != Blue {
+		t.Fatal("unexpected severity colors")
+	}
+}
@@ -0,0 +1,52 @@
+package display
+
+// Severity ranks how urgently a reading needs attention. The empty value
+// means the reading has no thresholds and is rendered in the neutral color.
+type Severity string
+
+const (
+	SeverityOK       Severity = "ok"
+	SeverityWarning  Severity = "warning"
+	SeverityCritical Severity = "critical"
+	SeverityUnknown  Severity = "unknown"
+)
+
+// Color returns the terminal color for s.
+func (s Severity) Color() string {
+	switch s {
+	case SeverityOK:
+		return Green
+	case SeverityWarning, SeverityUnknown:
+		return Yellow
+	case SeverityCritical:
+		return Red
+	default:
+		return Blue
+	}
+}
+
+func (s Severity) rank() int {
+	switch s {
+	case SeverityOK:
+		return 1
+	case SeverityUnknown:
+		return 2
+	case SeverityWarning:
+		return 3
+	case SeverityCritical:
+		return 4
+	default:
+		return 0
+	}
+}
+
+// WorstSeverity returns the most urgent of the given severities.
+func WorstSeverity(severities ...Severity) Severity {
+	worst := Severity("")
+	for _, s := range severities {
+		if s.rank() > worst.rank() {
+			worst = s
+		}
+	}
+	return worst
+}
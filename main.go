@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"net/http"
@@ -34,6 +35,7 @@ func main() {
 	jsonOutput := flag.Bool("json", false, "Output machine-readable JSON")
 	noColor := flag.Bool("no-color", false, "Disable ANSI colors")
 	servicesFilter := flag.String("services", "", "Only show selected media services (comma-separated)")
+	timeout := flag.Duration("timeout", 0, "Time budget for the whole run (default: render_budget or 10s)")
 	flag.Parse()
 
 	if *noColor || *jsonOutput || os.Getenv("NO_COLOR") != "" {
@@ -50,14 +52,7 @@ func main() {
 		return
 	}
 
-	client := &http.Client{
-		Timeout: curlTimeout,
-		Transport: &http.Transport{
-			MaxIdleConns:       10,
-			IdleConnTimeout:    30 * time.Second,
-			DisableCompression: false,
-		},
-	}
+	client := newHTTPClient()
 
 	cfg, err := config.Load(*configPath, *noConfig, func(msg string, args ...interface{}) {
 		display.DebugLog(*debug, msg, args...)
@@ -83,30 +78,44 @@ func main() {
 		os.Exit(1)
 	}
 
+	ctx, cancel := budgetContext(cfg, *timeout, *debug)
+	defer cancel()
+
 	if *jsonOutput {
-		renderJSON(cfg, serviceSet, client, *debug)
+		renderJSON(ctx, cfg, *configPath, serviceSet, client, *debug)
 		return
 	}
 
-	display.PrintHeader()
+	// Collection starts before the header so the update check overlaps it.
+	snapshot, statuses, fromDaemon := daemonSnapshot(ctx, cfg, serviceSet, *debug)
+	var pending chan collection
+	if !fromDaemon {
+		pending = make(chan collection, 1)
+		go func() { pending <- collectAll(ctx, cfg, serviceSet, client, true, *debug) }()
+	}
+
+	display.PrintHeader(ctx)
 
-	if msg := update.CheckUpdate(VERSION, client); msg != "" {
+	if msg := update.CheckUpdate(ctx, VERSION, client); msg != "" {
 		fmt.Printf("%s⚠ %s%s\n\n", display.Yellow, msg, display.Reset)
 	}
 
+	if !fromDaemon {
+		run := <-pending
+		snapshot, statuses = run.snapshot, run.statuses
+		if run.needsRefresh {
+			startMediaCacheRefresh(cfg, *configPath, *debug)
+		}
+	}
+
 	display.PrintSection("System Information")
 
-	sysCfg := system.ConfigAccessorFrom(cfg)
-	showPlatformSystemInfo(sysCfg, *debug)
+	showPlatformSystemInfo(snapshot)
 
 	display.PrintSection("Services & Resources")
 
-	system.ShowContainers(sysCfg, *debug)
-	system.ShowProcesses(sysCfg, *debug)
-	system.ShowUser(sysCfg, *debug)
-	system.ShowDisk(sysCfg, *debug)
-	system.ShowTemp(sysCfg, *debug)
-	media.ShowMediaServices(cfg, serviceSet, client, *debug)
+	system.Render(snapshot.ResourceMetrics()...)
+	media.RenderMediaStatuses(statuses)
 
 	fmt.Println()
 }
@@ -126,17 +135,58 @@ func handleSubcommand() bool {
 	case "check-config":
 		handleCheckConfig(os.Args[2:])
 		return true
+	case "check":
+		handleCheck(os.Args[2:])
+		return true
+	case "serve-metrics":
+		handleServeMetrics(os.Args[2:])
+		return true
+	case "textfile":
+		handleTextfile(os.Args[2:])
+		return true
+	case "daemon":
+		handleDaemon(os.Args[2:])
+		return true
+	case "refresh-media-cache":
+		handleRefreshMediaCache(os.Args[2:])
+		return true
+	case "refresh-updates-cache":
+		handleRefreshUpdatesCache(os.Args[2:])
+		return true
 	default:
 		return false
 	}
 }
 
-func showPlatformSystemInfo(cfg system.ConfigAccessor, debug bool) {
-	system.ShowOS(cfg, debug)
-	system.ShowUptime(cfg, debug)
-	system.ShowLoad(cfg, debug)
-	system.ShowMemory(cfg, debug)
-	system.ShowBandwidth(cfg, debug)
+func newHTTPClient() *http.Client {
+	return &http.Client{
+		Timeout: curlTimeout,
+		Transport: &http.Transport{
+			MaxIdleConns:       10,
+			IdleConnTimeout:    30 * time.Second,
+			DisableCompression: false,
+		},
+	}
+}
+
+// budgetContext returns a context that expires after the run's time budget:
+// override when positive, otherwise render_budget or its default. An invalid
+// render_budget falls back to the default; check-config reports it.
+func budgetContext(cfg config.Config, override time.Duration, debug bool) (context.Context, context.CancelFunc) {
+	budget := override
+	if budget <= 0 {
+		var err error
+		if budget, err = config.ParseRenderBudget(cfg.RenderBudget); err != nil {
+			display.DebugLog(debug, "Using default time budget: %v", err)
+			budget = config.DefaultRenderBudget
+		}
+	}
+	display.DebugLog(debug, "Time budget: %s", budget)
+	return context.WithTimeout(context.Background(), budget)
+}
+
+func showPlatformSystemInfo(snapshot system.SystemSnapshot) {
+	system.Render(snapshot.SystemMetrics()...)
 }
 
 func usage() {
@@ -152,11 +202,16 @@ Options:
   -json           Output machine-readable JSON
   -no-color       Disable ANSI colors
   -services LIST  Only show selected media services (comma-separated)
+  -timeout DUR    Time budget for the whole run (default: render_budget or 10s)
 
 Commands:
   self-update     Update to the latest version from GitHub releases
   configure       Create or edit the config file
   check-config    Validate configuration and print diagnostics
+  check           Run as a monitoring plugin (exit 0/1/2/3 with perfdata)
+  serve-metrics   Serve OpenMetrics on /metrics (-listen, -interval)
+  textfile        Write metrics for the node_exporter textfile collector (-output)
+  daemon          Collect on an interval and serve snapshots for instant logins
 
 Configuration Files:
   Optional; only required for media integrations or custom system paths.
@@ -0,0 +1,291 @@
+package media
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"motd/config"
+	"motd/display"
+	"motd/util"
+)
+
+const (
+	defaultCacheTTL      = time.Minute
+	defaultCacheMaxStale = time.Hour
+	cacheFilePrefix      = "media-"
+	refreshLockFile      = "media-refresh.lock"
+	refreshLockMaxAge    = 2 * time.Minute
+)
+
+// configuredService is implemented by services built from a ServiceConfig.
+type configuredService interface {
+	serviceConfig() config.ServiceConfig
+}
+
+func (s plexService) serviceConfig() config.ServiceConfig     { return s.cfg }
+func (s jellyfinService) serviceConfig() config.ServiceConfig { return s.cfg }
+func (s sonarrService) serviceConfig() config.ServiceConfig   { return s.cfg }
+func (s radarrService) serviceConfig() config.ServiceConfig   { return s.cfg }
+func (s seerrService) serviceConfig() config.ServiceConfig    { return s.cfg }
+
+type cacheEntry struct {
+	CheckedAt time.Time   `json:"checked_at"`
+	Status    MediaStatus `json:"status"`
+}
+
+// resultCache stores one file per service instance under dir.
+type resultCache struct {
+	dir      string
+	ttl      time.Duration
+	maxStale time.Duration
+	now      func() time.Time
+}
+
+// newResultCache returns nil when the cache is not configured or no user
+// cache directory is available.
+func newResultCache(cfg config.Config) *resultCache {
+	if cfg.MediaCache == nil {
+		return nil
+	}
+	ttl, maxStale, err := cacheDurations(cfg.MediaCache)
+	if err != nil {
+		return nil
+	}
+	dir := defaultCacheDir()
+	if dir == "" {
+		return nil
+	}
+	return &resultCache{dir: dir, ttl: ttl, maxStale: maxStale, now: time.Now}
+}
+
+func defaultCacheDir() string {
+	cache, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	dir := filepath.Join(cache, "motd")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return ""
+	}
+	return dir
+}
+
+func cacheDurations(cfg *config.MediaCacheConfig) (time.Duration, time.Duration, error) {
+	ttl, maxStale := defaultCacheTTL, defaultCacheMaxStale
+	if value := strings.TrimSpace(cfg.TTL); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed <= 0 {
+			return 0, 0, fmt.Errorf("media_cache.ttl must be a positive duration")
+		}
+		ttl = parsed
+	}
+	if value := strings.TrimSpace(cfg.MaxStale); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed <= 0 {
+			return 0, 0, fmt.Errorf("media_cache.max_stale must be a positive duration")
+		}
+		maxStale = parsed
+	}
+	if maxStale < ttl {
+		return 0, 0, fmt.Errorf("media_cache.max_stale must not be shorter than media_cache.ttl")
+	}
+	return ttl, maxStale, nil
+}
+
+// ValidateCacheConfig reports invalid media_cache settings and per-service
+// cache_ttl values.
+func ValidateCacheConfig(cfg config.Config) error {
+	if cfg.MediaCache != nil {
+		if _, _, err := cacheDurations(cfg.MediaCache); err != nil {
+			return err
+		}
+	}
+	kinds := []struct {
+		name     string
+		services []config.ServiceConfig
+	}{
+		{"plex", cfg.Services.Plex},
+		{"jellyfin", cfg.Services.Jellyfin},
+		{"sonarr", cfg.Services.Sonarr},
+		{"radarr", cfg.Services.Radarr},
+		{"seerr", cfg.Services.Seerr},
+	}
+	for _, kind := range kinds {
+		for i, svc := range kind.services {
+			if value := strings.TrimSpace(svc.CacheTTL); value != "" {
+				if parsed, err := time.ParseDuration(value); err != nil || parsed <= 0 {
+					return fmt.Errorf("%s[%d].cache_ttl must be a positive duration", kind.name, i)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// ttlFor returns the service's cache_ttl, or the cache-wide TTL.
+func (c *resultCache) ttlFor(svc Service) time.Duration {
+	if configured, ok := svc.(configuredService); ok {
+		if value := strings.TrimSpace(configured.serviceConfig().CacheTTL); value != "" {
+			if ttl, err := time.ParseDuration(value); err == nil && ttl > 0 {
+				return ttl
+			}
+		}
+	}
+	return c.ttl
+}
+
+// path names the entry after a hash of everything that identifies the
+// instance, credentials included, so fixing a token invalidates old results.
+func (c *resultCache) path(svc Service) string {
+	key := []string{string(svc.Kind()), svc.Name()}
+	if configured, ok := svc.(configuredService); ok {
+		cfg := configured.serviceConfig()
+		key = append(key, cfg.URL, cfg.Token, cfg.APIKey)
+	}
+	sum := sha256.Sum256([]byte(strings.Join(key, "\x00")))
+	return filepath.Join(c.dir, cacheFilePrefix+hex.EncodeToString(sum[:16])+".json")
+}
+
+func (c *resultCache) load(svc Service) (cacheEntry, bool) {
+	data, err := os.ReadFile(c.path(svc))
+	if err != nil {
+		return cacheEntry{}, false
+	}
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil || entry.CheckedAt.IsZero() {
+		return cacheEntry{}, false
+	}
+	if entry.Status.Kind != svc.Kind() || entry.Status.Name != svc.Name() {
+		return cacheEntry{}, false
+	}
+	return entry, true
+}
+
+// store skips statuses cut off by the time budget; they say nothing about
+// the service.
+func (c *resultCache) store(svc Service, status MediaStatus) {
+	if status.Error == ErrorTimedOut {
+		return
+	}
+	status.Stale = false
+	data, err := json.Marshal(cacheEntry{CheckedAt: status.CheckedAt, Status: status})
+	if err != nil {
+		return
+	}
+	_ = config.AtomicWriteFile(c.path(svc), data, 0600)
+}
+
+// CollectCachedMediaStatuses is CollectMediaStatuses backed by the on-disk
+// cache. Fresh entries are reused, stale entries are returned with Stale set,
+// and missing or expired entries are checked live. needsRefresh reports that
+// at least one stale entry should be refreshed in the background.
+func CollectCachedMediaStatuses(ctx context.Context, cfg config.Config, selected map[string]bool, client *http.Client, pool *util.Pool, debug bool) (statuses []MediaStatus, needsRefresh bool) {
+	cache := newResultCache(cfg)
+	if cache == nil {
+		return CollectMediaStatuses(ctx, cfg, selected, client, pool, debug), false
+	}
+	statuses, needsRefresh = cache.collect(ctx, allServices(cfg, selected, debug), client, pool, debug)
+	return EvaluateStatuses(statuses, cfg.Thresholds), needsRefresh
+}
+
+func (c *resultCache) collect(ctx context.Context, services []Service, client *http.Client, pool *util.Pool, debug bool) ([]MediaStatus, bool) {
+	statuses := make([]MediaStatus, len(services))
+	var live []Service
+	var liveIndex []int
+	needsRefresh := false
+	now := c.now()
+
+	for i, svc := range services {
+		entry, ok := c.load(svc)
+		age := now.Sub(entry.CheckedAt)
+		switch {
+		case ok && age < c.ttlFor(svc):
+			statuses[i] = entry.Status
+		case ok && age < c.maxStale:
+			statuses[i] = entry.Status
+			statuses[i].Stale = true
+			needsRefresh = true
+		default:
+			live = append(live, svc)
+			liveIndex = append(liveIndex, i)
+		}
+		statuses[i].Order = i
+	}
+
+	for j, status := range collectMediaStatuses(ctx, live, client, pool, debug) {
+		i := liveIndex[j]
+		status.Order = i
+		c.store(services[i], status)
+		statuses[i] = status
+	}
+	display.DebugLog(debug, "Media cache: %d checked live, stale refresh needed: %v", len(live), needsRefresh)
+	return statuses, needsRefresh
+}
+
+// RefreshMediaCache re-checks every cached service that is no longer fresh
+// and releases the refresh lock taken by StartMediaCacheRefresh.
+func RefreshMediaCache(ctx context.Context, cfg config.Config, client *http.Client, debug bool) {
+	cache := newResultCache(cfg)
+	if cache == nil {
+		return
+	}
+	defer os.Remove(filepath.Join(cache.dir, refreshLockFile))
+
+	services := allServices(cfg, nil, debug)
+	var due []Service
+	now := cache.now()
+	for _, svc := range services {
+		if entry, ok := cache.load(svc); ok && now.Sub(entry.CheckedAt) < cache.ttlFor(svc) {
+			continue
+		}
+		due = append(due, svc)
+	}
+	for i, status := range collectMediaStatuses(ctx, due, client, nil, debug) {
+		cache.store(due[i], status)
+	}
+}
+
+// StartMediaCacheRefresh takes the refresh lock and runs spawn, which should
+// start a detached process that calls RefreshMediaCache. It does nothing
+// when another refresh holds a recent lock.
+func StartMediaCacheRefresh(cfg config.Config, spawn func() error) error {
+	cache := newResultCache(cfg)
+	if cache == nil {
+		return nil
+	}
+	lockPath := filepath.Join(cache.dir, refreshLockFile)
+	if !acquireRefreshLock(lockPath, cache.now()) {
+		return nil
+	}
+	if err := spawn(); err != nil {
+		os.Remove(lockPath)
+		return err
+	}
+	return nil
+}
+
+// acquireRefreshLock takes the media refresh lock at lockPath.
+func acquireRefreshLock(lockPath string, now time.Time) bool {
+	return util.AcquireLockFile(lockPath, now, refreshLockMaxAge)
+}
+
+// formatAge renders how long ago a cached result was checked, for example
+// "45s", "3m" or "2h".
+func formatAge(age time.Duration) string {
+	switch {
+	case age < time.Minute:
+		return fmt.Sprintf("%ds", int(age.Seconds()))
+	case age < time.Hour:
+		return fmt.Sprintf("%dm", int(age.Minutes()))
+	default:
+		return fmt.Sprintf("%dh", int(age.Hours()))
+	}
+}
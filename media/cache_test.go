@@ -0,0 +1,145 @@
+package media
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"motd/config"
+)
+
+type countingTestService struct {
+	name  string
+	calls *atomic.Int32
+}
+
+func (s countingTestService) Name() string { return s.name }
+func (s countingTestService) Kind() Kind   { return KindSonarr }
+
+func (s countingTestService) Check(context.Context, *http.Client) (Result, error) {
+	s.calls.Add(1)
+	return Result{Kind: KindSonarr, Missing: int(s.calls.Load())}, nil
+}
+
+func testResultCache(t *testing.T, now time.Time) *resultCache {
+	t.Helper()
+	return &resultCache{dir: t.TempDir(), ttl: time.Minute, maxStale: time.Hour, now: func() time.Time { return now }}
+}
+
+func TestResultCacheServesFreshStaleAndExpiredEntries(t *testing.T) {
+	now := time.Now()
+	cache := testResultCache(t, now)
+	var calls atomic.Int32
+	svc := countingTestService{name: "Sonarr", calls: &calls}
+
+	statuses, refresh := cache.collect(context.Background(), []Service{svc}, nil, nil, false)
+	if calls.Load() != 1 || refresh || statuses[0].Result.Missing != 1 {
+		t.Fatalf("expected a live check on a cold cache, calls=%d refresh=%v statuses=%+v", calls.Load(), refresh, statuses)
+	}
+
+	cache.now = func() time.Time { return now.Add(30 * time.Second) }
+	statuses, refresh = cache.collect(context.Background(), []Service{svc}, nil, nil, false)
+	if calls.Load() != 1 || refresh || statuses[0].Stale {
+		t.Fatalf("expected a fresh cache hit, calls=%d refresh=%v statuses=%+v", calls.Load(), refresh, statuses)
+	}
+
+	cache.now = func() time.Time { return now.Add(3 * time.Minute) }
+	statuses, refresh = cache.collect(context.Background(), []Service{svc}, nil, nil, false)
+	if calls.Load() != 1 || !refresh || !statuses[0].Stale || statuses[0].Result.Missing != 1 {
+		t.Fatalf("expected a stale hit with refresh, calls=%d refresh=%v statuses=%+v", calls.Load(), refresh, statuses)
+	}
+
+	cache.now = func() time.Time { return now.Add(2 * time.Hour) }
+	statuses, refresh = cache.collect(context.Background(), []Service{svc}, nil, nil, false)
+	if calls.Load() != 2 || refresh || statuses[0].Stale || statuses[0].Result.Missing != 2 {
+		t.Fatalf("expected an expired entry to be checked live, calls=%d refresh=%v statuses=%+v", calls.Load(), refresh, statuses)
+	}
+}
+
+func TestResultCacheSkipsBudgetTimeouts(t *testing.T) {
+	cache := testResultCache(t, time.Now())
+	svc := countingTestService{name: "Sonarr", calls: new(atomic.Int32)}
+	cache.store(svc, MediaStatus{Name: svc.Name(), Kind: svc.Kind(), Error: ErrorTimedOut, CheckedAt: time.Now()})
+	if _, ok := cache.load(svc); ok {
+		t.Fatal("expected a timed out status not to be cached")
+	}
+}
+
+func TestResultCacheKeepsServiceOrder(t *testing.T) {
+	now := time.Now()
+	cache := testResultCache(t, now)
+	var calls atomic.Int32
+	first := countingTestService{name: "First", calls: &calls}
+	second := countingTestService{name: "Second", calls: &calls}
+	cache.collect(context.Background(), []Service{second}, nil, nil, false)
+
+	statuses, _ := cache.collect(context.Background(), []Service{first, second}, nil, nil, false)
+	if len(statuses) != 2 || statuses[0].Name != "First" || statuses[1].Name != "Second" {
+		t.Fatalf("expected configured order, got %+v", statuses)
+	}
+}
+
+func TestResultCacheKeyIncludesCredentials(t *testing.T) {
+	cache := testResultCache(t, time.Now())
+	a := sonarrService{cfg: config.ServiceConfig{Name: "HD", URL: "https://sonarr.example.com", APIKey: "old"}}
+	b := sonarrService{cfg: config.ServiceConfig{Name: "HD", URL: "https://sonarr.example.com", APIKey: "new"}}
+	if cache.path(a) == cache.path(b) {
+		t.Fatal("expected a new API key to use a new cache entry")
+	}
+	if strings.Contains(cache.path(a), "old") {
+		t.Fatal("expected credentials to be hashed out of the cache path")
+	}
+}
+
+func TestResultCacheServiceTTLOverride(t *testing.T) {
+	cache := testResultCache(t, time.Now())
+	svc := plexService{cfg: config.ServiceConfig{Name: "Main", CacheTTL: "5m"}}
+	if got := cache.ttlFor(svc); got != 5*time.Minute {
+		t.Fatalf("expected per-service TTL, got %s", got)
+	}
+	if got := cache.ttlFor(plexService{}); got != time.Minute {
+		t.Fatalf("expected cache-wide TTL, got %s", got)
+	}
+}
+
+func TestStaleStatusText(t *testing.T) {
+	status := MediaStatus{Kind: KindSeerr, Result: Result{Kind: KindSeerr, Pending: 2}, CheckedAt: time.Now().Add(-3*time.Minute - time.Second), Stale: true}
+	if got := status.Text(); got != "2 pending requests (as of 3m ago)" {
+		t.Fatalf("unexpected stale text: %q", got)
+	}
+}
+
+func TestAcquireRefreshLock(t *testing.T) {
+	lockPath := filepath.Join(t.TempDir(), refreshLockFile)
+	now := time.Now()
+	if !acquireRefreshLock(lockPath, now) {
+		t.Fatal("expected first lock to succeed")
+	}
+	if acquireRefreshLock(lockPath, now) {
+		t.Fatal("expected a held lock to block a second refresh")
+	}
+	old := now.Add(-2 * refreshLockMaxAge)
+	if err := os.Chtimes(lockPath, old, old); err != nil {
+		t.Fatalf("age lock: %v", err)
+	}
+	if !acquireRefreshLock(lockPath, now) {
+		t.Fatal("expected an abandoned lock to be replaced")
+	}
+}
+
+func TestValidateCacheConfig(t *testing.T) {
+	cfg := config.Config{MediaCache: &config.MediaCacheConfig{TTL: "10m", MaxStale: "5m"}}
+	if err := ValidateCacheConfig(cfg); err == nil {
+		t.Fatal("expected max_stale shorter than ttl to fail")
+	}
+	cfg = config.Config{}
+	cfg.Services.Radarr = []config.ServiceConfig{{CacheTTL: "soon"}}
+	if err := ValidateCacheConfig(cfg); err == nil || !strings.Contains(err.Error(), "radarr[0].cache_ttl") {
+		t.Fatalf("expected invalid cache_ttl to fail, got %v", err)
+	}
+}
@@ -0,0 +1,87 @@
+package media
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+)
+
+// ErrorKind classifies why a media service check failed.
+type ErrorKind string
+
+const (
+	ErrorAuth        ErrorKind = "auth"
+	ErrorTimeout     ErrorKind = "timeout"
+	ErrorUnreachable ErrorKind = "unreachable"
+	ErrorBadResponse ErrorKind = "bad_response"
+	ErrorTLS         ErrorKind = "tls"
+
+	// ErrorTimedOut means the run's time budget ran out before the check
+	// finished, as opposed to the service itself timing out.
+	ErrorTimedOut ErrorKind = "timed_out"
+)
+
+var errInvalidResponse = errors.New("invalid response")
+
+// statusError is returned when a service answers with a non-200 status.
+type statusError struct {
+	code int
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("unexpected status %d", e.code)
+}
+
+// classifyError maps a Check error to a category and a short operator hint
+// such as "401 unauthorized" or "connection refused".
+func classifyError(err error) (ErrorKind, string) {
+	var status *statusError
+	if errors.As(err, &status) {
+		hint := strings.TrimSpace(fmt.Sprintf("%d %s", status.code, strings.ToLower(http.StatusText(status.code))))
+		if status.code == http.StatusUnauthorized || status.code == http.StatusForbidden {
+			return ErrorAuth, hint
+		}
+		return ErrorBadResponse, hint
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return ErrorTimeout, "timeout"
+	}
+
+	var certErr *tls.CertificateVerificationError
+	var unknownAuthority x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	var invalidCert x509.CertificateInvalidError
+	if errors.As(err, &certErr) || errors.As(err, &unknownAuthority) || errors.As(err, &hostnameErr) || errors.As(err, &invalidCert) {
+		return ErrorTLS, "certificate error"
+	}
+	var recordErr tls.RecordHeaderError
+	var alertErr tls.AlertError
+	if errors.As(err, &recordErr) || errors.As(err, &alertErr) {
+		return ErrorTLS, "TLS handshake failed"
+	}
+
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return ErrorUnreachable, "DNS lookup failed"
+	}
+	if errors.Is(err, syscall.ECONNREFUSED) {
+		return ErrorUnreachable, "connection refused"
+	}
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return ErrorUnreachable, "connection failed"
+	}
+
+	if errors.Is(err, errInvalidResponse) {
+		return ErrorBadResponse, "invalid response"
+	}
+	return ErrorBadResponse, "request failed"
+}
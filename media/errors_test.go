@@ -0,0 +1,85 @@
+package media
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"motd/config"
+)
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		kind   ErrorKind
+		detail string
+	}{
+		{"unauthorized", &statusError{code: http.StatusUnauthorized}, ErrorAuth, "401 unauthorized"},
+		{"forbidden", fmt.Errorf("wrapped: %w", &statusError{code: http.StatusForbidden}), ErrorAuth, "403 forbidden"},
+		{"server error", &statusError{code: http.StatusBadGateway}, ErrorBadResponse, "502 bad gateway"},
+		{"dns", &net.DNSError{Err: "no such host", Name: "plex.invalid", IsNotFound: true}, ErrorUnreachable, "DNS lookup failed"},
+		{"invalid body", fmt.Errorf("%w: %w", errInvalidResponse, errors.New("unexpected EOF")), ErrorBadResponse, "invalid response"},
+		{"unknown", errors.New("boom"), ErrorBadResponse, "request failed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kind, detail := classifyError(tt.err)
+			if kind != tt.kind || detail != tt.detail {
+				t.Fatalf("classifyError() = %q %q, want %q %q", kind, detail, tt.kind, tt.detail)
+			}
+		})
+	}
+}
+
+func TestCollectMediaStatusesPreservesErrorReasons(t *testing.T) {
+	unauthorized := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+	}))
+	defer unauthorized.Close()
+
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer slow.Close()
+
+	tlsServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer tlsServer.Close()
+
+	closed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	closedURL := closed.URL
+	closed.Close()
+
+	garbage := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprint(w, `{not json`)
+	}))
+	defer garbage.Close()
+
+	services := []Service{
+		sonarrService{cfg: config.ServiceConfig{Name: "Auth", URL: unauthorized.URL, APIKey: "k", Enabled: true}},
+		sonarrService{cfg: config.ServiceConfig{Name: "Slow", URL: slow.URL, APIKey: "k", Enabled: true}},
+		sonarrService{cfg: config.ServiceConfig{Name: "TLS", URL: tlsServer.URL, APIKey: "k", Enabled: true}},
+		sonarrService{cfg: config.ServiceConfig{Name: "Down", URL: closedURL, APIKey: "k", Enabled: true}},
+		sonarrService{cfg: config.ServiceConfig{Name: "Garbage", URL: garbage.URL, APIKey: "k", Enabled: true}},
+	}
+
+	results := collectMediaStatuses(context.Background(), services, &http.Client{Timeout: 500 * time.Millisecond}, nil, false)
+	want := []ErrorKind{ErrorAuth, ErrorTimeout, ErrorTLS, ErrorUnreachable, ErrorBadResponse}
+	if len(results) != len(want) {
+		t.Fatalf("expected %d results, got %+v", len(want), results)
+	}
+	for i, result := range results {
+		if result.Error != want[i] {
+			t.Fatalf("%s: expected %q, got %q (%s)", result.Name, want[i], result.Error, result.Detail)
+		}
+	}
+	if got := results[0].Text(); got != "unavailable (401 unauthorized)" {
+		t.Fatalf("unexpected auth failure text: %q", got)
+	}
+}
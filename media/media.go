@@ -1,15 +1,15 @@
 package media
 
 import (
+	"context"
 	"encoding/json"
 	"encoding/xml"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
-	"sort"
 	"strings"
-	"sync"
+	"time"
 
 	"motd/config"
 	"motd/display"
@@ -22,9 +22,36 @@ const (
 	maxConcurrentMediaChecks = 8
 )
 
+// Kind identifies the media service type behind a Result.
+type Kind string
+
+const (
+	KindPlex     Kind = "plex"
+	KindJellyfin Kind = "jellyfin"
+	KindSonarr   Kind = "sonarr"
+	KindRadarr   Kind = "radarr"
+	KindSeerr    Kind = "seerr"
+)
+
+// Service checks one configured media service instance. Check returns raw
+// counts; terminal text and colors are derived from the Result. The request
+// is abandoned when ctx is done.
 type Service interface {
 	Name() string
-	Render(client *http.Client, debug bool) (text string, color string, ok bool)
+	Kind() Kind
+	Check(ctx context.Context, client *http.Client) (Result, error)
+}
+
+// Result is the structured outcome of a successful service check. Only the
+// fields relevant to Kind are populated.
+type Result struct {
+	Kind         Kind  `json:"kind"`
+	Streams      int   `json:"streams"`
+	Transcodes   int   `json:"transcodes"`
+	BandwidthBps int64 `json:"bandwidth_bps"`
+	HasBandwidth bool  `json:"has_bandwidth"`
+	Missing      int   `json:"missing"`
+	Pending      int   `json:"pending"`
 }
 
 type plexService struct {
@@ -32,30 +59,35 @@ type plexService struct {
 }
 
 func (s plexService) Name() string { return serviceLabel("Plex", s.cfg.Name) }
+func (s plexService) Kind() Kind   { return KindPlex }
 
 type jellyfinService struct {
 	cfg config.ServiceConfig
 }
 
 func (s jellyfinService) Name() string { return serviceLabel("Jellyfin", s.cfg.Name) }
+func (s jellyfinService) Kind() Kind   { return KindJellyfin }
 
 type sonarrService struct {
 	cfg config.ServiceConfig
 }
 
 func (s sonarrService) Name() string { return serviceLabel("Sonarr", s.cfg.Name) }
+func (s sonarrService) Kind() Kind   { return KindSonarr }
 
 type radarrService struct {
 	cfg config.ServiceConfig
 }
 
 func (s radarrService) Name() string { return serviceLabel("Radarr", s.cfg.Name) }
+func (s radarrService) Kind() Kind   { return KindRadarr }
 
 type seerrService struct {
 	cfg config.ServiceConfig
 }
 
 func (s seerrService) Name() string { return serviceLabel("Seerr", s.cfg.Name) }
+func (s seerrService) Kind() Kind   { return KindSeerr }
 
 type plexSessionsResponse struct {
 	Size   int `xml:"size,attr"`
@@ -92,12 +124,116 @@ type seerrRequestCountResponse struct {
 	Pending int `json:"pending"`
 }
 
+// MediaStatus is the outcome of one service check. When Error is set the
+// check failed and Detail holds a short hint such as "401 unauthorized".
+// Stale marks a cached result that is past its TTL and being refreshed.
 type MediaStatus struct {
-	Order int
-	Name  string
-	Text  string
-	Color string
-	Error string
+	Order     int              `json:"-"`
+	Name      string           `json:"name"`
+	Kind      Kind             `json:"kind"`
+	Result    Result           `json:"result"`
+	Error     ErrorKind        `json:"error,omitempty"`
+	Detail    string           `json:"error_detail,omitempty"`
+	Severity  display.Severity `json:"severity,omitempty"`
+	CheckedAt time.Time        `json:"checked_at,omitzero"`
+	Stale     bool             `json:"stale,omitempty"`
+}
+
+// Text returns the terminal summary for the status.
+func (s MediaStatus) Text() string {
+	text := s.Result.Text()
+	if s.Error == ErrorTimedOut {
+		return "timed out"
+	}
+	if s.Error != "" {
+		text = "unavailable"
+		if s.Detail != "" {
+			text = fmt.Sprintf("unavailable (%s)", s.Detail)
+		}
+	}
+	if s.Stale && !s.CheckedAt.IsZero() {
+		text += fmt.Sprintf(" (as of %s ago)", formatAge(time.Since(s.CheckedAt)))
+	}
+	return text
+}
+
+// Color returns the terminal color for the status.
+func (s MediaStatus) Color() string {
+	return s.Severity.Color()
+}
+
+// evaluate sets the severity from th. A failed check is always a warning and
+// one cut off by the time budget is unknown.
+func (s *MediaStatus) evaluate(th config.ThresholdsConfig) {
+	if s.Error == ErrorTimedOut {
+		s.Severity = display.SeverityUnknown
+		return
+	}
+	if s.Error != "" {
+		s.Severity = display.SeverityWarning
+		return
+	}
+	s.Severity = s.Result.Severity(th)
+}
+
+// HasStreams reports whether the result carries stream counts (Plex, Jellyfin).
+func (r Result) HasStreams() bool {
+	return r.Kind == KindPlex || r.Kind == KindJellyfin
+}
+
+// Mbps returns the stream bandwidth in megabits per second.
+func (r Result) Mbps() float64 {
+	return float64(r.BandwidthBps) / 1000000.0
+}
+
+// Text renders the result as a short English summary.
+func (r Result) Text() string {
+	switch r.Kind {
+	case KindPlex, KindJellyfin:
+		if r.Streams == 0 {
+			return "No active streams"
+		}
+		text := fmt.Sprintf("%d streams", r.Streams)
+		if r.Transcodes > 0 {
+			text += fmt.Sprintf(", %d transcodes", r.Transcodes)
+		}
+		if r.HasBandwidth {
+			text += fmt.Sprintf(" (%.2f Mbps)", r.Mbps())
+		}
+		return text
+	case KindSonarr:
+		if r.Missing == 0 {
+			return "No missing episodes"
+		}
+		return fmt.Sprintf("%d missing episode%s", r.Missing, util.PluralSuffix(r.Missing))
+	case KindRadarr:
+		if r.Missing == 0 {
+			return "No missing movies"
+		}
+		return fmt.Sprintf("%d missing movie%s", r.Missing, util.PluralSuffix(r.Missing))
+	case KindSeerr:
+		if r.Pending == 0 {
+			return "No pending requests"
+		}
+		return fmt.Sprintf("%d pending request%s", r.Pending, util.PluralSuffix(r.Pending))
+	}
+	return ""
+}
+
+// Severity grades the result against the configured alert levels.
+func (r Result) Severity(th config.ThresholdsConfig) display.Severity {
+	switch r.Kind {
+	case KindPlex, KindJellyfin:
+		return display.WorstSeverity(
+			th.StreamsLevels().Evaluate(float64(r.Streams)),
+			th.TranscodesLevels().Evaluate(float64(r.Transcodes)),
+		)
+	case KindSonarr, KindRadarr:
+		return th.MissingLevels().Evaluate(float64(r.Missing))
+	case KindSeerr:
+		return th.PendingLevels().Evaluate(float64(r.Pending))
+	}
+	return display.SeverityUnknown
 }
 
 func AllServices(cfg config.Config, selected map[string]bool) []Service {
@@ -191,70 +327,89 @@ func HasMediaServices(cfg config.Config, selected map[string]bool) bool {
 	return len(AllServices(cfg, selected)) > 0
 }
 
-func ShowMediaServices(cfg config.Config, selected map[string]bool, client *http.Client, debug bool) {
+func ShowMediaServices(ctx context.Context, cfg config.Config, selected map[string]bool, client *http.Client, debug bool) {
 	services := allServices(cfg, selected, debug)
 	if len(services) == 0 {
 		return
 	}
 
+	RenderMediaStatuses(EvaluateStatuses(collectMediaStatuses(ctx, services, client, nil, debug), cfg.Thresholds))
+}
+
+// RenderMediaStatuses prints the Media Services section for statuses that
+// were already collected, for example by `motd daemon`.
+func RenderMediaStatuses(statuses []MediaStatus) {
+	if len(statuses) == 0 {
+		return
+	}
 	display.PrintSection("Media Services")
-	for _, result := range collectMediaStatuses(services, client, debug) {
-		fmt.Print(formatMediaLine(result.Name, result.Text, result.Color))
+	for _, result := range statuses {
+		fmt.Print(formatMediaLine(result.Name, result.Text(), result.Color()))
 	}
 }
 
-func CollectMediaStatuses(cfg config.Config, selected map[string]bool, client *http.Client, debug bool) []MediaStatus {
-	return collectMediaStatuses(allServices(cfg, selected, debug), client, debug)
+// CollectMediaStatuses checks every selected service in pool, or in a pool
+// of MaxConcurrentMediaChecks slots when pool is nil. Services that have not
+// answered when ctx is done are reported with ErrorTimedOut.
+func CollectMediaStatuses(ctx context.Context, cfg config.Config, selected map[string]bool, client *http.Client, pool *util.Pool, debug bool) []MediaStatus {
+	return EvaluateStatuses(collectMediaStatuses(ctx, allServices(cfg, selected, debug), client, pool, debug), cfg.Thresholds)
 }
 
-func collectMediaStatuses(services []Service, client *http.Client, debug bool) []MediaStatus {
-	var wg sync.WaitGroup
-	results := make(chan MediaStatus, len(services))
-	limit := MaxConcurrentMediaChecks()
-	if limit > len(services) {
-		limit = len(services)
-	}
-	semaphore := make(chan struct{}, limit)
-	order := 0
-
-	start := func(svc Service) {
-		currentOrder := order
-		order++
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			semaphore <- struct{}{}
-			defer func() { <-semaphore }()
-			text, color, ok := svc.Render(client, debug)
-			if ok {
-				results <- MediaStatus{Order: currentOrder, Name: svc.Name(), Text: text, Color: color}
-			} else {
-				results <- MediaStatus{Order: currentOrder, Name: svc.Name(), Text: "unavailable", Color: display.Yellow, Error: "unavailable"}
-			}
-		}()
+// EvaluateStatuses sets the severity of each status from th.
+func EvaluateStatuses(statuses []MediaStatus, th config.ThresholdsConfig) []MediaStatus {
+	for i := range statuses {
+		statuses[i].evaluate(th)
 	}
+	return statuses
+}
 
-	for _, svc := range services {
-		start(svc)
+func collectMediaStatuses(ctx context.Context, services []Service, client *http.Client, pool *util.Pool, debug bool) []MediaStatus {
+	if pool == nil {
+		pool = util.NewPool(MaxConcurrentMediaChecks())
 	}
-
-	go func() {
-		wg.Wait()
-		close(results)
-	}()
-
-	collected := make([]MediaStatus, 0, order)
-	for result := range results {
-		collected = append(collected, result)
+	results := make(chan MediaStatus, len(services))
+	for order, svc := range services {
+		pool.Go(ctx, func() {
+			results <- checkService(ctx, order, svc, client, debug)
+		})
+	}
+
+	collected := make([]MediaStatus, len(services))
+	received := make([]bool, len(services))
+	for remaining := len(services); remaining > 0; remaining-- {
+		select {
+		case status := <-results:
+			collected[status.Order] = status
+			received[status.Order] = true
+		case <-ctx.Done():
+			for i, svc := range services {
+				if !received[i] {
+					display.DebugLog(debug, "%s check timed out", svc.Name())
+					collected[i] = MediaStatus{Order: i, Name: svc.Name(), Kind: svc.Kind(), Error: ErrorTimedOut}
+				}
+			}
+			return collected
+		}
 	}
-
-	sort.Slice(collected, func(i, j int) bool {
-		return collected[i].Order < collected[j].Order
-	})
-
 	return collected
 }
 
+func checkService(ctx context.Context, order int, svc Service, client *http.Client, debug bool) MediaStatus {
+	result, err := svc.Check(ctx, client)
+	status := MediaStatus{Order: order, Name: svc.Name(), Kind: svc.Kind(), CheckedAt: time.Now()}
+	switch {
+	case err != nil && ctx.Err() != nil:
+		display.DebugLog(debug, "%s check timed out: %v", svc.Name(), err)
+		status.Error = ErrorTimedOut
+	case err != nil:
+		display.DebugLog(debug, "%s check failed: %v", svc.Name(), err)
+		status.Error, status.Detail = classifyError(err)
+	default:
+		status.Result = result
+	}
+	return status
+}
+
 // IsPlaintextToRemote returns true when rawURL uses http:// with a
 // non-loopback host. Callers should warn users about credential exposure.
 func IsPlaintextToRemote(rawURL string) bool {
@@ -359,12 +514,12 @@ func hasNowPlayingItem(raw json.RawMessage) bool {
 
 func decodeJSONResponse(resp *http.Response, target interface{}) error {
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+		return &statusError{code: resp.StatusCode}
 	}
 
 	decoder := json.NewDecoder(io.LimitReader(resp.Body, maxMediaResponseSize))
 	if err := decoder.Decode(target); err != nil {
-		return err
+		return fmt.Errorf("%w: %w", errInvalidResponse, err)
 	}
 
 	return nil
@@ -392,7 +547,7 @@ func countAvailableRecords(records []json.RawMessage) int {
 	return count
 }
 
-func parseJellyfinSessions(sessions []jellyfinSession) (int, int, float64, bool) {
+func parseJellyfinSessions(sessions []jellyfinSession) (int, int, int64, bool) {
 	active := 0
 	transcodes := 0
 	totalBitrate := int64(0)
@@ -414,181 +569,124 @@ func parseJellyfinSessions(sessions []jellyfinSession) (int, int, float64, bool)
 		}
 	}
 
-	mbps := float64(totalBitrate) / 1000000.0
-	return active, transcodes, mbps, hasBitrate
+	return active, transcodes, totalBitrate, hasBitrate
 }
 
-func (s plexService) Render(client *http.Client, debug bool) (string, string, bool) {
-	req, err := http.NewRequest("GET", serviceURL(s.cfg.URL, "/status/sessions"), nil)
+func (s plexService) Check(ctx context.Context, client *http.Client) (Result, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", serviceURL(s.cfg.URL, "/status/sessions"), nil)
 	if err != nil {
-		display.DebugLog(debug, "Plex request failed for %s: %v", s.cfg.Name, err)
-		return "", "", false
+		return Result{}, fmt.Errorf("build request: %w", err)
 	}
 	req.Header.Set("X-Plex-Token", s.cfg.Token)
 
 	resp, err := client.Do(req)
 	if err != nil {
-		display.DebugLog(debug, "Plex request failed for %s: %v", s.cfg.Name, err)
-		return "", "", false
+		return Result{}, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		display.DebugLog(debug, "Plex returned status %d for %s", resp.StatusCode, s.cfg.Name)
-		return "", "", false
+		return Result{}, &statusError{code: resp.StatusCode}
 	}
 
 	var sessions plexSessionsResponse
 	if err := xml.NewDecoder(io.LimitReader(resp.Body, maxMediaResponseSize)).Decode(&sessions); err != nil {
-		display.DebugLog(debug, "Failed to parse Plex XML for %s: %v", s.cfg.Name, err)
-		return "", "", false
+		return Result{}, fmt.Errorf("%w: parse XML: %w", errInvalidResponse, err)
 	}
 
-	transcodes := 0
-	bandwidth := 0
+	result := Result{Kind: KindPlex, Streams: sessions.Size, HasBandwidth: true}
 	for _, video := range sessions.Videos {
 		if video.TranscodeSession.VideoDecision == "transcode" {
-			transcodes++
+			result.Transcodes++
 		}
-		bandwidth += video.Session.Bandwidth
-	}
-
-	if sessions.Size == 0 {
-		return "No active streams", display.Green, true
-	}
-
-	bwMbps := float64(bandwidth) / 1000.0
-	if transcodes == 0 {
-		return fmt.Sprintf("%d streams (%.2f Mbps)", sessions.Size, bwMbps), display.Yellow, true
+		// Plex reports session bandwidth in kbps.
+		result.BandwidthBps += int64(video.Session.Bandwidth) * 1000
 	}
-
-	return fmt.Sprintf("%d streams, %d transcodes (%.2f Mbps)", sessions.Size, transcodes, bwMbps), display.Red, true
+	return result, nil
 }
 
-func (s jellyfinService) Render(client *http.Client, debug bool) (string, string, bool) {
-	req, err := http.NewRequest("GET", serviceURL(s.cfg.URL, "/Sessions"), nil)
+func (s jellyfinService) Check(ctx context.Context, client *http.Client) (Result, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", serviceURL(s.cfg.URL, "/Sessions"), nil)
 	if err != nil {
-		display.DebugLog(debug, "Jellyfin request build failed for %s: %v", s.cfg.Name, err)
-		return "", "", false
+		return Result{}, fmt.Errorf("build request: %w", err)
 	}
 	req.Header.Set("X-Emby-Token", s.cfg.Token)
 	req.Header.Set("Authorization", "MediaBrowser Token=\""+s.cfg.Token+"\"")
 
 	resp, err := client.Do(req)
 	if err != nil {
-		display.DebugLog(debug, "Jellyfin request failed for %s: %v", s.cfg.Name, err)
-		return "", "", false
+		return Result{}, err
 	}
 	defer resp.Body.Close()
 
 	var sessions []jellyfinSession
 	if err := decodeJSONResponse(resp, &sessions); err != nil {
-		display.DebugLog(debug, "Failed to decode Jellyfin response for %s: %v", s.cfg.Name, err)
-		return "", "", false
-	}
-
-	count, transcodes, bwMbps, hasBW := parseJellyfinSessions(sessions)
-
-	if count == 0 {
-		return "No active streams", display.Green, true
-	}
-
-	if transcodes == 0 {
-		if hasBW {
-			return fmt.Sprintf("%d streams (%.2f Mbps)", count, bwMbps), display.Yellow, true
-		}
-		return fmt.Sprintf("%d streams", count), display.Yellow, true
-	}
-
-	if hasBW {
-		return fmt.Sprintf("%d streams, %d transcodes (%.2f Mbps)", count, transcodes, bwMbps), display.Red, true
+		return Result{}, err
 	}
 
-	return fmt.Sprintf("%d streams, %d transcodes", count, transcodes), display.Red, true
+	count, transcodes, bitrate, hasBW := parseJellyfinSessions(sessions)
+	return Result{Kind: KindJellyfin, Streams: count, Transcodes: transcodes, BandwidthBps: bitrate, HasBandwidth: hasBW}, nil
 }
 
-func (s sonarrService) Render(client *http.Client, debug bool) (string, string, bool) {
-	req, err := http.NewRequest("GET", serviceURL(s.cfg.URL, "/api/v3/wanted/missing"), nil)
+func (s sonarrService) Check(ctx context.Context, client *http.Client) (Result, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", serviceURL(s.cfg.URL, "/api/v3/wanted/missing"), nil)
 	if err != nil {
-		display.DebugLog(debug, "Sonarr request build failed for %s: %v", s.cfg.Name, err)
-		return "", "", false
+		return Result{}, fmt.Errorf("build request: %w", err)
 	}
 	req.Header.Set("X-Api-Key", s.cfg.APIKey)
 
 	resp, err := client.Do(req)
 	if err != nil {
-		display.DebugLog(debug, "Sonarr request failed for %s: %v", s.cfg.Name, err)
-		return "", "", false
+		return Result{}, err
 	}
 	defer resp.Body.Close()
 
 	var result arrWantedMissingResponse
 	if err := decodeJSONResponse(resp, &result); err != nil {
-		display.DebugLog(debug, "Failed to decode Sonarr response for %s: %v", s.cfg.Name, err)
-		return "", "", false
-	}
-
-	count := parseARRMissingCount(result)
-	if count == 0 {
-		return "No missing episodes", display.Green, true
+		return Result{}, err
 	}
 
-	return fmt.Sprintf("%d missing episode%s", count, util.PluralSuffix(count)), display.Yellow, true
+	return Result{Kind: KindSonarr, Missing: parseARRMissingCount(result)}, nil
 }
 
-func (s radarrService) Render(client *http.Client, debug bool) (string, string, bool) {
-	req, err := http.NewRequest("GET", serviceURL(s.cfg.URL, "/api/v3/wanted/missing?excludeUnavailable=true"), nil)
+func (s radarrService) Check(ctx context.Context, client *http.Client) (Result, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", serviceURL(s.cfg.URL, "/api/v3/wanted/missing?excludeUnavailable=true"), nil)
 	if err != nil {
-		display.DebugLog(debug, "Radarr request build failed for %s: %v", s.cfg.Name, err)
-		return "", "", false
+		return Result{}, fmt.Errorf("build request: %w", err)
 	}
 	req.Header.Set("X-Api-Key", s.cfg.APIKey)
 
 	resp, err := client.Do(req)
 	if err != nil {
-		display.DebugLog(debug, "Radarr request failed for %s: %v", s.cfg.Name, err)
-		return "", "", false
+		return Result{}, err
 	}
 	defer resp.Body.Close()
 
 	var result arrWantedMissingResponse
 	if err := decodeJSONResponse(resp, &result); err != nil {
-		display.DebugLog(debug, "Failed to decode Radarr response for %s: %v", s.cfg.Name, err)
-		return "", "", false
+		return Result{}, err
 	}
 
-	count := countAvailableRecords(result.Records)
-	if count == 0 {
-		return "No missing movies", display.Green, true
-	}
-
-	return fmt.Sprintf("%d missing movie%s", count, util.PluralSuffix(count)), display.Yellow, true
+	return Result{Kind: KindRadarr, Missing: countAvailableRecords(result.Records)}, nil
 }
 
-func (s seerrService) Render(client *http.Client, debug bool) (string, string, bool) {
-	req, err := http.NewRequest("GET", serviceURL(s.cfg.URL, "/api/v1/request/count"), nil)
+func (s seerrService) Check(ctx context.Context, client *http.Client) (Result, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", serviceURL(s.cfg.URL, "/api/v1/request/count"), nil)
 	if err != nil {
-		display.DebugLog(debug, "Seerr request build failed for %s: %v", s.cfg.Name, err)
-		return "", "", false
+		return Result{}, fmt.Errorf("build request: %w", err)
 	}
 	req.Header.Set("X-Api-Key", s.cfg.APIKey)
 
 	resp, err := client.Do(req)
 	if err != nil {
-		display.DebugLog(debug, "Seerr request failed for %s: %v", s.cfg.Name, err)
-		return "", "", false
+		return Result{}, err
 	}
 	defer resp.Body.Close()
 
 	var result seerrRequestCountResponse
 	if err := decodeJSONResponse(resp, &result); err != nil {
-		display.DebugLog(debug, "Failed to decode Seerr response for %s: %v", s.cfg.Name, err)
-		return "", "", false
-	}
-
-	if result.Pending == 0 {
-		return "No pending requests", display.Green, true
+		return Result{}, err
 	}
 
-	return fmt.Sprintf("%d pending request%s", result.Pending, util.PluralSuffix(result.Pending)), display.Yellow, true
+	return Result{Kind: KindSeerr, Pending: result.Pending}, nil
 }
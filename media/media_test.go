@@ -2,6 +2,7 @@ package media
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -10,11 +11,13 @@ import (
 	"os"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"motd/config"
 	"motd/display"
+	"motd/util"
 )
 
 type blockingTestService struct {
@@ -27,10 +30,14 @@ func (s blockingTestService) Name() string {
 	return s.name
 }
 
-func (s blockingTestService) Render(_ *http.Client, _ bool) (string, string, bool) {
+func (s blockingTestService) Kind() Kind {
+	return KindSeerr
+}
+
+func (s blockingTestService) Check(context.Context, *http.Client) (Result, error) {
 	s.entered <- struct{}{}
 	<-s.release
-	return "ok", display.Green, true
+	return Result{Kind: KindSeerr}, nil
 }
 
 func captureStderr(t *testing.T, fn func()) string {
@@ -87,7 +94,7 @@ func TestParseJellyfinSessions(t *testing.T) {
 		{NowPlayingItem: json.RawMessage(`null`)},
 	}
 
-	active, transcodes, mbps, hasBW := parseJellyfinSessions(sessions)
+	active, transcodes, bitrate, hasBW := parseJellyfinSessions(sessions)
 	if active != 2 {
 		t.Fatalf("expected 2 active streams, got %d", active)
 	}
@@ -97,8 +104,8 @@ func TestParseJellyfinSessions(t *testing.T) {
 	if !hasBW {
 		t.Fatal("expected hasBW=true")
 	}
-	if mbps != 10.0 {
-		t.Fatalf("expected 10.0 Mbps, got %.2f", mbps)
+	if bitrate != 10_000_000 {
+		t.Fatalf("expected 10000000 bps, got %d", bitrate)
 	}
 }
 
@@ -136,11 +143,14 @@ func TestRenderJellyfinInstance_RequestAndOutput(t *testing.T) {
 	defer server.Close()
 
 	svc := jellyfinService{cfg: config.ServiceConfig{Name: "Main", URL: server.URL, Token: "jellyfin-token", Enabled: true}}
-	text, _, ok := svc.Render(server.Client(), false)
-	if !ok {
-		t.Fatal("expected Jellyfin output")
+	result, err := svc.Check(context.Background(), server.Client())
+	if err != nil {
+		t.Fatalf("expected Jellyfin result, got %v", err)
+	}
+	if result.Streams != 1 || result.Transcodes != 1 || result.BandwidthBps != 5_000_000 || !result.HasBandwidth {
+		t.Fatalf("unexpected Jellyfin result: %+v", result)
 	}
-	if !strings.Contains(text, "1 streams, 1 transcode") || !strings.Contains(text, "5.00 Mbps") {
+	if text := result.Text(); !strings.Contains(text, "1 streams, 1 transcode") || !strings.Contains(text, "5.00 Mbps") {
 		t.Fatalf("unexpected Jellyfin output: %q", text)
 	}
 }
@@ -162,11 +172,14 @@ func TestRenderRadarrInstance_RequestAndPluralization(t *testing.T) {
 	defer server.Close()
 
 	svc := radarrService{cfg: config.ServiceConfig{Name: "HD", URL: server.URL, APIKey: "radarr-key", Enabled: true}}
-	text, _, ok := svc.Render(server.Client(), false)
-	if !ok {
-		t.Fatal("expected Radarr output")
+	result, err := svc.Check(context.Background(), server.Client())
+	if err != nil {
+		t.Fatalf("expected Radarr result, got %v", err)
+	}
+	if result.Missing != 1 {
+		t.Fatalf("unexpected Radarr result: %+v", result)
 	}
-	if !strings.Contains(text, "1 missing movie") || strings.Contains(text, "movies") {
+	if text := result.Text(); !strings.Contains(text, "1 missing movie") || strings.Contains(text, "movies") {
 		t.Fatalf("unexpected Radarr output: %q", text)
 	}
 }
@@ -187,13 +200,19 @@ func TestRenderPlexInstance_ActiveTranscodes(t *testing.T) {
 	defer server.Close()
 
 	svc := plexService{cfg: config.ServiceConfig{Name: "Main", URL: server.URL, Token: "plex-token", Enabled: true}}
-	text, _, ok := svc.Render(server.Client(), false)
-	if !ok {
-		t.Fatal("expected Plex output")
+	result, err := svc.Check(context.Background(), server.Client())
+	if err != nil {
+		t.Fatalf("expected Plex result, got %v", err)
 	}
-	if !strings.Contains(text, "2 streams, 1 transcode") || !strings.Contains(text, "6.00 Mbps") {
+	if result.Streams != 2 || result.Transcodes != 1 || result.BandwidthBps != 6_000_000 {
+		t.Fatalf("unexpected Plex result: %+v", result)
+	}
+	if text := result.Text(); !strings.Contains(text, "2 streams, 1 transcode") || !strings.Contains(text, "6.00 Mbps") {
 		t.Fatalf("unexpected Plex output: %q", text)
 	}
+	if got := result.Severity(config.ThresholdsConfig{}); got != display.SeverityWarning {
+		t.Fatalf("expected transcoding Plex result to be a warning, got %q", got)
+	}
 }
 
 func TestRenderMediaLine(t *testing.T) {
@@ -265,7 +284,7 @@ func TestShowMediaServicesStableOrder(t *testing.T) {
 		fmt.Fprint(buf, "dummy output to check ordering\n")
 	}
 
-	results := collectMediaStatuses(AllServices(cfg, nil), client, false)
+	results := collectMediaStatuses(context.Background(), AllServices(cfg, nil), client, nil, false)
 	if len(results) == 0 {
 		t.Fatal("expected media status results")
 	}
@@ -412,11 +431,14 @@ func TestSeerrServiceRender(t *testing.T) {
 	defer server.Close()
 
 	svc := seerrService{cfg: config.ServiceConfig{Name: "Main", URL: server.URL, APIKey: "test-key", Enabled: true}}
-	text, _, ok := svc.Render(server.Client(), false)
-	if !ok {
-		t.Fatal("expected Seerr output")
+	result, err := svc.Check(context.Background(), server.Client())
+	if err != nil {
+		t.Fatalf("expected Seerr result, got %v", err)
+	}
+	if result.Pending != 5 {
+		t.Fatalf("unexpected Seerr result: %+v", result)
 	}
-	if !strings.Contains(text, "5 pending requests") {
+	if text := result.Text(); !strings.Contains(text, "5 pending requests") {
 		t.Fatalf("unexpected Seerr output: %q", text)
 	}
 }
@@ -501,7 +523,7 @@ func TestCollectMediaStatusesDebugSkipReasons(t *testing.T) {
 	cfg.Services.Radarr = []config.ServiceConfig{{Name: "RemoteHTTP", URL: "http://radarr.example.com", APIKey: "secret", Enabled: true}}
 
 	stderr := captureStderr(t, func() {
-		results := CollectMediaStatuses(cfg, nil, &http.Client{}, true)
+		results := CollectMediaStatuses(context.Background(), cfg, nil, &http.Client{}, nil, true)
 		if len(results) != 0 {
 			t.Fatalf("expected no ready services, got %+v", results)
 		}
@@ -524,7 +546,7 @@ func TestCollectMediaStatusesSkipReasonsRequireDebug(t *testing.T) {
 	cfg.Services.Plex = []config.ServiceConfig{{Name: "Disabled", URL: "https://plex.example.com", Token: "secret", Enabled: false}}
 
 	stderr := captureStderr(t, func() {
-		_ = CollectMediaStatuses(cfg, nil, &http.Client{}, false)
+		_ = CollectMediaStatuses(context.Background(), cfg, nil, &http.Client{}, nil, false)
 	})
 	if stderr != "" {
 		t.Fatalf("expected no skip diagnostics with debug disabled, got %q", stderr)
@@ -584,7 +606,7 @@ func TestCollectMediaStatusesLimitsConcurrency(t *testing.T) {
 
 	done := make(chan []MediaStatus, 1)
 	go func() {
-		done <- collectMediaStatuses(services, &http.Client{}, false)
+		done <- collectMediaStatuses(context.Background(), services, &http.Client{}, nil, false)
 	}()
 
 	for i := 0; i < MaxConcurrentMediaChecks(); i++ {
@@ -608,6 +630,58 @@ func TestCollectMediaStatusesLimitsConcurrency(t *testing.T) {
 	}
 }
 
+func TestCollectMediaStatusesSharesCallerPool(t *testing.T) {
+	pool := util.NewPool(1)
+	holding, busy := make(chan struct{}), make(chan struct{})
+	pool.Go(context.Background(), func() {
+		close(holding)
+		<-busy
+	})
+	<-holding
+
+	entered := make(chan struct{}, 1)
+	release := make(chan struct{})
+	done := make(chan []MediaStatus, 1)
+	go func() {
+		done <- collectMediaStatuses(context.Background(), []Service{blockingTestService{name: "Seerr", entered: entered, release: release}}, &http.Client{}, pool, false)
+	}()
+
+	select {
+	case <-entered:
+		t.Fatal("expected the check to wait for the shared pool slot")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(busy)
+	<-entered
+	close(release)
+	if results := <-done; len(results) != 1 || results[0].Error != "" {
+		t.Fatalf("unexpected results: %+v", results)
+	}
+}
+
+func TestCollectMediaStatusesReportsBudgetTimeout(t *testing.T) {
+	entered := make(chan struct{}, 2)
+	release := make(chan struct{})
+	defer close(release)
+	services := []Service{
+		blockingTestService{name: "Slow", entered: entered, release: release},
+		countingTestService{name: "Fast", calls: new(atomic.Int32)},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	results := collectMediaStatuses(ctx, services, &http.Client{}, nil, false)
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %+v", results)
+	}
+	if results[0].Name != "Slow" || results[0].Error != ErrorTimedOut || results[0].Text() != "timed out" {
+		t.Fatalf("expected the slow service to time out, got %+v", results[0])
+	}
+	if results[1].Name != "Fast" || results[1].Error != "" {
+		t.Fatalf("expected the fast service to finish, got %+v", results[1])
+	}
+}
+
 func TestCountAvailableRecords_IncludesAvailable(t *testing.T) {
 	records := []json.RawMessage{
 		json.RawMessage(`{"isAvailable":true}`),
@@ -663,9 +737,9 @@ func TestPlexRenderOversized_NoPanic(t *testing.T) {
 	defer server.Close()
 
 	svc := plexService{cfg: config.ServiceConfig{Name: "Test", URL: server.URL, Token: "t", Enabled: true}}
-	text, _, ok := svc.Render(server.Client(), false)
-	if ok {
-		t.Fatalf("expected Render to fail on oversized XML, got text: %q", text)
+	result, err := svc.Check(context.Background(), server.Client())
+	if err == nil {
+		t.Fatalf("expected Check to fail on oversized XML, got result: %+v", result)
 	}
 }
 
@@ -679,3 +753,57 @@ func TestCountAvailableRecords_InvalidJSONCounted(t *testing.T) {
 		t.Fatalf("expected 2 (1 invalid + 1 available), got %d", got)
 	}
 }
+
+func TestResultTextAndSeverity(t *testing.T) {
+	tests := []struct {
+		result   Result
+		text     string
+		severity display.Severity
+	}{
+		{Result{Kind: KindPlex, HasBandwidth: true}, "No active streams", display.SeverityOK},
+		{Result{Kind: KindPlex, Streams: 2, BandwidthBps: 3_500_000, HasBandwidth: true}, "2 streams (3.50 Mbps)", display.SeverityOK},
+		{Result{Kind: KindJellyfin, Streams: 1, Transcodes: 1}, "1 streams, 1 transcodes", display.SeverityWarning},
+		{Result{Kind: KindSonarr}, "No missing episodes", display.SeverityOK},
+		{Result{Kind: KindSonarr, Missing: 1}, "1 missing episode", display.SeverityWarning},
+		{Result{Kind: KindRadarr, Missing: 3}, "3 missing movies", display.SeverityWarning},
+		{Result{Kind: KindSeerr, Pending: 1}, "1 pending request", display.SeverityWarning},
+	}
+	for _, tt := range tests {
+		if got := tt.result.Text(); got != tt.text {
+			t.Fatalf("Text() = %q, want %q", got, tt.text)
+		}
+		if got := tt.result.Severity(config.ThresholdsConfig{}); got != tt.severity {
+			t.Fatalf("Severity() for %q = %q, want %q", tt.text, got, tt.severity)
+		}
+	}
+}
+
+func TestResultSeverityUsesConfiguredLevels(t *testing.T) {
+	warn, critical := 3.0, 5.0
+	th := config.ThresholdsConfig{
+		Streams:    &config.Threshold{Warn: &warn, Critical: &critical},
+		Transcodes: &config.Threshold{},
+	}
+	tests := []struct {
+		streams int
+		want    display.Severity
+	}{
+		{2, display.SeverityOK},
+		{3, display.SeverityWarning},
+		{6, display.SeverityCritical},
+	}
+	for _, tt := range tests {
+		result := Result{Kind: KindPlex, Streams: tt.streams, Transcodes: tt.streams}
+		if got := result.Severity(th); got != tt.want {
+			t.Fatalf("Severity() with %d streams = %q, want %q", tt.streams, got, tt.want)
+		}
+	}
+}
+
+func TestMediaStatusErrorText(t *testing.T) {
+	status := MediaStatus{Name: "Plex", Kind: KindPlex, Error: ErrorAuth, Detail: "401 unauthorized"}
+	status.evaluate(config.ThresholdsConfig{})
+	if status.Text() != "unavailable (401 unauthorized)" || status.Color() != display.Yellow {
+		t.Fatalf("unexpected error status rendering: %q %q", status.Text(), status.Color())
+	}
+}
@@ -0,0 +1,564 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"motd/config"
+	"motd/display"
+	"motd/media"
+	"motd/system"
+)
+
+const (
+	defaultMetricsListen   = "127.0.0.1:9877"
+	defaultMetricsInterval = 60 * time.Second
+	minMetricsInterval     = time.Second
+	openMetricsContentType = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+)
+
+// metricsExport is one collection pass rendered by writeMetrics.
+type metricsExport struct {
+	Snapshot    system.SystemSnapshot
+	Media       []media.MediaStatus
+	CollectedAt time.Time
+}
+
+func collectMetricsExport(ctx context.Context, cfg config.Config, serviceSet map[string]bool, client *http.Client, debug bool) metricsExport {
+	run := collectAll(ctx, cfg, serviceSet, client, false, debug)
+	return metricsExport{
+		Snapshot:    run.snapshot.Shared(),
+		Media:       run.statuses,
+		CollectedAt: time.Now(),
+	}
+}
+
+type metricLabel struct {
+	name  string
+	value string
+}
+
+type metricSample struct {
+	labels []metricLabel
+	value  float64
+}
+
+// metricFamily is one OpenMetrics family. Every motd reading is a
+// point-in-time value, so families are gauges unless they carry build info.
+type metricFamily struct {
+	name    string
+	typ     string
+	help    string
+	unit    string
+	samples []metricSample
+}
+
+func (f *metricFamily) add(value float64, labels ...metricLabel) {
+	f.samples = append(f.samples, metricSample{labels: labels, value: value})
+}
+
+// addStates adds one sample per state under the label name, 1 for current and
+// 0 for the others, so a state change flips values instead of starting a new
+// series. A current state missing from states gets a sample of its own.
+func (f *metricFamily) addStates(name string, states []string, current string, labels ...metricLabel) {
+	if !slices.Contains(states, current) {
+		states = append(slices.Clone(states), current)
+	}
+	for _, state := range states {
+		f.add(boolValue(state == current), append(slices.Clone(labels), label(name, state))...)
+	}
+}
+
+func label(name, value string) metricLabel {
+	return metricLabel{name: name, value: value}
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// metricsFormat selects the exposition format written by writeMetrics.
+type metricsFormat int
+
+const (
+	// formatOpenMetrics is served by serve-metrics.
+	formatOpenMetrics metricsFormat = iota
+	// formatPrometheusText is the 0.0.4 text format read by the node_exporter
+	// textfile collector, which rejects UNIT lines, info types and "# EOF".
+	formatPrometheusText
+)
+
+// writeMetrics renders export in the given format. Families without samples
+// are omitted so unavailable readings do not appear as zero.
+func writeMetrics(w io.Writer, export metricsExport, format metricsFormat) error {
+	var b strings.Builder
+	for _, family := range metricFamilies(export) {
+		family.write(&b, format)
+	}
+	if format == formatOpenMetrics {
+		b.WriteString("# EOF\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// The states exported as one 0/1 series each, so that a pool, workload or
+// unit changing state keeps its series.
+var (
+	zfsPoolStates        = []string{"ONLINE", "DEGRADED", "FAULTED", "OFFLINE", "REMOVED", "UNAVAIL", "SUSPENDED"}
+	workloadStates       = []string{"running", "stopped", "failed", "unknown"}
+	workloadHealthStates = []string{"healthy", "unhealthy", "starting", "none", "unknown"}
+	unitActiveStates     = []string{"active", "reloading", "inactive", "failed", "activating", "deactivating"}
+	mediaErrorCategories = []media.ErrorKind{media.ErrorAuth, media.ErrorTimeout, media.ErrorUnreachable, media.ErrorBadResponse, media.ErrorTLS, media.ErrorTimedOut}
+)
+
+func metricFamilies(export metricsExport) []*metricFamily {
+	snap := export.Snapshot
+	newFamily := func(name, unit, help string) *metricFamily {
+		return &metricFamily{name: name, typ: "gauge", unit: unit, help: help}
+	}
+
+	info := &metricFamily{name: "motd_build", typ: "info", help: "motd build information."}
+	info.add(1, label("version", VERSION))
+	collected := newFamily("motd_collected_timestamp_seconds", "seconds", "Unix time of the collection these readings come from.")
+	if !export.CollectedAt.IsZero() {
+		collected.add(float64(export.CollectedAt.UnixNano()) / float64(time.Second))
+	}
+
+	uptime := newFamily("motd_uptime_seconds", "seconds", "System uptime.")
+	if snap.Uptime != nil {
+		uptime.add(snap.Uptime.Seconds)
+	}
+	updatesPending := newFamily("motd_updates_pending", "", "Pending package updates.")
+	updatesSecurity := newFamily("motd_updates_security", "", "Pending package updates from security repositories.")
+	rebootRequired := newFamily("motd_reboot_required", "", "Whether the host needs a reboot for installed updates or a newer kernel.")
+	if updates := snap.Updates; updates != nil {
+		if updates.Pending != nil {
+			updatesPending.add(float64(*updates.Pending), label("manager", updates.Manager))
+		}
+		if updates.Security != nil {
+			updatesSecurity.add(float64(*updates.Security), label("manager", updates.Manager))
+		}
+		rebootRequired.add(boolValue(updates.RebootNeeded()))
+	}
+
+	loadAverage := newFamily("motd_load_average", "", "System load average.")
+	cpuCores := newFamily("motd_cpu_cores", "", "Logical CPU count.")
+	cpuUsage := newFamily("motd_cpu_usage_percent", "", "Overall CPU utilization over a short sample.")
+	cpuIOWait := newFamily("motd_cpu_iowait_percent", "", "Share of CPU time spent waiting for I/O over a short sample.")
+	cpuSteal := newFamily("motd_cpu_steal_percent", "", "Share of CPU time taken by the hypervisor over a short sample.")
+	if load := snap.Load; load != nil {
+		for i, period := range []string{"1m", "5m", "15m"} {
+			if i < len(load.Averages) {
+				loadAverage.add(load.Averages[i], label("period", period))
+			}
+		}
+		if load.Cores > 0 {
+			cpuCores.add(float64(load.Cores))
+		}
+		if load.CPUPercent != nil {
+			cpuUsage.add(*load.CPUPercent)
+		}
+		if load.IOWaitPercent != nil {
+			cpuIOWait.add(*load.IOWaitPercent)
+		}
+		if load.StealPercent != nil {
+			cpuSteal.add(*load.StealPercent)
+		}
+	}
+
+	memoryTotal := newFamily("motd_memory_total_bytes", "bytes", "Total physical memory.")
+	memoryUsed := newFamily("motd_memory_used_bytes", "bytes", "Used physical memory.")
+	if snap.Memory != nil {
+		memoryTotal.add(float64(snap.Memory.TotalBytes))
+		memoryUsed.add(float64(snap.Memory.UsedBytes))
+	}
+	swapTotal := newFamily("motd_swap_total_bytes", "bytes", "Total swap space.")
+	swapUsed := newFamily("motd_swap_used_bytes", "bytes", "Used swap space.")
+	zramOriginal := newFamily("motd_zram_original_bytes", "bytes", "Uncompressed data stored in a zram device.")
+	zramUsed := newFamily("motd_zram_memory_used_bytes", "bytes", "Memory a zram device uses to store its data.")
+	oomKills := &metricFamily{name: "motd_oom_kills", typ: "counter", help: "Processes killed by the OOM killer since boot."}
+	if memory := snap.Memory; memory != nil {
+		if memory.SwapTotalBytes > 0 {
+			swapTotal.add(float64(memory.SwapTotalBytes))
+			swapUsed.add(float64(memory.SwapUsedBytes))
+		}
+		for _, device := range memory.ZRAM {
+			zramOriginal.add(float64(device.OriginalBytes), label("device", device.Name))
+			zramUsed.add(float64(device.MemUsedBytes), label("device", device.Name))
+		}
+		if memory.OOMKills != nil {
+			oomKills.add(float64(*memory.OOMKills))
+		}
+	}
+	pressure := newFamily("motd_pressure_stall_percent", "", "Share of the last 10 seconds tasks stalled on a resource.")
+	if snap.Pressure != nil {
+		for _, resource := range snap.Pressure.Resources() {
+			pressure.add(resource.Stall.Some.Avg10, label("resource", resource.Name), label("kind", "some"))
+			if resource.Stall.Full != nil && resource.Name != "cpu" {
+				pressure.add(resource.Stall.Full.Avg10, label("resource", resource.Name), label("kind", "full"))
+			}
+		}
+	}
+
+	bandwidth := newFamily("motd_bandwidth_month_bytes", "bytes", "Month-to-date network traffic.")
+	bandwidthEstimate := newFamily("motd_bandwidth_month_estimate_bytes", "bytes", "Estimated end-of-month network traffic.")
+	if bw := snap.Bandwidth; bw != nil {
+		bandwidth.add(float64(bw.RxBytes), label("interface", bw.Interface), label("direction", "rx"))
+		bandwidth.add(float64(bw.TxBytes), label("interface", bw.Interface), label("direction", "tx"))
+		bandwidthEstimate.add(float64(bw.RxEstimateBytes), label("interface", bw.Interface), label("direction", "rx"))
+		bandwidthEstimate.add(float64(bw.TxEstimateBytes), label("interface", bw.Interface), label("direction", "tx"))
+	}
+	interfaceUp := newFamily("motd_network_interface_up", "", "Whether a network interface link is up.")
+	interfaceSpeed := newFamily("motd_network_interface_speed_bits_per_second", "", "Network interface link speed.")
+	for _, iface := range snap.Interfaces {
+		name := label("interface", iface.Name)
+		interfaceUp.add(boolValue(iface.State == system.LinkUp), name)
+		if iface.SpeedMbps > 0 {
+			interfaceSpeed.add(float64(iface.SpeedMbps)*1e6, name)
+		}
+		if bw := iface.Bandwidth; bw != nil {
+			bandwidth.add(float64(bw.RxBytes), name, label("direction", "rx"))
+			bandwidth.add(float64(bw.TxBytes), name, label("direction", "tx"))
+			bandwidthEstimate.add(float64(bw.RxEstimateBytes), name, label("direction", "rx"))
+			bandwidthEstimate.add(float64(bw.TxEstimateBytes), name, label("direction", "tx"))
+		}
+	}
+
+	processes := newFamily("motd_processes", "", "Running process count.")
+	if snap.Processes != nil {
+		processes.add(float64(snap.Processes.Count))
+	}
+	users := newFamily("motd_logged_in_users", "", "Logged in user count.")
+	if snap.Users != nil {
+		users.add(float64(snap.Users.Count))
+	}
+
+	diskTotal := newFamily("motd_disk_total_bytes", "bytes", "Filesystem size.")
+	diskUsed := newFamily("motd_disk_used_bytes", "bytes", "Filesystem space in use.")
+	inodesTotal := newFamily("motd_disk_inodes", "", "Filesystem inode count.")
+	inodesUsed := newFamily("motd_disk_inodes_used", "", "Filesystem inodes in use.")
+	for _, disk := range snap.Disks {
+		diskTotal.add(float64(disk.TotalBytes), label("mount", disk.Path))
+		diskUsed.add(float64(disk.UsedBytes), label("mount", disk.Path))
+		if disk.InodesTotal > 0 {
+			inodesTotal.add(float64(disk.InodesTotal), label("mount", disk.Path))
+			inodesUsed.add(float64(disk.InodesUsed), label("mount", disk.Path))
+		}
+	}
+
+	zfsHealthy := newFamily("motd_zfs_pool_healthy", "", "Whether a ZFS pool is ONLINE.")
+	zfsState := newFamily("motd_zfs_pool_state", "", "Whether a ZFS pool is in the given health state.")
+	zfsSize := newFamily("motd_zfs_pool_size_bytes", "bytes", "ZFS pool size.")
+	zfsAllocated := newFamily("motd_zfs_pool_allocated_bytes", "bytes", "ZFS pool space allocated, snapshots and reservations included.")
+	zfsFragmentation := newFamily("motd_zfs_pool_fragmentation_percent", "", "ZFS pool free space fragmentation.")
+	zfsLastScrub := newFamily("motd_zfs_pool_last_scrub_timestamp_seconds", "seconds", "Unix time the last ZFS scrub finished.")
+	for _, pool := range snap.ZFSPools {
+		name := label("pool", pool.Name)
+		zfsHealthy.add(boolValue(pool.Health == "ONLINE"), name)
+		zfsState.addStates("state", zfsPoolStates, pool.Health, name)
+		zfsSize.add(float64(pool.SizeBytes), name)
+		zfsAllocated.add(float64(pool.AllocatedBytes), name)
+		if pool.FragmentationPercent != nil {
+			zfsFragmentation.add(*pool.FragmentationPercent, name)
+		}
+		if pool.LastScrub != nil {
+			zfsLastScrub.add(float64(pool.LastScrub.Unix()), name)
+		}
+	}
+
+	mdDegraded := newFamily("motd_md_array_degraded", "", "Whether a Linux software RAID array is degraded.")
+	mdSync := newFamily("motd_md_array_sync_progress_percent", "", "Progress of a running md resync, recovery, reshape, check or repair.")
+	for _, array := range snap.RAIDArrays {
+		mdDegraded.add(boolValue(array.Degraded), label("array", array.Name), label("level", array.Level))
+		if array.SyncPercent != nil {
+			mdSync.add(*array.SyncPercent, label("array", array.Name), label("action", array.SyncAction))
+		}
+	}
+	btrfsErrors := newFamily("motd_btrfs_device_errors", "", "Btrfs device error counters since they were last reset.")
+	btrfsMissing := newFamily("motd_btrfs_device_missing", "", "Whether a Btrfs device is missing.")
+	for _, filesystem := range snap.Btrfs {
+		for _, device := range filesystem.Devices {
+			fs := []metricLabel{label("uuid", filesystem.UUID), label("devid", device.ID)}
+			btrfsMissing.add(boolValue(device.Missing), fs...)
+			for _, counter := range []struct {
+				kind  string
+				value uint64
+			}{
+				{"write", device.WriteErrors},
+				{"read", device.ReadErrors},
+				{"flush", device.FlushErrors},
+				{"corruption", device.CorruptionErrors},
+				{"generation", device.GenerationErrors},
+			} {
+				btrfsErrors.add(float64(counter.value), append(fs, label("type", counter.kind))...)
+			}
+		}
+	}
+
+	smartPassed := newFamily("motd_smart_passed", "", "Whether a drive passes its SMART overall health assessment.")
+	smartReallocated := newFamily("motd_smart_reallocated_sectors", "", "Sectors an ATA drive has reallocated.")
+	smartPending := newFamily("motd_smart_pending_sectors", "", "Sectors an ATA drive is waiting to reallocate.")
+	smartWear := newFamily("motd_smart_percentage_used", "", "NVMe estimate of drive life used, which can exceed 100.")
+	smartPowerOn := newFamily("motd_smart_power_on_hours", "", "Hours a drive has been powered on.")
+	for _, drive := range snap.SMARTDrives {
+		device := label("device", drive.Device)
+		if drive.Passed != nil {
+			smartPassed.add(boolValue(*drive.Passed), device, label("model", drive.Model))
+		}
+		if drive.ReallocatedSectors != nil {
+			smartReallocated.add(float64(*drive.ReallocatedSectors), device)
+		}
+		if drive.PendingSectors != nil {
+			smartPending.add(float64(*drive.PendingSectors), device)
+		}
+		if drive.PercentageUsed != nil {
+			smartWear.add(*drive.PercentageUsed, device)
+		}
+		if drive.PowerOnHours != nil {
+			smartPowerOn.add(float64(*drive.PowerOnHours), device)
+		}
+	}
+
+	temperature := newFamily("motd_temperature_celsius", "celsius", "CPU temperature.")
+	if snap.Temperature != nil {
+		temperature.add(snap.Temperature.Celsius)
+	}
+	sensorTemperature := newFamily("motd_temperature_sensor_celsius", "celsius", "Temperature of a selected hwmon sensor.")
+	sensorCritical := newFamily("motd_temperature_sensor_critical_celsius", "celsius", "Critical temperature the hwmon chip reports for a sensor.")
+	if snap.Temperature != nil {
+		for _, sensor := range snap.Temperature.Sensors {
+			labels := []metricLabel{label("sensor", sensor.Name), label("chip", sensor.Chip)}
+			sensorTemperature.add(sensor.Celsius, labels...)
+			if sensor.CritCelsius != nil {
+				sensorCritical.add(*sensor.CritCelsius, labels...)
+			}
+		}
+	}
+
+	containersOnline := newFamily("motd_containers_online", "", "Container workloads reported online by the status agent.")
+	containersTotal := newFamily("motd_containers", "", "Container workloads reported by the status agent.")
+	workloadOnline := newFamily("motd_container_workload_online", "", "Whether a container workload is online.")
+	workloadState := newFamily("motd_container_workload_state", "", "Whether a container workload is in the given state.")
+	workloadHealth := newFamily("motd_container_workload_health", "", "Whether a container workload's health check is in the given state.")
+	if containers := snap.Containers; containers != nil {
+		containersOnline.add(float64(containers.Online))
+		containersTotal.add(float64(containers.Total))
+		for _, workload := range containers.Workloads {
+			name := label("name", workload.Name)
+			workloadOnline.add(boolValue(workload.Online), name)
+			workloadState.addStates("state", workloadStates, workload.State, name)
+			workloadHealth.addStates("health", workloadHealthStates, workload.Health, name)
+		}
+	}
+
+	unitsActive := newFamily("motd_systemd_units_active", "", "Watched systemd units that are active.")
+	unitsTotal := newFamily("motd_systemd_units", "", "Watched systemd units.")
+	unitActive := newFamily("motd_systemd_unit_active", "", "Whether a watched systemd unit is active.")
+	unitState := newFamily("motd_systemd_unit_state", "", "Whether a watched systemd unit is in the given active state.")
+	failedUnits := newFamily("motd_systemd_failed_units", "", "Systemd units in the failed state.")
+	if units := snap.Units; units != nil {
+		unitsActive.add(float64(units.Active))
+		unitsTotal.add(float64(units.Total))
+		for _, unit := range units.Units {
+			name := label("name", unit.Name)
+			unitActive.add(boolValue(unit.Active), name)
+			unitState.addStates("state", unitActiveStates, unit.ActiveState, name)
+		}
+		failedUnits.add(float64(len(units.Failed)))
+	}
+
+	mediaUp := newFamily("motd_media_up", "", "Whether the last media service check succeeded.")
+	mediaError := newFamily("motd_media_check_error", "", "Whether the last media service check failed with the given error category.")
+	streams := newFamily("motd_media_streams", "", "Active media streams.")
+	transcodes := newFamily("motd_media_transcodes", "", "Active media transcodes.")
+	streamBandwidth := newFamily("motd_media_stream_bandwidth_bits_per_second", "", "Combined bandwidth of active media streams.")
+	missing := newFamily("motd_media_missing", "", "Monitored items missing from Sonarr or Radarr.")
+	pending := newFamily("motd_media_pending_requests", "", "Pending Seerr requests.")
+	for _, status := range export.Media {
+		service := []metricLabel{label("service", status.Name), label("kind", string(status.Kind))}
+		mediaUp.add(boolValue(status.Error == ""), service...)
+		for _, category := range mediaErrorCategories {
+			mediaError.add(boolValue(status.Error == category), append(slices.Clone(service), label("category", string(category)))...)
+		}
+		if status.Error != "" {
+			continue
+		}
+		result := status.Result
+		switch result.Kind {
+		case media.KindPlex, media.KindJellyfin:
+			streams.add(float64(result.Streams), service...)
+			transcodes.add(float64(result.Transcodes), service...)
+			if result.HasBandwidth {
+				streamBandwidth.add(float64(result.BandwidthBps), service...)
+			}
+		case media.KindSonarr, media.KindRadarr:
+			missing.add(float64(result.Missing), service...)
+		case media.KindSeerr:
+			pending.add(float64(result.Pending), service...)
+		}
+	}
+
+	return []*metricFamily{
+		info, collected, uptime, updatesPending, updatesSecurity, rebootRequired, loadAverage, cpuCores, cpuUsage, cpuIOWait, cpuSteal,
+		memoryTotal, memoryUsed, swapTotal, swapUsed, zramOriginal, zramUsed, oomKills, pressure,
+		bandwidth, bandwidthEstimate, interfaceUp, interfaceSpeed, processes, users,
+		diskTotal, diskUsed, inodesTotal, inodesUsed,
+		zfsHealthy, zfsState, zfsSize, zfsAllocated, zfsFragmentation, zfsLastScrub,
+		mdDegraded, mdSync, btrfsErrors, btrfsMissing,
+		smartPassed, smartReallocated, smartPending, smartWear, smartPowerOn,
+		temperature, sensorTemperature, sensorCritical,
+		containersOnline, containersTotal, workloadOnline, workloadState, workloadHealth,
+		unitsActive, unitsTotal, unitActive, unitState, failedUnits,
+		mediaUp, mediaError, streams, transcodes, streamBandwidth, missing, pending,
+	}
+}
+
+func (f *metricFamily) write(b *strings.Builder, format metricsFormat) {
+	if len(f.samples) == 0 {
+		return
+	}
+	sampleName := f.name
+	switch f.typ {
+	case "info":
+		sampleName += "_info"
+	case "counter":
+		sampleName += "_total"
+	}
+	if format == formatPrometheusText {
+		typ := f.typ
+		if typ == "info" {
+			typ = "gauge"
+		}
+		fmt.Fprintf(b, "# HELP %s %s\n", sampleName, escapeMetricText(f.help, false))
+		fmt.Fprintf(b, "# TYPE %s %s\n", sampleName, typ)
+	} else {
+		fmt.Fprintf(b, "# TYPE %s %s\n", f.name, f.typ)
+		if f.unit != "" {
+			fmt.Fprintf(b, "# UNIT %s %s\n", f.name, f.unit)
+		}
+		fmt.Fprintf(b, "# HELP %s %s\n", f.name, escapeMetricText(f.help, false))
+	}
+	for _, sample := range f.samples {
+		b.WriteString(sampleName)
+		if len(sample.labels) > 0 {
+			pairs := make([]string, 0, len(sample.labels))
+			for _, l := range sample.labels {
+				pairs = append(pairs, fmt.Sprintf(`%s="%s"`, l.name, escapeMetricText(l.value, true)))
+			}
+			b.WriteString("{" + strings.Join(pairs, ",") + "}")
+		}
+		b.WriteString(" " + strconv.FormatFloat(sample.value, 'f', -1, 64) + "\n")
+	}
+}
+
+func escapeMetricText(s string, quoted bool) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, "\n", `\n`)
+	if quoted {
+		s = strings.ReplaceAll(s, `"`, `\"`)
+	}
+	return s
+}
+
+// metricsCache serializes collection and reuses the last result until
+// interval has passed, so concurrent or frequent scrapes never run more
+// than one round of media checks at a time.
+type metricsCache struct {
+	mu          sync.Mutex
+	interval    time.Duration
+	collect     func() metricsExport
+	now         func() time.Time
+	export      metricsExport
+	refreshedAt time.Time
+}
+
+func newMetricsCache(interval time.Duration, collect func() metricsExport) *metricsCache {
+	return &metricsCache{interval: interval, collect: collect, now: time.Now}
+}
+
+func (c *metricsCache) get() metricsExport {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	if c.refreshedAt.IsZero() || now.Sub(c.refreshedAt) >= c.interval {
+		c.export = c.collect()
+		c.refreshedAt = now
+	}
+	return c.export
+}
+
+func metricsHandler(cache *metricsCache) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /metrics", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", openMetricsContentType)
+		_ = writeMetrics(w, cache.get(), formatOpenMetrics)
+	})
+	return mux
+}
+
+func handleServeMetrics(args []string) {
+	fs := flagSet("serve-metrics")
+	debug := fs.Bool("d", false, "Enable debug mode")
+	configPath := fs.String("config", "", "Load config from a specific JSON file")
+	noConfig := fs.Bool("no-config", false, "Skip config loading and export system metrics only")
+	servicesFilter := fs.String("services", "", "Only export selected media services (comma-separated)")
+	listen := fs.String("listen", defaultMetricsListen, "Address to serve /metrics on")
+	interval := fs.Duration("interval", defaultMetricsInterval, "Minimum time between collections; scrapes in between reuse the last result")
+	if err := fs.Parse(args); err != nil {
+		os.Exit(2)
+	}
+	display.SetColorEnabled(false)
+
+	if *interval < minMetricsInterval {
+		fmt.Fprintf(os.Stderr, "Error: -interval must be at least %s\n", minMetricsInterval)
+		os.Exit(2)
+	}
+	if _, _, err := net.SplitHostPort(*listen); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: invalid -listen address %q: %v\n", *listen, err)
+		os.Exit(2)
+	}
+
+	cfg, err := config.Load(*configPath, *noConfig, func(msg string, args ...interface{}) {
+		display.DebugLog(*debug, msg, args...)
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading configuration: %v\n", err)
+		os.Exit(1)
+	}
+	serviceSet, err := parseServiceFilter(*servicesFilter)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	client := newHTTPClient()
+	cache := newMetricsCache(*interval, func() metricsExport {
+		ctx, cancel := budgetContext(cfg, 0, *debug)
+		defer cancel()
+		return collectMetricsExport(ctx, cfg, serviceSet, client, *debug)
+	})
+	server := &http.Server{
+		Addr:              *listen,
+		Handler:           metricsHandler(cache),
+		ReadHeaderTimeout: 5 * time.Second,
+		WriteTimeout:      2 * time.Minute,
+	}
+	fmt.Fprintf(os.Stderr, "Serving metrics on http://%s/metrics\n", *listen)
+	if err := server.ListenAndServe(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"motd/media"
+	"motd/system"
+)
+
+func TestWriteOpenMetrics(t *testing.T) {
+	failed, powerOnHours, iowait, pendingUpdates, oomKills := false, uint64(21412), 7.5, 4, uint64(3)
+	export := metricsExport{
+		Snapshot: system.SystemSnapshot{
+			Updates:    &system.UpdateInfo{Manager: system.PackageManagerPacman, Pending: &pendingUpdates, RunningKernel: "6.9.7-arch1-1", NewestKernel: "6.10.2-arch1-1"},
+			Load:       &system.LoadInfo{Averages: []float64{0.5, 0.25, 0.1}, Cores: 4, IOWaitPercent: &iowait},
+			Memory:     &system.MemoryInfo{TotalBytes: 8 << 30, UsedBytes: 2 << 30, SwapTotalBytes: 1 << 30, OOMKills: &oomKills, ZRAM: []system.ZRAMDevice{{Name: "zram0", OriginalBytes: 4096, MemUsedBytes: 1024}}},
+			Pressure:   &system.PressureInfo{IO: &system.PressureStall{Some: system.PressureAverages{Avg10: 5.25}, Full: &system.PressureAverages{Avg10: 1.5}}},
+			Disks:      system.DiskList{{Label: "Disk (/)", Path: "/", TotalBytes: 100, UsedBytes: 40, InodesTotal: 1000, InodesUsed: 250}},
+			RAIDArrays: system.RAIDArrayList{{Name: "md1", Level: "raid5", Degraded: true}},
+			ZFSPools:   system.ZFSPoolList{{Name: "tank", Health: "DEGRADED", SizeBytes: 1000, AllocatedBytes: 600}},
+			Interfaces: system.InterfaceList{
+				{Name: "eth0", State: system.LinkUp, SpeedMbps: 1000, Bandwidth: &system.BandwidthInfo{Interface: "eth0", RxBytes: 500, TxBytes: 100}},
+				{Name: "eth1", State: system.LinkDown},
+			},
+			SMARTDrives:  system.SMARTDriveList{{Device: "sda", Model: "WDC WD80EFZZ", Passed: &failed, PowerOnHours: &powerOnHours}},
+			Temperature:  &system.TemperatureInfo{Celsius: 58, Sensors: []system.TemperatureSensor{{Name: "drivetemp", Chip: "drivetemp", Celsius: 36}}},
+			Users:        &system.UserInfo{Count: 1, LastLogin: &system.LastLogin{User: "alice", Time: time.Unix(1791882720, 0)}},
+			FailedLogins: &system.FailedLogins{Count: 37, SinceLastLogin: true},
+			Units:        &system.UnitStatus{Active: 1, Total: 1, Units: []system.UnitState{{Name: "nginx.service", ActiveState: "active", SubState: "running", Active: true}}},
+			Containers: &system.ContainerStatus{Online: 1, Total: 2, Workloads: []system.WorkloadStatus{
+				{Name: "web", State: "running", Health: "healthy", Online: true},
+				{Name: `db "primary"`, State: "stopped", Health: "none"},
+			}},
+		},
+		Media: []media.MediaStatus{
+			{Name: "Plex (Main)", Kind: media.KindPlex, Result: media.Result{Kind: media.KindPlex, Streams: 2, Transcodes: 1, BandwidthBps: 8_000_000, HasBandwidth: true}},
+			{Name: "Sonarr", Kind: media.KindSonarr, Result: media.Result{Kind: media.KindSonarr, Missing: 3}},
+			{Name: "Seerr", Kind: media.KindSeerr, Error: media.ErrorAuth, Detail: "401 unauthorized"},
+		},
+	}
+
+	var b strings.Builder
+	if err := writeMetrics(&b, export, formatOpenMetrics); err != nil {
+		t.Fatalf("writeMetrics: %v", err)
+	}
+	out := b.String()
+	for _, want := range []string{
+		"# TYPE motd_build info\n",
+		"motd_updates_pending{manager=\"pacman\"} 4\n",
+		"motd_reboot_required 1\n",
+		"motd_load_average{period=\"1m\"} 0.5\n",
+		"motd_cpu_cores 4\n",
+		"motd_cpu_iowait_percent 7.5\n",
+		"# UNIT motd_memory_used_bytes bytes\n",
+		"motd_memory_used_bytes 2147483648\n",
+		"motd_swap_used_bytes 0\n",
+		"motd_zram_memory_used_bytes{device=\"zram0\"} 1024\n",
+		"# TYPE motd_oom_kills counter\n",
+		"motd_oom_kills_total 3\n",
+		"motd_pressure_stall_percent{resource=\"io\",kind=\"full\"} 1.5\n",
+		"motd_disk_used_bytes{mount=\"/\"} 40\n",
+		"motd_disk_inodes_used{mount=\"/\"} 250\n",
+		"motd_zfs_pool_healthy{pool=\"tank\"} 0\n",
+		"motd_zfs_pool_state{pool=\"tank\",state=\"ONLINE\"} 0\n",
+		"motd_zfs_pool_state{pool=\"tank\",state=\"DEGRADED\"} 1\n",
+		"motd_zfs_pool_allocated_bytes{pool=\"tank\"} 600\n",
+		"motd_md_array_degraded{array=\"md1\",level=\"raid5\"} 1\n",
+		"motd_network_interface_up{interface=\"eth0\"} 1\n",
+		"motd_network_interface_up{interface=\"eth1\"} 0\n",
+		"motd_network_interface_speed_bits_per_second{interface=\"eth0\"} 1000000000\n",
+		"motd_bandwidth_month_bytes{interface=\"eth0\",direction=\"rx\"} 500\n",
+		"motd_smart_passed{device=\"sda\",model=\"WDC WD80EFZZ\"} 0\n",
+		"motd_smart_power_on_hours{device=\"sda\"} 21412\n",
+		"motd_temperature_celsius 58\n",
+		"motd_temperature_sensor_celsius{sensor=\"drivetemp\",chip=\"drivetemp\"} 36\n",
+		"motd_containers_online 1\n",
+		"motd_systemd_unit_active{name=\"nginx.service\"} 1\n",
+		"motd_systemd_unit_state{name=\"nginx.service\",state=\"active\"} 1\n",
+		"motd_systemd_unit_state{name=\"nginx.service\",state=\"failed\"} 0\n",
+		"motd_systemd_failed_units 0\n",
+		"motd_container_workload_online{name=\"db \\\"primary\\\"\"} 0\n",
+		"motd_container_workload_state{name=\"db \\\"primary\\\"\",state=\"stopped\"} 1\n",
+		"motd_container_workload_state{name=\"web\",state=\"stopped\"} 0\n",
+		"motd_container_workload_health{name=\"web\",health=\"healthy\"} 1\n",
+		"motd_media_streams{service=\"Plex (Main)\",kind=\"plex\"} 2\n",
+		"motd_media_stream_bandwidth_bits_per_second{service=\"Plex (Main)\",kind=\"plex\"} 8000000\n",
+		"motd_media_missing{service=\"Sonarr\",kind=\"sonarr\"} 3\n",
+		"motd_media_up{service=\"Seerr\",kind=\"seerr\"} 0\n",
+		"motd_media_check_error{service=\"Seerr\",kind=\"seerr\",category=\"auth\"} 1\n",
+		"motd_media_check_error{service=\"Seerr\",kind=\"seerr\",category=\"timeout\"} 0\n",
+		"motd_media_check_error{service=\"Sonarr\",kind=\"sonarr\",category=\"auth\"} 0\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected %q in output:\n%s", want, out)
+		}
+	}
+	for _, absent := range []string{"motd_last_login", "motd_failed_logins", "motd_temperature_sensor_critical_celsius", "motd_updates_security{", "motd_media_pending_requests", "motd_uptime_seconds"} {
+		if strings.Contains(out, absent) {
+			t.Fatalf("expected unavailable family %s to be omitted:\n%s", absent, out)
+		}
+	}
+	if !strings.HasSuffix(out, "# EOF\n") {
+		t.Fatalf("expected output to end with # EOF, got %q", out[len(out)-20:])
+	}
+}
+
+func TestMetricFamilyAddStatesKeepsUnknownState(t *testing.T) {
+	family := &metricFamily{name: "motd_systemd_unit_state", typ: "gauge"}
+	family.addStates("state", []string{"active", "failed"}, "maintenance", label("name", "x.service"))
+
+	var b strings.Builder
+	family.write(&b, formatPrometheusText)
+	for _, want := range []string{
+		"motd_systemd_unit_state{name=\"x.service\",state=\"active\"} 0\n",
+		"motd_systemd_unit_state{name=\"x.service\",state=\"failed\"} 0\n",
+		"motd_systemd_unit_state{name=\"x.service\",state=\"maintenance\"} 1\n",
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Fatalf("expected %q in output:\n%s", want, b.String())
+		}
+	}
+}
+
+func TestMetricsCacheReusesResultWithinInterval(t *testing.T) {
+	calls := 0
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	cache := newMetricsCache(time.Minute, func() metricsExport {
+		calls++
+		return metricsExport{CollectedAt: now}
+	})
+	cache.now = func() time.Time { return now }
+
+	cache.get()
+	now = now.Add(30 * time.Second)
+	cache.get()
+	if calls != 1 {
+		t.Fatalf("expected one collection within the interval, got %d", calls)
+	}
+	now = now.Add(30 * time.Second)
+	cache.get()
+	if calls != 2 {
+		t.Fatalf("expected a new collection after the interval, got %d", calls)
+	}
+}
+
+func TestMetricsHandler(t *testing.T) {
+	cache := newMetricsCache(time.Minute, func() metricsExport { return metricsExport{} })
+	server := httptest.NewServer(metricsHandler(cache))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/metrics")
+	if err != nil {
+		t.Fatalf("GET /metrics: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != openMetricsContentType {
+		t.Fatalf("unexpected response: %d %q", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+
+	resp, err = http.Post(server.URL+"/metrics", "text/plain", nil)
+	if err != nil {
+		t.Fatalf("POST /metrics: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Fatalf("expected POST to be rejected, got %d", resp.StatusCode)
+	}
+}
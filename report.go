@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -18,12 +19,14 @@ type outputReport struct {
 	Version    string            `json:"version"`
 	System     systemReport      `json:"system"`
 	Containers *containersReport `json:"containers,omitempty"`
+	Units      *unitsReport      `json:"units,omitempty"`
 	Media      []mediaJSONItem   `json:"media,omitempty"`
 }
 
 type systemReport struct {
 	TankMount string `json:"tank_mount,omitempty"`
 	Interface string `json:"interface,omitempty"`
+	system.SystemSnapshot
 }
 
 type containersReport struct {
@@ -32,6 +35,7 @@ type containersReport struct {
 	Online          int                `json:"online"`
 	Total           int                `json:"total"`
 	Status          string             `json:"status"`
+	Severity        display.Severity   `json:"severity"`
 	Workloads       []workloadJSONItem `json:"workloads"`
 }
 
@@ -42,11 +46,37 @@ type workloadJSONItem struct {
 	Online bool   `json:"online"`
 }
 
+type unitsReport struct {
+	Active   int              `json:"active"`
+	Total    int              `json:"total"`
+	Status   string           `json:"status"`
+	Severity display.Severity `json:"severity"`
+	Units    []unitJSONItem   `json:"units"`
+	Failed   []unitJSONItem   `json:"failed"`
+}
+
+type unitJSONItem struct {
+	Name        string `json:"name"`
+	LoadState   string `json:"load_state"`
+	ActiveState string `json:"active_state"`
+	SubState    string `json:"sub_state"`
+	Active      bool   `json:"active"`
+}
+
 type mediaJSONItem struct {
-	Name   string `json:"name"`
-	Status string `json:"status"`
-	Text   string `json:"text,omitempty"`
-	Error  string `json:"error,omitempty"`
+	Name         string           `json:"name"`
+	Kind         string           `json:"kind"`
+	Status       string           `json:"status"`
+	Severity     display.Severity `json:"severity"`
+	Streams      *int             `json:"streams,omitempty"`
+	Transcodes   *int             `json:"transcodes,omitempty"`
+	BandwidthBps *int64           `json:"bandwidth_bps,omitempty"`
+	Missing      *int             `json:"missing,omitempty"`
+	Pending      *int             `json:"pending,omitempty"`
+	Error        string           `json:"error,omitempty"`
+	ErrorDetail  string           `json:"error_detail,omitempty"`
+	Stale        bool             `json:"stale,omitempty"`
+	CheckedAt    string           `json:"checked_at,omitempty"`
 }
 
 func parseServiceFilter(raw string) (map[string]bool, error) {
@@ -75,17 +105,25 @@ func parseServiceFilter(raw string) (map[string]bool, error) {
 	return selected, nil
 }
 
-func renderJSON(cfg config.Config, serviceSet map[string]bool, client *http.Client, debug bool) {
-	sysCfg := system.ConfigAccessorFrom(cfg)
+func renderJSON(ctx context.Context, cfg config.Config, configPath string, serviceSet map[string]bool, client *http.Client, debug bool) {
+	snapshot, statuses, fromDaemon := daemonSnapshot(ctx, cfg, serviceSet, debug)
+	if !fromDaemon {
+		run := collectAll(ctx, cfg, serviceSet, client, true, debug)
+		snapshot, statuses = run.snapshot, run.statuses
+		if run.needsRefresh {
+			startMediaCacheRefresh(cfg, configPath, debug)
+		}
+	}
 	report := outputReport{
 		Version: VERSION,
 		System: systemReport{
-			TankMount: cfg.System.TankMount,
-			Interface: cfg.System.Network.Interface,
+			TankMount:      cfg.System.TankMount,
+			Interface:      cfg.System.Network.Interface,
+			SystemSnapshot: snapshot,
 		},
 	}
 
-	if containerStatus, ok := system.GetContainerStatus(sysCfg, debug); ok {
+	if containerStatus := snapshot.Containers; containerStatus != nil {
 		workloads := make([]workloadJSONItem, 0, len(containerStatus.Workloads))
 		for _, workload := range containerStatus.Workloads {
 			workloads = append(workloads, workloadJSONItem{Name: workload.Name, State: workload.State, Health: workload.Health, Online: workload.Online})
@@ -96,16 +134,24 @@ func renderJSON(cfg config.Config, serviceSet map[string]bool, client *http.Clie
 			Online:          containerStatus.Online,
 			Total:           containerStatus.Total,
 			Status:          containerStatus.Status,
+			Severity:        containerStatus.Severity(),
 			Workloads:       workloads,
 		}
 	}
 
-	for _, item := range media.CollectMediaStatuses(cfg, serviceSet, client, debug) {
-		status := "ok"
-		if item.Error != "" {
-			status = "error"
+	if unitStatus := snapshot.Units; unitStatus != nil {
+		report.Units = &unitsReport{
+			Active:   unitStatus.Active,
+			Total:    unitStatus.Total,
+			Status:   unitStatus.Status,
+			Severity: unitStatus.Severity(),
+			Units:    newUnitJSONItems(unitStatus.Units),
+			Failed:   newUnitJSONItems(unitStatus.Failed),
 		}
-		report.Media = append(report.Media, mediaJSONItem{Name: item.Name, Status: status, Text: item.Text, Error: item.Error})
+	}
+
+	for _, item := range statuses {
+		report.Media = append(report.Media, newMediaJSONItem(item))
 	}
 
 	encoder := json.NewEncoder(os.Stdout)
@@ -116,6 +162,42 @@ func renderJSON(cfg config.Config, serviceSet map[string]bool, client *http.Clie
 	}
 }
 
+func newUnitJSONItems(units []system.UnitState) []unitJSONItem {
+	items := make([]unitJSONItem, 0, len(units))
+	for _, unit := range units {
+		items = append(items, unitJSONItem{Name: unit.Name, LoadState: unit.LoadState, ActiveState: unit.ActiveState, SubState: unit.SubState, Active: unit.Active})
+	}
+	return items
+}
+
+func newMediaJSONItem(item media.MediaStatus) mediaJSONItem {
+	out := mediaJSONItem{Name: item.Name, Kind: string(item.Kind), Status: "ok", Severity: item.Severity, Stale: item.Stale}
+	if !item.CheckedAt.IsZero() {
+		out.CheckedAt = item.CheckedAt.UTC().Format(time.RFC3339)
+	}
+	if item.Error != "" {
+		out.Status = "error"
+		out.Error = string(item.Error)
+		out.ErrorDetail = item.Detail
+		return out
+	}
+
+	result := item.Result
+	switch result.Kind {
+	case media.KindPlex, media.KindJellyfin:
+		out.Streams = &result.Streams
+		out.Transcodes = &result.Transcodes
+		if result.HasBandwidth {
+			out.BandwidthBps = &result.BandwidthBps
+		}
+	case media.KindSonarr, media.KindRadarr:
+		out.Missing = &result.Missing
+	case media.KindSeerr:
+		out.Pending = &result.Pending
+	}
+	return out
+}
+
 type configIssue struct {
 	Level   string `json:"level"`
 	Message string `json:"message"`
@@ -1,6 +1,11 @@
 package main
 
-import "testing"
+import (
+	"testing"
+
+	"motd/media"
+	"motd/system"
+)
 
 func TestParseServiceFilter(t *testing.T) {
 	got, err := parseServiceFilter(" Plex,radarr ")
@@ -27,3 +32,36 @@ func TestParseServiceFilterEmpty(t *testing.T) {
 		t.Fatalf("expected nil filter, got %+v", got)
 	}
 }
+
+func TestNewMediaJSONItemExposesRawValues(t *testing.T) {
+	item := newMediaJSONItem(media.MediaStatus{Name: "Plex (Main)", Kind: media.KindPlex, Result: media.Result{Kind: media.KindPlex, Streams: 3, Transcodes: 1, BandwidthBps: 12_400_000, HasBandwidth: true}})
+	if item.Status != "ok" || item.Kind != "plex" {
+		t.Fatalf("unexpected status/kind: %+v", item)
+	}
+	if item.Streams == nil || *item.Streams != 3 || item.Transcodes == nil || *item.Transcodes != 1 || item.BandwidthBps == nil || *item.BandwidthBps != 12_400_000 {
+		t.Fatalf("unexpected stream values: %+v", item)
+	}
+	if item.Missing != nil || item.Pending != nil {
+		t.Fatalf("expected unrelated counts to be omitted: %+v", item)
+	}
+
+	sonarr := newMediaJSONItem(media.MediaStatus{Name: "Sonarr", Kind: media.KindSonarr, Result: media.Result{Kind: media.KindSonarr}})
+	if sonarr.Missing == nil || *sonarr.Missing != 0 || sonarr.Streams != nil {
+		t.Fatalf("expected zero missing count to be reported: %+v", sonarr)
+	}
+
+	failed := newMediaJSONItem(media.MediaStatus{Name: "Seerr", Kind: media.KindSeerr, Error: media.ErrorAuth, Detail: "401 unauthorized"})
+	if failed.Status != "error" || failed.Error != "auth" || failed.ErrorDetail != "401 unauthorized" || failed.Pending != nil {
+		t.Fatalf("unexpected error item: %+v", failed)
+	}
+}
+
+func TestNewUnitJSONItems(t *testing.T) {
+	items := newUnitJSONItems([]system.UnitState{{Name: "nginx.service", LoadState: "loaded", ActiveState: "active", SubState: "running", Active: true}})
+	if len(items) != 1 || items[0].Name != "nginx.service" || items[0].SubState != "running" || !items[0].Active {
+		t.Fatalf("unexpected unit items: %+v", items)
+	}
+	if empty := newUnitJSONItems(nil); empty == nil || len(empty) != 0 {
+		t.Fatalf("expected an empty list rather than null, got %+v", empty)
+	}
+}
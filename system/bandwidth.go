@@ -0,0 +1,221 @@
+package system
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"motd/config"
+)
+
+// Bandwidth modes accepted by network.bandwidth.
+const (
+	BandwidthAuto   = "auto"
+	BandwidthVnstat = "vnstat"
+	BandwidthNative = "native"
+)
+
+const bandwidthStateVersion = 1
+
+// bandwidthState is the accounting kept by the native bandwidth mode. It
+// stores the last kernel counters seen for each interface next to the
+// month-to-date totals built from them.
+type bandwidthState struct {
+	Version    int                            `json:"version"`
+	Interfaces map[string]interfaceAccounting `json:"interfaces"`
+}
+
+type interfaceAccounting struct {
+	Month     string    `json:"month"`
+	BootID    string    `json:"boot_id,omitempty"`
+	RxCounter uint64    `json:"rx_counter"`
+	TxCounter uint64    `json:"tx_counter"`
+	RxBytes   uint64    `json:"rx_bytes"`
+	TxBytes   uint64    `json:"tx_bytes"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// counterSample is one reading of an interface's byte counters.
+type counterSample struct {
+	BootID   string
+	BootTime time.Time
+	Rx       uint64
+	Tx       uint64
+}
+
+// ValidateNetworkConfig reports an unknown bandwidth mode, a relative
+// state_file, or an interfaces list that cannot be resolved.
+func ValidateNetworkConfig(cfg config.NetworkConfig) error {
+	if len(cfg.Interfaces) > 0 && strings.TrimSpace(cfg.Interface) != "" {
+		return fmt.Errorf("network.interface and network.interfaces cannot both be set")
+	}
+	for i, name := range cfg.Interfaces {
+		name = strings.TrimSpace(name)
+		if name == InterfacesAll && len(cfg.Interfaces) > 1 {
+			return fmt.Errorf("network.interfaces must not list other interfaces next to %q", InterfacesAll)
+		}
+		if !validInterfaceName(name) {
+			return fmt.Errorf("network.interfaces[%d] is not a valid interface name", i)
+		}
+	}
+	switch strings.TrimSpace(cfg.Bandwidth) {
+	case "", BandwidthAuto, BandwidthVnstat, BandwidthNative:
+	default:
+		return fmt.Errorf("network.bandwidth must be one of auto, vnstat or native")
+	}
+	if path := strings.TrimSpace(cfg.StateFile); path != "" && !filepath.IsAbs(path) {
+		return fmt.Errorf("network.state_file must be absolute")
+	}
+	return nil
+}
+
+// validInterfaceName rejects names that could escape /sys/class/net.
+func validInterfaceName(name string) bool {
+	return name != "" && name != "." && name != ".." && !strings.ContainsAny(name, "/\x00")
+}
+
+func bandwidthMode(cfg ConfigAccessor) string {
+	if mode := strings.TrimSpace(cfg.BandwidthMode); mode != "" {
+		return mode
+	}
+	return BandwidthAuto
+}
+
+// bandwidthStatePath returns network.state_file, or bandwidth.json in the
+// user cache directory next to the media and update-check caches.
+func bandwidthStatePath(cfg ConfigAccessor) (string, error) {
+	if path := strings.TrimSpace(cfg.BandwidthStateFile); path != "" {
+		return path, nil
+	}
+	cache, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cache, "motd", "bandwidth.json"), nil
+}
+
+// recordBandwidth folds sample into the state file at path and returns the
+// month-to-date usage for iface.
+func recordBandwidth(path, iface string, sample counterSample, now time.Time) (BandwidthInfo, error) {
+	usage, err := recordBandwidthSamples(path, map[string]counterSample{iface: sample}, now)
+	if err != nil {
+		return BandwidthInfo{}, err
+	}
+	return usage[iface], nil
+}
+
+// recordBandwidthSamples folds one sample per interface into the state file
+// at path with a single write and returns the month-to-date usage of each.
+func recordBandwidthSamples(path string, samples map[string]counterSample, now time.Time) (map[string]BandwidthInfo, error) {
+	state := bandwidthState{Version: bandwidthStateVersion, Interfaces: map[string]interfaceAccounting{}}
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		var loaded bandwidthState
+		if json.Unmarshal(data, &loaded) == nil && loaded.Version == bandwidthStateVersion && loaded.Interfaces != nil {
+			state = loaded
+		}
+	case !errors.Is(err, os.ErrNotExist):
+		return nil, err
+	}
+
+	usage := make(map[string]BandwidthInfo, len(samples))
+	for iface, sample := range samples {
+		previous, ok := state.Interfaces[iface]
+		acct := accountCounters(previous, ok, sample, now)
+		state.Interfaces[iface] = acct
+
+		info := monthlyBandwidth(iface, acct.RxBytes, acct.TxBytes, now)
+		info.Source = BandwidthNative
+		usage[iface] = info
+	}
+	pruneBandwidthState(state, now)
+
+	encoded, err := json.Marshal(state)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("save bandwidth state: %w", err)
+	}
+	if err := config.AtomicWriteFile(path, encoded, 0o644); err != nil {
+		return nil, fmt.Errorf("save bandwidth state: %w", err)
+	}
+	return usage, nil
+}
+
+// pruneBandwidthState forgets interfaces that have not been sampled for a
+// month, such as the veth and tap devices that "all" picks up and that go
+// away with their container or VM.
+func pruneBandwidthState(state bandwidthState, now time.Time) {
+	cutoff := now.AddDate(0, -1, 0)
+	for iface, acct := range state.Interfaces {
+		if acct.UpdatedAt.Before(cutoff) {
+			delete(state.Interfaces, iface)
+		}
+	}
+}
+
+// accountCounters adds the traffic between the previous sample and this one
+// to the month-to-date totals, starting again at zero in a new month. A new
+// boot ID means the counters restarted, so everything since boot is new
+// traffic. On the very first sample the counters are only counted when the
+// host booted this month, because earlier traffic belongs to older months.
+// The first sample of a new month only counts the share of the traffic that
+// falls after the month began, assuming it was spread evenly.
+func accountCounters(previous interfaceAccounting, ok bool, sample counterSample, now time.Time) interfaceAccounting {
+	month := now.Format("2006-01")
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	next := interfaceAccounting{Month: month, BootID: sample.BootID, RxCounter: sample.Rx, TxCounter: sample.Tx, UpdatedAt: now}
+	if !ok {
+		if !sample.BootTime.IsZero() && !sample.BootTime.Before(monthStart) {
+			next.RxBytes, next.TxBytes = sample.Rx, sample.Tx
+		}
+		return next
+	}
+
+	rx, tx, since := sample.Rx, sample.Tx, sample.BootTime
+	if previous.BootID == sample.BootID {
+		rx, tx, since = counterDelta(previous.RxCounter, sample.Rx), counterDelta(previous.TxCounter, sample.Tx), previous.UpdatedAt
+	}
+	if previous.Month == month {
+		next.RxBytes, next.TxBytes = previous.RxBytes+rx, previous.TxBytes+tx
+		return next
+	}
+	next.RxBytes, next.TxBytes = monthShare(rx, since, monthStart, now), monthShare(tx, since, monthStart, now)
+	return next
+}
+
+// monthShare returns the part of delta, counted evenly from since to now,
+// that falls after monthStart. Without a start time the delta is dropped.
+func monthShare(delta uint64, since, monthStart, now time.Time) uint64 {
+	switch {
+	case since.IsZero() || !since.Before(now):
+		return 0
+	case !since.Before(monthStart):
+		return delta
+	}
+	return uint64(float64(delta) * now.Sub(monthStart).Seconds() / now.Sub(since).Seconds())
+}
+
+// counterWrapMargin is how close to 2^32 a counter must have been for a
+// backwards move to be read as a 32-bit wrap rather than a reset.
+const counterWrapMargin = 1 << 30
+
+// counterDelta returns how far a kernel byte counter moved. A counter that
+// went backwards from just below 2^32 wrapped as a 32-bit value; any other
+// was reset, for example by a driver reload, and counts from zero.
+func counterDelta(previous, current uint64) uint64 {
+	if current >= previous {
+		return current - previous
+	}
+	if previous <= math.MaxUint32 && previous > math.MaxUint32-counterWrapMargin {
+		return math.MaxUint32 - previous + current + 1
+	}
+	return current
+}
@@ -0,0 +1,188 @@
+package system
+
+import (
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"motd/config"
+)
+
+func TestCounterDelta(t *testing.T) {
+	tests := []struct {
+		name              string
+		previous, current uint64
+		want              uint64
+	}{
+		{"forward", 100, 250, 150},
+		{"unchanged", 100, 100, 0},
+		{"32-bit wrap", math.MaxUint32 - 9, 20, 30},
+		{"32-bit reset", 1 << 31, 500, 500},
+		{"64-bit reset", 1 << 40, 500, 500},
+	}
+	for _, tt := range tests {
+		if got := counterDelta(tt.previous, tt.current); got != tt.want {
+			t.Fatalf("%s: counterDelta(%d, %d) = %d, want %d", tt.name, tt.previous, tt.current, got, tt.want)
+		}
+	}
+}
+
+func TestAccountCounters(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	previous := interfaceAccounting{Month: "2026-10", BootID: "a", RxCounter: 1000, TxCounter: 500, RxBytes: 10000, TxBytes: 5000}
+
+	tests := []struct {
+		name     string
+		previous interfaceAccounting
+		ok       bool
+		sample   counterSample
+		rx, tx   uint64
+	}{
+		{"first sample after boot this month", interfaceAccounting{}, false, counterSample{BootID: "a", BootTime: now.Add(-24 * time.Hour), Rx: 700, Tx: 300}, 700, 300},
+		{"first sample after boot last month", interfaceAccounting{}, false, counterSample{BootID: "a", BootTime: now.AddDate(0, -1, 0), Rx: 700, Tx: 300}, 0, 0},
+		{"same boot", previous, true, counterSample{BootID: "a", Rx: 1600, Tx: 900}, 10600, 5400},
+		{"reboot", previous, true, counterSample{BootID: "b", Rx: 200, Tx: 100}, 10200, 5100},
+		{"new month prorates the delta", interfaceAccounting{Month: "2026-09", BootID: "a", RxCounter: 1000, TxCounter: 500, RxBytes: 99999, TxBytes: 99999, UpdatedAt: time.Date(2026, 9, 15, 12, 0, 0, 0, time.UTC)}, true, counterSample{BootID: "a", Rx: 1600, Tx: 900}, 300, 200},
+		{"new month without a previous time", interfaceAccounting{Month: "2026-09", BootID: "a", RxCounter: 1000, TxCounter: 500, RxBytes: 99999, TxBytes: 99999}, true, counterSample{BootID: "a", Rx: 1600, Tx: 900}, 0, 0},
+		{"reboot this month after a quiet month", interfaceAccounting{Month: "2026-09", BootID: "a", RxBytes: 99999, TxBytes: 99999, UpdatedAt: time.Date(2026, 9, 20, 0, 0, 0, 0, time.UTC)}, true, counterSample{BootID: "b", BootTime: now.Add(-time.Hour), Rx: 200, Tx: 100}, 200, 100},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := accountCounters(tt.previous, tt.ok, tt.sample, now)
+			if got.RxBytes != tt.rx || got.TxBytes != tt.tx {
+				t.Fatalf("got rx=%d tx=%d, want rx=%d tx=%d", got.RxBytes, got.TxBytes, tt.rx, tt.tx)
+			}
+			if got.Month != "2026-10" || got.RxCounter != tt.sample.Rx || got.BootID != tt.sample.BootID {
+				t.Fatalf("expected the sample to become the new baseline, got %+v", got)
+			}
+		})
+	}
+}
+
+func TestRecordBandwidthPersistsAccounting(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "bandwidth.json")
+	now := time.Date(2026, 10, 10, 12, 0, 0, 0, time.UTC)
+
+	first, err := recordBandwidth(path, "eth0", counterSample{BootID: "a", BootTime: now.Add(-time.Hour), Rx: GB, Tx: GB / 2}, now)
+	if err != nil {
+		t.Fatalf("first sample failed: %v", err)
+	}
+	if first.RxBytes != GB || first.Source != BandwidthNative || first.Interface != "eth0" {
+		t.Fatalf("unexpected first reading: %+v", first)
+	}
+
+	second, err := recordBandwidth(path, "eth0", counterSample{BootID: "a", Rx: 3 * GB, Tx: GB}, now.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("second sample failed: %v", err)
+	}
+	want := monthlyBandwidth("eth0", 3*GB, GB, now.Add(time.Hour))
+	if second.RxBytes != want.RxBytes || second.TxBytes != want.TxBytes || second.RxEstimateBytes != want.RxEstimateBytes {
+		t.Fatalf("got %+v, want %+v", second, want)
+	}
+}
+
+func TestRecordBandwidthSamplesKeepsInterfacesApart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bandwidth.json")
+	now := time.Date(2026, 10, 10, 12, 0, 0, 0, time.UTC)
+	if _, err := recordBandwidthSamples(path, map[string]counterSample{
+		"eth0": {BootID: "a", BootTime: now.Add(-time.Hour), Rx: GB, Tx: GB},
+		"eth1": {BootID: "a", BootTime: now.Add(-time.Hour), Rx: 2 * GB, Tx: 2 * GB},
+	}, now); err != nil {
+		t.Fatalf("first samples failed: %v", err)
+	}
+
+	usage, err := recordBandwidthSamples(path, map[string]counterSample{
+		"eth1": {BootID: "a", Rx: 3 * GB, Tx: 3 * GB},
+	}, now.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("second samples failed: %v", err)
+	}
+	if len(usage) != 1 || usage["eth1"].RxBytes != 3*GB {
+		t.Fatalf("unexpected eth1 usage: %+v", usage)
+	}
+
+	eth0, err := recordBandwidth(path, "eth0", counterSample{BootID: "a", Rx: GB, Tx: GB}, now.Add(2*time.Hour))
+	if err != nil {
+		t.Fatalf("eth0 sample failed: %v", err)
+	}
+	if eth0.RxBytes != GB {
+		t.Fatalf("expected eth0 accounting to survive an eth1-only write, got %+v", eth0)
+	}
+}
+
+func TestRecordBandwidthSamplesPrunesStaleInterfaces(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bandwidth.json")
+	now := time.Date(2026, 10, 10, 12, 0, 0, 0, time.UTC)
+	if _, err := recordBandwidthSamples(path, map[string]counterSample{
+		"eth0":      {BootID: "a", BootTime: now.Add(-time.Hour), Rx: GB, Tx: GB},
+		"veth1a2b3": {BootID: "a", BootTime: now.Add(-time.Hour), Rx: GB, Tx: GB},
+	}, now); err != nil {
+		t.Fatalf("first samples failed: %v", err)
+	}
+	if _, err := recordBandwidth(path, "eth0", counterSample{BootID: "a", Rx: 2 * GB, Tx: 2 * GB}, now.AddDate(0, 0, 20)); err != nil {
+		t.Fatalf("second sample failed: %v", err)
+	}
+	if _, err := recordBandwidth(path, "eth0", counterSample{BootID: "a", Rx: 3 * GB, Tx: 3 * GB}, now.AddDate(0, 1, 1)); err != nil {
+		t.Fatalf("third sample failed: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var state bandwidthState
+	if err := json.Unmarshal(data, &state); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := state.Interfaces["veth1a2b3"]; ok || len(state.Interfaces) != 1 {
+		t.Fatalf("expected only eth0 to be kept, got %+v", state.Interfaces)
+	}
+}
+
+func TestRecordBandwidthReplacesCorruptState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bandwidth.json")
+	if err := os.WriteFile(path, []byte("{not json"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2026, 10, 10, 12, 0, 0, 0, time.UTC)
+	if _, err := recordBandwidth(path, "eth0", counterSample{Rx: 1, Tx: 1}, now); err != nil {
+		t.Fatalf("expected a corrupt state file to be replaced, got %v", err)
+	}
+}
+
+func TestValidateNetworkConfig(t *testing.T) {
+	if err := ValidateNetworkConfig(config.NetworkConfig{Bandwidth: "native", StateFile: "/var/lib/motd/bandwidth.json"}); err != nil {
+		t.Fatalf("expected valid config, got %v", err)
+	}
+	if err := ValidateNetworkConfig(config.NetworkConfig{Bandwidth: "sflow"}); err == nil {
+		t.Fatal("expected unknown mode to be rejected")
+	}
+	if err := ValidateNetworkConfig(config.NetworkConfig{StateFile: "bandwidth.json"}); err == nil {
+		t.Fatal("expected relative state_file to be rejected")
+	}
+
+	tests := []struct {
+		name       string
+		interfaces []string
+		iface      string
+		valid      bool
+	}{
+		{"named", []string{"eth0", "wg0"}, "", true},
+		{"all", []string{"all"}, "", true},
+		{"all mixed with names", []string{"all", "eth0"}, "", false},
+		{"path", []string{"../eth0"}, "", false},
+		{"empty name", []string{""}, "", false},
+		{"both keys", []string{"eth0"}, "eth1", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateNetworkConfig(config.NetworkConfig{Interface: tt.iface, Interfaces: tt.interfaces})
+			if (err == nil) != tt.valid {
+				t.Fatalf("ValidateNetworkConfig(%v) = %v, want valid=%v", tt.interfaces, err, tt.valid)
+			}
+		})
+	}
+}
@@ -0,0 +1,185 @@
+package system
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	"motd/display"
+	"motd/util"
+)
+
+const btrfsSysfsPath = "/sys/fs/btrfs"
+
+// BtrfsFilesystemList is every mounted Btrfs filesystem.
+type BtrfsFilesystemList []BtrfsFilesystem
+
+// BtrfsFilesystem carries the per-device error counters the kernel keeps for
+// one filesystem. The counters persist across reboots until they are reset
+// with `btrfs device stats -z`.
+type BtrfsFilesystem struct {
+	UUID     string           `json:"uuid"`
+	Label    string           `json:"label,omitempty"`
+	Devices  []BtrfsDevice    `json:"devices"`
+	Severity display.Severity `json:"severity,omitempty"`
+}
+
+type BtrfsDevice struct {
+	ID               string `json:"id"`
+	Missing          bool   `json:"missing,omitempty"`
+	WriteErrors      uint64 `json:"write_errors"`
+	ReadErrors       uint64 `json:"read_errors"`
+	FlushErrors      uint64 `json:"flush_errors"`
+	CorruptionErrors uint64 `json:"corruption_errors"`
+	GenerationErrors uint64 `json:"generation_errors"`
+}
+
+// Errors returns the sum of every error counter.
+func (d BtrfsDevice) Errors() uint64 {
+	return d.WriteErrors + d.ReadErrors + d.FlushErrors + d.CorruptionErrors + d.GenerationErrors
+}
+
+// btrfsEnabled skips the collector on hosts without the btrfs module loaded.
+func btrfsEnabled(cfg ConfigAccessor) bool {
+	_, err := os.Stat(btrfsSysfsPath)
+	return err == nil
+}
+
+func readBtrfsFilesystems(ctx context.Context, cfg ConfigAccessor) (BtrfsFilesystemList, error) {
+	filesystems, err := scanBtrfs(os.DirFS(btrfsSysfsPath))
+	if err != nil {
+		return nil, err
+	}
+	if len(filesystems) == 0 {
+		return nil, errors.New("no Btrfs filesystems mounted")
+	}
+	return filesystems, nil
+}
+
+// scanBtrfs reads every filesystem directory under /sys/fs/btrfs, given as
+// fsys. Each one is named by its UUID and lists its devices under devinfo;
+// error_stats needs Linux 5.14 or newer, and devices without it report no
+// counters.
+func scanBtrfs(fsys fs.FS) (BtrfsFilesystemList, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+	var filesystems BtrfsFilesystemList
+	for _, entry := range entries {
+		uuid := entry.Name()
+		if !entry.IsDir() || strings.Count(uuid, "-") != 4 {
+			continue
+		}
+		filesystem := BtrfsFilesystem{UUID: uuid}
+		if label, err := fs.ReadFile(fsys, path.Join(uuid, "label")); err == nil {
+			filesystem.Label = strings.TrimSpace(string(label))
+		}
+		devices, err := fs.ReadDir(fsys, path.Join(uuid, "devinfo"))
+		if err != nil {
+			return nil, fmt.Errorf("btrfs %s: %w", uuid, err)
+		}
+		for _, device := range devices {
+			dir := path.Join(uuid, "devinfo", device.Name())
+			info := BtrfsDevice{ID: device.Name()}
+			if missing, err := fs.ReadFile(fsys, path.Join(dir, "missing")); err == nil {
+				info.Missing = strings.TrimSpace(string(missing)) == "1"
+			}
+			if stats, err := fs.ReadFile(fsys, path.Join(dir, "error_stats")); err == nil {
+				parseBtrfsErrorStats(stats, &info)
+			}
+			filesystem.Devices = append(filesystem.Devices, info)
+		}
+		filesystems = append(filesystems, filesystem)
+	}
+	return filesystems, nil
+}
+
+// parseBtrfsErrorStats reads "write_errs 0" lines from error_stats.
+func parseBtrfsErrorStats(data []byte, device *BtrfsDevice) {
+	counters := map[string]*uint64{
+		"write_errs":      &device.WriteErrors,
+		"read_errs":       &device.ReadErrors,
+		"flush_errs":      &device.FlushErrors,
+		"corruption_errs": &device.CorruptionErrors,
+		"generation_errs": &device.GenerationErrors,
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		if counter, ok := counters[fields[0]]; ok {
+			if value, err := strconv.ParseUint(fields[1], 10, 64); err == nil {
+				*counter = value
+			}
+		}
+	}
+}
+
+// btrfsSeverity is critical when a device is missing and a warning when any
+// device has recorded errors.
+func btrfsSeverity(filesystem BtrfsFilesystem) display.Severity {
+	severity := display.SeverityOK
+	for _, device := range filesystem.Devices {
+		switch {
+		case device.Missing:
+			return display.SeverityCritical
+		case device.Errors() > 0:
+			severity = display.SeverityWarning
+		}
+	}
+	return severity
+}
+
+// Name returns the label, or the start of the UUID for unlabeled
+// filesystems.
+func (b BtrfsFilesystem) Name() string {
+	if b.Label != "" {
+		return b.Label
+	}
+	if len(b.UUID) > 8 {
+		return b.UUID[:8]
+	}
+	return b.UUID
+}
+
+// btrfsValue renders a filesystem as "2 devices, no errors" or lists the
+// devices that need attention, such as "devid 2 missing".
+func btrfsValue(filesystem BtrfsFilesystem) string {
+	var problems []string
+	for _, device := range filesystem.Devices {
+		if device.Missing {
+			problems = append(problems, "devid "+device.ID+" missing")
+			continue
+		}
+		var counts []string
+		for _, counter := range []struct {
+			name  string
+			value uint64
+		}{
+			{"write", device.WriteErrors},
+			{"read", device.ReadErrors},
+			{"flush", device.FlushErrors},
+			{"corruption", device.CorruptionErrors},
+			{"generation", device.GenerationErrors},
+		} {
+			if counter.value > 0 {
+				counts = append(counts, fmt.Sprintf("%d %s", counter.value, counter.name))
+			}
+		}
+		if len(counts) > 0 {
+			problems = append(problems, "devid "+device.ID+" errors: "+strings.Join(counts, ", "))
+		}
+	}
+	if len(problems) > 0 {
+		return strings.Join(problems, "; ")
+	}
+	count := len(filesystem.Devices)
+	return fmt.Sprintf("%d device%s, no errors", count, util.PluralSuffix(count))
+}
@@ -0,0 +1,60 @@
+package system
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"motd/config"
+	"motd/display"
+)
+
+func TestScanBtrfs(t *testing.T) {
+	const healthy = "3c7e1f52-6a0d-4f1e-9a55-2b1f8e0c4d11"
+	const failing = "9b2d4e61-0c3a-4b7f-8e12-6d5a1c9f0e22"
+	fsys := fstest.MapFS{
+		"features/raid1c34":                    {Data: []byte("0\n")},
+		healthy + "/label":                     {Data: []byte("data\n")},
+		healthy + "/devinfo/1/missing":         {Data: []byte("0\n")},
+		healthy + "/devinfo/1/error_stats":     {Data: []byte("write_errs 0\nread_errs 0\nflush_errs 0\ncorruption_errs 0\ngeneration_errs 0\n")},
+		healthy + "/devinfo/2/missing":         {Data: []byte("0\n")},
+		healthy + "/devinfo/2/error_stats":     {Data: []byte("write_errs 0\nread_errs 0\nflush_errs 0\ncorruption_errs 0\ngeneration_errs 0\n")},
+		failing + "/label":                     {Data: []byte("\n")},
+		failing + "/devinfo/1/missing":         {Data: []byte("0\n")},
+		failing + "/devinfo/1/error_stats":     {Data: []byte("write_errs 0\nread_errs 12\nflush_errs 0\ncorruption_errs 3\ngeneration_errs 0\n")},
+		failing + "/devinfo/2/missing":         {Data: []byte("1\n")},
+		failing + "/devinfo/2/scrub_speed_max": {Data: []byte("0\n")},
+	}
+
+	filesystems, err := scanBtrfs(fsys)
+	if err != nil {
+		t.Fatalf("scanBtrfs failed: %v", err)
+	}
+	if len(filesystems) != 2 {
+		t.Fatalf("expected 2 filesystems, got %+v", filesystems)
+	}
+	snap := SystemSnapshot{Btrfs: filesystems}
+	snap.Evaluate(config.ThresholdsConfig{})
+
+	want := []struct {
+		line     string
+		severity display.Severity
+	}{
+		{"Btrfs (data)=2 devices, no errors", display.SeverityOK},
+		{"Btrfs (9b2d4e61)=devid 1 errors: 12 read, 3 corruption; devid 2 missing", display.SeverityCritical},
+	}
+	for i, line := range snap.Btrfs.Lines() {
+		if got := line.Label + "=" + line.Value; got != want[i].line {
+			t.Fatalf("line %d = %q, want %q", i, got, want[i].line)
+		}
+		if snap.Btrfs[i].Severity != want[i].severity {
+			t.Fatalf("line %d severity = %q, want %q", i, snap.Btrfs[i].Severity, want[i].severity)
+		}
+	}
+}
+
+func TestBtrfsErrorsWithoutMissingDeviceWarn(t *testing.T) {
+	filesystem := BtrfsFilesystem{UUID: "x", Devices: []BtrfsDevice{{ID: "1", GenerationErrors: 1}}}
+	if got := btrfsSeverity(filesystem); got != display.SeverityWarning {
+		t.Fatalf("expected recorded errors to warn, got %q", got)
+	}
+}
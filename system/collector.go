@@ -0,0 +1,387 @@
+package system
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"motd/display"
+	"motd/util"
+)
+
+// Line is one rendered banner row.
+type Line struct {
+	Label string
+	Value string
+	Color string
+}
+
+// Metric is a typed collector result that can describe itself as banner lines.
+type Metric interface {
+	Lines() []Line
+}
+
+// Collector reads a single metric. Collectors never print; callers either
+// render the result with Render or encode it through SystemSnapshot.
+// Collect should return promptly once ctx is done. Enabled reports whether
+// the collector applies to cfg at all, so an unconfigured collector is never
+// reported as timed out.
+type Collector interface {
+	Name() string
+	Enabled(cfg ConfigAccessor) bool
+	Collect(ctx context.Context, cfg ConfigAccessor) (Metric, error)
+}
+
+type metricCollector[T Metric] struct {
+	name    string
+	read    func(context.Context, ConfigAccessor) (T, error)
+	enabled func(ConfigAccessor) bool
+}
+
+func (c metricCollector[T]) Name() string { return c.name }
+
+func (c metricCollector[T]) Enabled(cfg ConfigAccessor) bool {
+	return c.enabled == nil || c.enabled(cfg)
+}
+
+func (c metricCollector[T]) Collect(ctx context.Context, cfg ConfigAccessor) (Metric, error) {
+	value, err := c.read(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// SystemCollectors returns the collectors for the System Information section
+// in display order.
+func SystemCollectors() []Collector {
+	return []Collector{
+		metricCollector[OSInfo]{name: "os", read: readOS},
+		metricCollector[UptimeInfo]{name: "uptime", read: readUptime},
+		metricCollector[UpdateInfo]{name: "updates", read: readUpdates, enabled: updatesEnabled},
+		metricCollector[LoadInfo]{name: "load", read: readLoad},
+		metricCollector[MemoryInfo]{name: "memory", read: readMemory},
+		metricCollector[PressureInfo]{name: "pressure", read: readPressure, enabled: pressureEnabled},
+		metricCollector[BandwidthInfo]{name: "bandwidth", read: readBandwidth, enabled: bandwidthEnabled},
+		metricCollector[InterfaceList]{name: "interfaces", read: readInterfaces, enabled: interfacesEnabled},
+	}
+}
+
+// ResourceCollectors returns the collectors for the Services & Resources
+// section in display order.
+func ResourceCollectors() []Collector {
+	return []Collector{
+		metricCollector[ContainerStatus]{name: "containers", read: readContainerStatus, enabled: containerStatusEnabled},
+		metricCollector[UnitStatus]{name: "units", read: readUnits, enabled: unitsEnabled},
+		metricCollector[ProcessInfo]{name: "processes", read: readProcesses},
+		metricCollector[UserInfo]{name: "users", read: readUsers},
+		metricCollector[FailedLogins]{name: "failed_logins", read: readFailedLogins, enabled: failedLoginsEnabled},
+		metricCollector[DiskList]{name: "disks", read: readDisks},
+		metricCollector[ZFSPoolList]{name: "zfs", read: readZFSPools, enabled: zfsEnabled},
+		metricCollector[RAIDArrayList]{name: "mdraid", read: readRAIDArrays, enabled: mdraidEnabled},
+		metricCollector[BtrfsFilesystemList]{name: "btrfs", read: readBtrfsFilesystems, enabled: btrfsEnabled},
+		metricCollector[SMARTDriveList]{name: "smart", read: readSMARTDrives, enabled: smartEnabled},
+		metricCollector[TemperatureInfo]{name: "temperature", read: readTemperature},
+	}
+}
+
+// Render prints every line of each metric as a DotLabel row.
+func Render(metrics ...Metric) {
+	for _, metric := range metrics {
+		for _, line := range metric.Lines() {
+			display.DotLabel(line.Label)
+			fmt.Printf("%s%s%s\n", line.Color, line.Value, display.Reset)
+		}
+	}
+}
+
+func (o OSInfo) Lines() []Line {
+	if o.Edition == "" && o.Build == "" {
+		return []Line{{Label: "OS Release", Value: o.Name, Color: display.Blue}}
+	}
+	return []Line{
+		{Label: "OS", Value: o.Name, Color: display.Blue},
+		{Label: "Edition", Value: o.Edition, Color: display.Blue},
+		{Label: "Build", Value: o.Build, Color: display.Blue},
+	}
+}
+
+func (u UptimeInfo) Lines() []Line {
+	return []Line{{Label: "Uptime", Value: FormatDuration(u.Duration()), Color: display.Blue}}
+}
+
+// Lines shows the pending package count, then why a reboot is needed.
+func (u UpdateInfo) Lines() []Line {
+	var lines []Line
+	if u.Pending != nil {
+		lines = append(lines, Line{Label: "Updates", Value: u.PendingValue(), Color: u.Severity.Color()})
+	}
+	if u.RebootNeeded() {
+		lines = append(lines, Line{Label: "Reboot required", Value: u.RebootValue(), Color: display.SeverityWarning.Color()})
+	}
+	return lines
+}
+
+// Lines shows the load averages with the 1-minute load per core, then the
+// sampled utilization. Windows has no load averages, so its CPU percentage
+// takes the load row.
+func (l LoadInfo) Lines() []Line {
+	if len(l.Averages) == 0 {
+		value := ""
+		if l.CPUPercent != nil {
+			value = fmt.Sprintf("%.0f%%", *l.CPUPercent)
+		}
+		return []Line{{Label: "CPU Load", Value: value, Color: l.Severity.Color()}}
+	}
+	value := formatLoadAverages(l.Averages)
+	if perCore, ok := l.PerCore(); ok {
+		value += fmt.Sprintf(" (%.2f per core, %d core%s)", perCore, l.Cores, util.PluralSuffix(l.Cores))
+	}
+	lines := []Line{{Label: "CPU Load", Value: value, Color: l.Severity.Color()}}
+	if l.CPUPercent != nil {
+		usage := fmt.Sprintf("%.0f%%", *l.CPUPercent)
+		var detail []string
+		if l.IOWaitPercent != nil {
+			detail = append(detail, fmt.Sprintf("%.0f%% iowait", *l.IOWaitPercent))
+		}
+		if l.StealPercent != nil {
+			detail = append(detail, fmt.Sprintf("%.0f%% steal", *l.StealPercent))
+		}
+		if len(detail) > 0 {
+			usage += " (" + strings.Join(detail, ", ") + ")"
+		}
+		lines = append(lines, Line{Label: "CPU Usage", Value: usage, Color: l.UsageSeverity.Color()})
+	}
+	return lines
+}
+
+// Lines shows swap only when it is configured and OOM kills only once there
+// have been some.
+func (m MemoryInfo) Lines() []Line {
+	lines := []Line{{Label: "Memory", Value: fmt.Sprintf("%.2f GB / %.2f GB", bytesToGB(m.UsedBytes), bytesToGB(m.TotalBytes)), Color: m.Severity.Color()}}
+	if m.SwapTotalBytes > 0 {
+		lines = append(lines, Line{Label: "Swap", Value: fmt.Sprintf("%.2f GB / %.2f GB", bytesToGB(m.SwapUsedBytes), bytesToGB(m.SwapTotalBytes)), Color: display.Blue})
+	}
+	for _, device := range m.ZRAM {
+		value := "empty"
+		if device.Ratio > 0 {
+			value = fmt.Sprintf("%.2f GB in %.2f GB (%.1fx)", bytesToGB(device.OriginalBytes), bytesToGB(device.MemUsedBytes), device.Ratio)
+		}
+		lines = append(lines, Line{Label: "ZRAM (" + device.Name + ")", Value: value, Color: display.Blue})
+	}
+	if m.OOMKills != nil && *m.OOMKills > 0 {
+		lines = append(lines, Line{Label: "OOM Kills", Value: fmt.Sprintf("%d since boot", *m.OOMKills), Color: display.Yellow})
+	}
+	return lines
+}
+
+// Lines shows the 10-second some stall of each resource, followed by the
+// full stall for memory and io, as "io 5.3%/1.2%". CPU full is left out on
+// purpose: system-wide it is always zero and only means something per cgroup.
+func (p PressureInfo) Lines() []Line {
+	var parts []string
+	for _, resource := range p.Resources() {
+		part := fmt.Sprintf("%s %.1f%%", resource.Name, resource.Stall.Some.Avg10)
+		if resource.Stall.Full != nil && resource.Name != "cpu" {
+			part += fmt.Sprintf("/%.1f%%", resource.Stall.Full.Avg10)
+		}
+		parts = append(parts, part)
+	}
+	return []Line{{Label: "Pressure (avg10)", Value: strings.Join(parts, ", "), Color: p.Severity.Color()}}
+}
+
+func (b BandwidthInfo) Lines() []Line {
+	return []Line{
+		{Label: "Bandwidth (rx)", Value: fmt.Sprintf("%.2f GB / %.2f GB est", bytesToGB(b.RxBytes), bytesToGB(b.RxEstimateBytes)), Color: display.Blue},
+		{Label: "Bandwidth (tx)", Value: fmt.Sprintf("%.2f GB / %.2f GB est", bytesToGB(b.TxBytes), bytesToGB(b.TxEstimateBytes)), Color: display.Blue},
+	}
+}
+
+func (l InterfaceList) Lines() []Line {
+	var lines []Line
+	for _, iface := range l {
+		value := iface.State
+		if iface.SpeedMbps > 0 {
+			value += ", " + formatLinkSpeed(iface.SpeedMbps)
+		}
+		lines = append(lines, Line{Label: "Network (" + iface.Name + ")", Value: value, Color: iface.Severity.Color()})
+		if len(iface.IPv4) > 0 {
+			lines = append(lines, Line{Label: iface.Name + " IPv4", Value: strings.Join(iface.IPv4, ", "), Color: display.Blue})
+		}
+		if len(iface.IPv6) > 0 {
+			lines = append(lines, Line{Label: iface.Name + " IPv6", Value: strings.Join(iface.IPv6, ", "), Color: display.Blue})
+		}
+		if bw := iface.Bandwidth; bw != nil {
+			lines = append(lines,
+				Line{Label: iface.Name + " (rx)", Value: fmt.Sprintf("%.2f GB / %.2f GB est", bytesToGB(bw.RxBytes), bytesToGB(bw.RxEstimateBytes)), Color: display.Blue},
+				Line{Label: iface.Name + " (tx)", Value: fmt.Sprintf("%.2f GB / %.2f GB est", bytesToGB(bw.TxBytes), bytesToGB(bw.TxEstimateBytes)), Color: display.Blue},
+			)
+		}
+	}
+	return lines
+}
+
+func (p ProcessInfo) Lines() []Line {
+	return []Line{{Label: "Processes", Value: fmt.Sprintf("%d", p.Count), Color: display.Blue}}
+}
+
+// Lines lists each session after the count, then the last login as
+// "Tue Oct 13 09:12 from 192.0.2.7 (3 logins since)".
+func (u UserInfo) Lines() []Line {
+	lines := []Line{{Label: "Logged in users", Value: fmt.Sprintf("%d", u.Count), Color: display.Blue}}
+	for _, session := range u.Sessions {
+		value := session.User
+		if session.Host != "" {
+			value += " from " + session.Host
+		}
+		lines = append(lines, Line{Label: "Session (" + session.TTY + ")", Value: value, Color: display.Blue})
+	}
+	if last := u.LastLogin; last != nil {
+		value := last.Time.Local().Format("Mon Jan 2 15:04")
+		if last.Host != "" {
+			value += " from " + last.Host
+		} else if last.TTY != "" {
+			value += " on " + last.TTY
+		}
+		if last.LoginsSince > 0 {
+			value += fmt.Sprintf(" (%d login%s since)", last.LoginsSince, util.PluralSuffix(last.LoginsSince))
+		}
+		lines = append(lines, Line{Label: "Last login", Value: value, Color: display.Blue})
+	}
+	return lines
+}
+
+func (f FailedLogins) Lines() []Line {
+	color := display.Green
+	if f.Count > 0 {
+		color = display.Yellow
+	}
+	return []Line{{Label: "Failed logins", Value: f.Value(), Color: color}}
+}
+
+func (d DiskList) Lines() []Line {
+	lines := make([]Line, 0, len(d))
+	for _, disk := range d {
+		value := fmt.Sprintf("%.2f GB / %.2f GB (%.0f%% used)", bytesToGB(disk.UsedBytes), bytesToGB(disk.TotalBytes), disk.UsedPercent)
+		if disk.InodesTotal > 0 {
+			value = fmt.Sprintf("%.2f GB / %.2f GB (%.0f%% used, %.0f%% inodes)", bytesToGB(disk.UsedBytes), bytesToGB(disk.TotalBytes), disk.UsedPercent, disk.InodesUsedPercent)
+		}
+		lines = append(lines, Line{Label: disk.Label, Value: value, Color: display.WorstSeverity(disk.Severity, disk.InodeSeverity).Color()})
+	}
+	return lines
+}
+
+func (z ZFSPoolList) Lines() []Line {
+	lines := make([]Line, 0, len(z))
+	for _, pool := range z {
+		value := fmt.Sprintf("%s, %.2f GB / %.2f GB (%.0f%% used", pool.Health, bytesToGB(pool.AllocatedBytes), bytesToGB(pool.SizeBytes), pool.CapacityPercent)
+		if pool.FragmentationPercent != nil {
+			value += fmt.Sprintf(", %.0f%% frag", *pool.FragmentationPercent)
+		}
+		value += ")"
+		switch {
+		case pool.ScrubInProgress:
+			value += ", scrubbing"
+		case pool.LastScrub != nil:
+			value += ", scrubbed " + pool.LastScrub.Format("2006-01-02")
+		default:
+			value += ", never scrubbed"
+		}
+		lines = append(lines, Line{Label: "ZFS (" + pool.Name + ")", Value: value, Color: pool.Severity.Color()})
+	}
+	return lines
+}
+
+func (r RAIDArrayList) Lines() []Line {
+	lines := make([]Line, 0, len(r))
+	for _, array := range r {
+		lines = append(lines, Line{Label: "RAID (" + array.Name + ")", Value: raidValue(array), Color: array.Severity.Color()})
+	}
+	return lines
+}
+
+func (b BtrfsFilesystemList) Lines() []Line {
+	lines := make([]Line, 0, len(b))
+	for _, filesystem := range b {
+		lines = append(lines, Line{Label: "Btrfs (" + filesystem.Name() + ")", Value: btrfsValue(filesystem), Color: filesystem.Severity.Color()})
+	}
+	return lines
+}
+
+func (d SMARTDriveList) Lines() []Line {
+	return []Line{{Label: "Drive Health", Value: d.Summary(), Color: d.Severity().Color()}}
+}
+
+func (t TemperatureInfo) Lines() []Line {
+	if len(t.Sensors) > 0 {
+		lines := make([]Line, 0, len(t.Sensors))
+		for _, sensor := range t.Sensors {
+			lines = append(lines, Line{Label: sensor.Name, Value: fmt.Sprintf("%.0f°C", sensor.Celsius), Color: sensor.Severity.Color()})
+		}
+		return lines
+	}
+	return []Line{{Label: "CPU Temperature", Value: fmt.Sprintf("%.0f°C", t.Celsius), Color: t.Severity.Color()}}
+}
+
+// TimedOutMetric stands in for a collector that did not finish within the
+// render budget.
+type TimedOutMetric struct {
+	Collector string
+}
+
+// collectorLabels names each collector the way its first banner row does.
+var collectorLabels = map[string]string{
+	"os":            "OS Release",
+	"uptime":        "Uptime",
+	"updates":       "Updates",
+	"load":          "CPU Load",
+	"memory":        "Memory",
+	"pressure":      "Pressure (avg10)",
+	"bandwidth":     "Bandwidth",
+	"interfaces":    "Network",
+	"containers":    "Containers",
+	"units":         "Units",
+	"processes":     "Processes",
+	"users":         "Logged in users",
+	"failed_logins": "Failed logins",
+	"disks":         "Disks",
+	"zfs":           "ZFS",
+	"mdraid":        "RAID",
+	"btrfs":         "Btrfs",
+	"smart":         "Drive Health",
+	"temperature":   "CPU Temperature",
+}
+
+func (t TimedOutMetric) Lines() []Line {
+	label, ok := collectorLabels[t.Collector]
+	if !ok {
+		label = t.Collector
+	}
+	return []Line{{Label: label, Value: "timed out", Color: display.Yellow}}
+}
+
+func (c ContainerStatus) Lines() []Line {
+	if c.Total == 0 {
+		return nil
+	}
+	return []Line{{Label: "Containers", Value: c.Status, Color: c.Severity().Color()}}
+}
+
+// Severity is ok when every workload is online and a warning otherwise.
+func (c ContainerStatus) Severity() display.Severity {
+	if c.Online == c.Total {
+		return display.SeverityOK
+	}
+	return display.SeverityWarning
+}
+
+func (u UnitStatus) Lines() []Line {
+	var lines []Line
+	if u.Total > 0 {
+		lines = append(lines, Line{Label: "Units", Value: u.Status, Color: u.WatchSeverity().Color()})
+	}
+	if len(u.Failed) > 0 {
+		lines = append(lines, Line{Label: "Failed units", Value: u.FailedNames(), Color: display.SeverityWarning.Color()})
+	}
+	return lines
+}
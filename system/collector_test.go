@@ -0,0 +1,230 @@
+package system
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"motd/config"
+	"motd/display"
+	"motd/util"
+)
+
+func TestMetricLines(t *testing.T) {
+	pct, iowait, steal := 37.0, 4.2, 0.4
+	tests := []struct {
+		name   string
+		metric Metric
+		want   []string
+	}{
+		{"os", OSInfo{Name: "Debian GNU/Linux 12"}, []string{"OS Release=Debian GNU/Linux 12"}},
+		{"windows os", OSInfo{Name: "Windows 11", Edition: "Pro", Build: "26100"}, []string{"OS=Windows 11", "Edition=Pro", "Build=26100"}},
+		{"uptime", UptimeInfo{Seconds: 3 * 3600}, []string{"Uptime=3 hours"}},
+		{"load", LoadInfo{Averages: []float64{0.5, 1, 1.25}}, []string{"CPU Load=0.50, 1.00, 1.25"}},
+		{"cpu percent", LoadInfo{CPUPercent: &pct}, []string{"CPU Load=37%"}},
+		{"sampled load", LoadInfo{Averages: []float64{2, 1.5, 1}, Cores: 4, CPUPercent: &pct, IOWaitPercent: &iowait, StealPercent: &steal}, []string{"CPU Load=2.00, 1.50, 1.00 (0.50 per core, 4 cores)", "CPU Usage=37% (4% iowait, 0% steal)"}},
+		{"memory", newMemoryInfo(4*GB, GB), []string{"Memory=1.00 GB / 4.00 GB"}},
+		{"bandwidth", BandwidthInfo{RxBytes: GB, TxBytes: 2 * GB, RxEstimateBytes: 3 * GB, TxEstimateBytes: 6 * GB}, []string{"Bandwidth (rx)=1.00 GB / 3.00 GB est", "Bandwidth (tx)=2.00 GB / 6.00 GB est"}},
+		{"interfaces", InterfaceList{
+			{Name: "eth0", State: LinkUp, SpeedMbps: 2500, IPv4: []string{"192.0.2.10/24"}, IPv6: []string{"2001:db8::10/64"}, Bandwidth: &BandwidthInfo{RxBytes: GB, TxBytes: GB, RxEstimateBytes: 3 * GB, TxEstimateBytes: 3 * GB}},
+			{Name: "eth1", State: LinkNotFound},
+		}, []string{"Network (eth0)=up, 2.5 Gb/s", "eth0 IPv4=192.0.2.10/24", "eth0 IPv6=2001:db8::10/64", "eth0 (rx)=1.00 GB / 3.00 GB est", "eth0 (tx)=1.00 GB / 3.00 GB est", "Network (eth1)=not found"}},
+		{"disks", DiskList{newDiskUsage("Disk (/)", "/", 4*GB, 3*GB)}, []string{"Disk (/)=3.00 GB / 4.00 GB (75% used)"}},
+		{"temperature", TemperatureInfo{Celsius: 48.4}, []string{"CPU Temperature=48°C"}},
+		{"containers", ContainerStatus{Online: 2, Total: 5, Status: "2 of 5 online"}, []string{"Containers=2 of 5 online"}},
+		{"no containers", ContainerStatus{}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines := tt.metric.Lines()
+			if len(lines) != len(tt.want) {
+				t.Fatalf("expected %d lines, got %+v", len(tt.want), lines)
+			}
+			for i, line := range lines {
+				if got := line.Label + "=" + line.Value; got != tt.want[i] {
+					t.Fatalf("line %d = %q, want %q", i, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestContainerStatusLinesColor(t *testing.T) {
+	if got := (ContainerStatus{Online: 3, Total: 3, Status: "All workloads online"}).Lines()[0].Color; got != display.Green {
+		t.Fatalf("expected all-online containers to be green, got %q", got)
+	}
+	if got := (ContainerStatus{Online: 1, Total: 3, Status: "1 of 3 online"}).Lines()[0].Color; got != display.Yellow {
+		t.Fatalf("expected partially online containers to be yellow, got %q", got)
+	}
+}
+
+func TestSnapshotSetAndOrder(t *testing.T) {
+	var snap SystemSnapshot
+	snap.Set(TemperatureInfo{Celsius: 40})
+	snap.Set(DiskList{newDiskUsage("Disk (/)", "/", GB, 0)})
+	snap.Set(UptimeInfo{Seconds: 60})
+	snap.Set(OSInfo{Name: "Linux"})
+	snap.Set(ContainerStatus{Online: 1, Total: 1})
+
+	system := snap.SystemMetrics()
+	if len(system) != 2 {
+		t.Fatalf("expected 2 system metrics, got %+v", system)
+	}
+	if _, ok := system[0].(OSInfo); !ok {
+		t.Fatalf("expected OS first, got %T", system[0])
+	}
+
+	resources := snap.ResourceMetrics()
+	if len(resources) != 3 {
+		t.Fatalf("expected 3 resource metrics, got %+v", resources)
+	}
+	if _, ok := resources[0].(ContainerStatus); !ok {
+		t.Fatalf("expected containers first, got %T", resources[0])
+	}
+	if _, ok := resources[2].(TemperatureInfo); !ok {
+		t.Fatalf("expected temperature last, got %T", resources[2])
+	}
+}
+
+func TestSnapshotEvaluate(t *testing.T) {
+	warn, critical, steal := 50.0, 60.0, 12.0
+	th := config.ThresholdsConfig{Disk: &config.DiskThresholds{Mounts: map[string]config.Threshold{"/tank": {Warn: &warn, Critical: &critical}}}}
+	snap := SystemSnapshot{
+		Load:        &LoadInfo{Averages: []float64{6, 1, 1}, Cores: 4, StealPercent: &steal},
+		Memory:      &MemoryInfo{UsedPercent: 90},
+		Disks:       DiskList{{Path: "/", UsedPercent: 55}, {Path: "/tank", UsedPercent: 55}},
+		Temperature: &TemperatureInfo{Celsius: 35},
+	}
+	snap.Evaluate(th)
+
+	if snap.Load.Severity != display.SeverityWarning {
+		t.Fatalf("load severity = %q, want warning", snap.Load.Severity)
+	}
+	if snap.Load.UsageSeverity != display.SeverityWarning {
+		t.Fatalf("cpu usage severity = %q, want warning", snap.Load.UsageSeverity)
+	}
+	if snap.Memory.Severity != display.SeverityWarning {
+		t.Fatalf("memory severity = %q, want warning", snap.Memory.Severity)
+	}
+	if snap.Disks[0].Severity != display.SeverityOK || snap.Disks[1].Severity != display.SeverityWarning {
+		t.Fatalf("unexpected disk severities: %+v", snap.Disks)
+	}
+	if snap.Temperature.Severity != display.SeverityOK {
+		t.Fatalf("temperature severity = %q, want ok", snap.Temperature.Severity)
+	}
+	if got := snap.Temperature.Lines()[0].Color; got != display.Green {
+		t.Fatalf("expected cool temperature to render green, got %q", got)
+	}
+}
+
+func TestMetricCollectorReturnsNilMetricOnError(t *testing.T) {
+	collector := metricCollector[UserInfo]{name: "users", read: func(context.Context, ConfigAccessor) (UserInfo, error) {
+		return UserInfo{Count: 3}, errTest
+	}}
+	metric, err := collector.Collect(context.Background(), ConfigAccessor{})
+	if err == nil || metric != nil {
+		t.Fatalf("expected nil metric with error, got %v %v", metric, err)
+	}
+}
+
+func TestCollectMetricsAbandonsBlockedCollector(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	collectors := []Collector{
+		metricCollector[UserInfo]{name: "users", read: func(context.Context, ConfigAccessor) (UserInfo, error) {
+			<-release
+			return UserInfo{Count: 1}, nil
+		}},
+		metricCollector[ProcessInfo]{name: "processes", read: func(context.Context, ConfigAccessor) (ProcessInfo, error) {
+			return ProcessInfo{Count: 7}, nil
+		}},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	snap := collectMetrics(ctx, collectors, ConfigAccessor{}, nil, false)
+	if snap.Users != nil || len(snap.TimedOut) != 1 || snap.TimedOut[0] != "users" {
+		t.Fatalf("expected users to time out, got %+v", snap)
+	}
+	if snap.Processes == nil || snap.Processes.Count != 7 {
+		t.Fatalf("expected processes despite the blocked collector, got %+v", snap.Processes)
+	}
+}
+
+func TestCollectMetricsReportsCancelledCommandAsTimeout(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	collectors := []Collector{metricCollector[UserInfo]{name: "users", read: func(context.Context, ConfigAccessor) (UserInfo, error) {
+		cancel()
+		return UserInfo{}, errTest
+	}}}
+	snap := collectMetrics(ctx, collectors, ConfigAccessor{}, nil, false)
+	if len(snap.TimedOut) != 1 || snap.TimedOut[0] != "users" {
+		t.Fatalf("expected users to be reported as timed out, got %+v", snap.TimedOut)
+	}
+}
+
+func TestCollectMetricsRunsCollectorsConcurrently(t *testing.T) {
+	// Each collector waits for the other to start, so a sequential run
+	// would never finish before the deadline.
+	uptimeStarted, loadStarted := make(chan struct{}), make(chan struct{})
+	collectors := []Collector{
+		metricCollector[UptimeInfo]{name: "uptime", read: func(ctx context.Context, _ ConfigAccessor) (UptimeInfo, error) {
+			close(uptimeStarted)
+			select {
+			case <-loadStarted:
+				return UptimeInfo{Seconds: 60}, nil
+			case <-ctx.Done():
+				return UptimeInfo{}, ctx.Err()
+			}
+		}},
+		metricCollector[LoadInfo]{name: "load", read: func(ctx context.Context, _ ConfigAccessor) (LoadInfo, error) {
+			close(loadStarted)
+			select {
+			case <-uptimeStarted:
+				return LoadInfo{Averages: []float64{1, 1, 1}}, nil
+			case <-ctx.Done():
+				return LoadInfo{}, ctx.Err()
+			}
+		}},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	snap := collectMetrics(ctx, collectors, ConfigAccessor{}, util.NewPool(2), false)
+	if snap.Uptime == nil || snap.Load == nil || len(snap.TimedOut) != 0 {
+		t.Fatalf("expected both collectors to finish, got %+v", snap)
+	}
+}
+
+func TestSnapshotRendersTimedOutCollectorsInOrder(t *testing.T) {
+	snap := SystemSnapshot{OS: &OSInfo{Name: "Linux"}, Memory: &MemoryInfo{TotalBytes: GB}, TimedOut: []string{"load", "users"}}
+
+	system := snap.SystemMetrics()
+	if len(system) != 3 {
+		t.Fatalf("expected 3 system metrics, got %+v", system)
+	}
+	line := system[1].Lines()[0]
+	if line.Label != "CPU Load" || line.Value != "timed out" || line.Color != display.Yellow {
+		t.Fatalf("unexpected timed out line: %+v", line)
+	}
+	if _, ok := system[2].(MemoryInfo); !ok {
+		t.Fatalf("expected memory after timed out load, got %T", system[2])
+	}
+	resources := snap.ResourceMetrics()
+	if len(resources) != 1 || resources[0].Lines()[0].Label != "Logged in users" {
+		t.Fatalf("unexpected resource metrics: %+v", resources)
+	}
+}
+
+func TestContainersCollectorDisabledWithoutConfig(t *testing.T) {
+	for _, collector := range ResourceCollectors() {
+		if collector.Name() == "containers" && collector.Enabled(ConfigAccessor{}) {
+			t.Fatal("expected containers collector to be disabled without container_status")
+		}
+	}
+}
+
+var errTest = errors.New("test failure")
@@ -28,19 +28,19 @@ const (
 )
 
 type ContainerStatus struct {
-	ProtocolVersion int
-	ObservedAt      time.Time
-	Online          int
-	Total           int
-	Status          string
-	Workloads       []WorkloadStatus
+	ProtocolVersion int              `json:"protocol_version"`
+	ObservedAt      time.Time        `json:"observed_at"`
+	Online          int              `json:"online"`
+	Total           int              `json:"total"`
+	Status          string           `json:"status"`
+	Workloads       []WorkloadStatus `json:"workloads"`
 }
 
 type WorkloadStatus struct {
-	Name   string
-	State  string
-	Health string
-	Online bool
+	Name   string `json:"name"`
+	State  string `json:"state"`
+	Health string `json:"health"`
+	Online bool   `json:"online"`
 }
 
 type agentStatusResponse struct {
@@ -55,10 +55,23 @@ type agentWorkload struct {
 	Health string `json:"health"`
 }
 
-func GetContainerStatus(cfg ConfigAccessor, debug bool) (ContainerStatus, bool) {
-	if cfg.ContainerStatus == nil {
+func GetContainerStatus(ctx context.Context, cfg ConfigAccessor, debug bool) (ContainerStatus, bool) {
+	status, err := readContainerStatus(ctx, cfg)
+	if err != nil {
+		display.DebugLog(debug, "Container status unavailable: %v", err)
 		return ContainerStatus{}, false
 	}
+	return status, true
+}
+
+func containerStatusEnabled(cfg ConfigAccessor) bool {
+	return cfg.ContainerStatus != nil
+}
+
+func readContainerStatus(ctx context.Context, cfg ConfigAccessor) (ContainerStatus, error) {
+	if cfg.ContainerStatus == nil {
+		return ContainerStatus{}, fmt.Errorf("container status is not configured")
+	}
 	socketPath := strings.TrimSpace(cfg.ContainerStatus.SocketPath)
 	if socketPath == "" {
 		socketPath = DefaultContainerStatusSocket
@@ -68,35 +81,15 @@ func GetContainerStatus(cfg ConfigAccessor, debug bool) (ContainerStatus, bool)
 	if value := strings.TrimSpace(cfg.ContainerStatus.MaxAge); value != "" {
 		parsed, err := time.ParseDuration(value)
 		if err != nil || parsed <= 0 {
-			display.DebugLog(debug, "Invalid container status max_age %q", value)
-			return ContainerStatus{}, false
+			return ContainerStatus{}, fmt.Errorf("invalid container status max_age %q", value)
 		}
 		maxAge = parsed
 	}
 
-	status, err := fetchContainerStatus(socketPath, maxAge)
-	if err != nil {
-		display.DebugLog(debug, "Container status unavailable: %v", err)
-		return ContainerStatus{}, false
-	}
-	return status, true
-}
-
-func ShowContainers(cfg ConfigAccessor, debug bool) {
-	status, ok := GetContainerStatus(cfg, debug)
-	if !ok || status.Total == 0 {
-		return
-	}
-
-	display.DotLabel("Containers")
-	if status.Online == status.Total {
-		fmt.Printf("%sAll workloads online%s\n", display.Green, display.Reset)
-		return
-	}
-	fmt.Printf("%s%d of %d online%s\n", display.Yellow, status.Online, status.Total, display.Reset)
+	return fetchContainerStatus(ctx, socketPath, maxAge)
 }
 
-func fetchContainerStatus(socketPath string, maxAge time.Duration) (ContainerStatus, error) {
+func fetchContainerStatus(ctx context.Context, socketPath string, maxAge time.Duration) (ContainerStatus, error) {
 	if !filepath.IsAbs(socketPath) {
 		return ContainerStatus{}, fmt.Errorf("container status socket path must be absolute")
 	}
@@ -112,7 +105,7 @@ func fetchContainerStatus(socketPath string, maxAge time.Duration) (ContainerSta
 	}}
 	defer transport.CloseIdleConnections()
 
-	request, err := http.NewRequest(http.MethodGet, "http://motd-status-agent/v1/status", nil)
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://motd-status-agent/v1/status", nil)
 	if err != nil {
 		return ContainerStatus{}, err
 	}
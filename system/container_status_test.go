@@ -1,6 +1,7 @@
 package system
 
 import (
+	"context"
 	"fmt"
 	"net"
 	"net/http"
@@ -19,7 +20,7 @@ func TestFetchContainerStatusAggregatesAndSorts(t *testing.T) {
 		_, _ = fmt.Fprint(w, status)
 	}))
 
-	result, err := fetchContainerStatus(socket, 24*time.Hour)
+	result, err := fetchContainerStatus(context.Background(), socket, 24*time.Hour)
 	if err != nil {
 		t.Fatalf("fetchContainerStatus failed: %v", err)
 	}
@@ -37,7 +38,7 @@ func TestFetchContainerStatusRejectsStaleResponse(t *testing.T) {
 		_, _ = fmt.Fprint(w, `{"protocol_version":1,"observed_at":"2020-01-01T00:00:00Z","workloads":[]}`)
 	}))
 
-	if _, err := fetchContainerStatus(socket, time.Second); err == nil {
+	if _, err := fetchContainerStatus(context.Background(), socket, time.Second); err == nil {
 		t.Fatal("expected stale response to fail")
 	}
 }
@@ -48,7 +49,7 @@ func TestFetchContainerStatusRejectsInvalidWorkload(t *testing.T) {
 		_, _ = fmt.Fprint(w, `{"protocol_version":1,"observed_at":"2026-08-18T12:34:56Z","workloads":[{"name":"alpha","state":"running","health":"broken"}]}`)
 	}))
 
-	if _, err := fetchContainerStatus(socket, 24*time.Hour); err == nil {
+	if _, err := fetchContainerStatus(context.Background(), socket, 24*time.Hour); err == nil {
 		t.Fatal("expected invalid workload to fail")
 	}
 }
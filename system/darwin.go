@@ -3,45 +3,46 @@
 package system
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"runtime"
 	"strconv"
 	"strings"
 	"time"
 
-	"motd/display"
 	"motd/util"
 )
 
-func ShowOS(cfg ConfigAccessor, debug bool) {
-	nameCmd, nameErr := util.SafeCommand("sw_vers", "-productName")
-	versionCmd, versionErr := util.SafeCommand("sw_vers", "-productVersion")
-	if nameErr != nil || versionErr != nil {
-		return
+func readOS(ctx context.Context, cfg ConfigAccessor) (OSInfo, error) {
+	nameCmd, nameErr := util.SafeCommandContext(ctx, "sw_vers", "-productName")
+	versionCmd, versionErr := util.SafeCommandContext(ctx, "sw_vers", "-productVersion")
+	if err := errors.Join(nameErr, versionErr); err != nil {
+		return OSInfo{}, err
 	}
 	nameOutput, nameErr := nameCmd.Output()
 	versionOutput, versionErr := versionCmd.Output()
-	if nameErr != nil || versionErr != nil {
-		return
+	if err := errors.Join(nameErr, versionErr); err != nil {
+		return OSInfo{}, err
 	}
 
-	display.DotLabel("OS Release")
-	fmt.Printf("%s%s %s%s\n", display.Blue, strings.TrimSpace(string(nameOutput)), strings.TrimSpace(string(versionOutput)), display.Reset)
+	return OSInfo{Name: strings.TrimSpace(string(nameOutput)) + " " + strings.TrimSpace(string(versionOutput))}, nil
 }
 
-func ShowUptime(cfg ConfigAccessor, debug bool) {
-	uptime := "unknown"
-	cmd, cmdErr := util.SafeCommand("sysctl", "-n", "kern.boottime")
-	if cmdErr == nil {
-		output, err := cmd.Output()
-		if err == nil {
-			if bootTime, ok := parseDarwinBootTime(output); ok {
-				uptime = FormatDuration(time.Since(bootTime))
-			}
-		}
+func readUptime(ctx context.Context, cfg ConfigAccessor) (UptimeInfo, error) {
+	cmd, err := util.SafeCommandContext(ctx, "sysctl", "-n", "kern.boottime")
+	if err != nil {
+		return UptimeInfo{}, err
 	}
-
-	display.DotLabel("Uptime")
-	fmt.Printf("%s%s%s\n", display.Blue, uptime, display.Reset)
+	output, err := cmd.Output()
+	if err != nil {
+		return UptimeInfo{}, err
+	}
+	bootTime, ok := parseDarwinBootTime(output)
+	if !ok {
+		return UptimeInfo{}, fmt.Errorf("unrecognized kern.boottime output")
+	}
+	return UptimeInfo{Seconds: time.Since(bootTime).Seconds()}, nil
 }
 
 func parseDarwinBootTime(output []byte) (time.Time, bool) {
@@ -65,46 +66,46 @@ func parseDarwinBootTime(output []byte) (time.Time, bool) {
 	return time.Unix(seconds, 0), true
 }
 
-func ShowLoad(cfg ConfigAccessor, debug bool) {
-	cmd, cmdErr := util.SafeCommand("sysctl", "-n", "vm.loadavg")
-	if cmdErr != nil {
-		return
+func readLoad(ctx context.Context, cfg ConfigAccessor) (LoadInfo, error) {
+	cmd, err := util.SafeCommandContext(ctx, "sysctl", "-n", "vm.loadavg")
+	if err != nil {
+		return LoadInfo{}, err
 	}
 	output, err := cmd.Output()
 	if err != nil {
-		return
+		return LoadInfo{}, err
 	}
 
 	load := strings.Trim(strings.TrimSpace(string(output)), "{}")
-	display.DotLabel("CPU Load")
-	fmt.Printf("%s%s%s\n", display.Blue, strings.TrimSpace(load), display.Reset)
+	averages, err := parseLoadAverages(strings.Fields(load))
+	if err != nil {
+		return LoadInfo{}, err
+	}
+	return LoadInfo{Averages: averages, Cores: runtime.NumCPU()}, nil
 }
 
-func ShowMemory(cfg ConfigAccessor, debug bool) {
-	totalCmd, totalErr := util.SafeCommand("sysctl", "-n", "hw.memsize")
-	statsCmd, statsErr := util.SafeCommand("vm_stat")
-	if totalErr != nil || statsErr != nil {
-		return
+func readMemory(ctx context.Context, cfg ConfigAccessor) (MemoryInfo, error) {
+	totalCmd, totalErr := util.SafeCommandContext(ctx, "sysctl", "-n", "hw.memsize")
+	statsCmd, statsErr := util.SafeCommandContext(ctx, "vm_stat")
+	if err := errors.Join(totalErr, statsErr); err != nil {
+		return MemoryInfo{}, err
 	}
 	totalOutput, totalErr := totalCmd.Output()
 	statsOutput, statsErr := statsCmd.Output()
-	if totalErr != nil || statsErr != nil {
-		return
+	if err := errors.Join(totalErr, statsErr); err != nil {
+		return MemoryInfo{}, err
 	}
 
 	totalBytes, err := strconv.ParseUint(strings.TrimSpace(string(totalOutput)), 10, 64)
 	if err != nil || totalBytes == 0 {
-		return
+		return MemoryInfo{}, fmt.Errorf("invalid hw.memsize output")
 	}
 	freeBytes, ok := parseDarwinFreeMemory(statsOutput)
 	if !ok || freeBytes > totalBytes {
-		return
+		return MemoryInfo{}, fmt.Errorf("invalid vm_stat output")
 	}
 
-	usedGB := float64(totalBytes-freeBytes) / float64(GB)
-	totalGB := float64(totalBytes) / float64(GB)
-	display.DotLabel("Memory")
-	fmt.Printf("%s%.2f GB / %.2f GB%s\n", display.Blue, usedGB, totalGB, display.Reset)
+	return newMemoryInfo(totalBytes, totalBytes-freeBytes), nil
 }
 
 func parseDarwinFreeMemory(output []byte) (uint64, bool) {
@@ -145,111 +146,158 @@ func parseDarwinFreeMemory(output []byte) (uint64, bool) {
 	return (freePages + speculativePages) * pageSize, freePages > 0 || speculativePages > 0
 }
 
-func ShowBandwidth(cfg ConfigAccessor, debug bool) {
+func readBandwidth(ctx context.Context, cfg ConfigAccessor) (BandwidthInfo, error) {
+	if bandwidthMode(cfg) == BandwidthNative {
+		return BandwidthInfo{}, fmt.Errorf("native bandwidth accounting is only supported on Linux")
+	}
 	if !util.HasCommand("vnstat") {
-		return
+		return BandwidthInfo{}, fmt.Errorf("vnstat not installed")
 	}
 
 	interfaceName := strings.TrimSpace(cfg.NetworkInterface)
 	if interfaceName == "" {
-		interfaceName = getDefaultInterface()
+		interfaceName = getDefaultInterface(ctx)
 	}
 	if interfaceName == "" {
-		return
+		return BandwidthInfo{}, fmt.Errorf("no default network interface")
 	}
 
-	cmd, cmdErr := util.SafeCommand("vnstat", "--json", "m", "-i", interfaceName)
-	if cmdErr != nil {
-		return
+	cmd, err := util.SafeCommandContext(ctx, "vnstat", "--json", "m", "-i", interfaceName)
+	if err != nil {
+		return BandwidthInfo{}, err
 	}
 	output, err := cmd.Output()
 	if err != nil {
-		display.DebugLog(debug, "vnstat command failed: %v", err)
-		return
+		return BandwidthInfo{}, fmt.Errorf("vnstat command failed: %w", err)
 	}
 
-	rxGB, txGB, rxEst, txEst, err := parseVnstatMonthlyUsage(output, interfaceName, time.Now())
+	info, err := parseVnstatMonthlyUsage(output, interfaceName, time.Now())
 	if err != nil {
-		display.DebugLog(debug, "Failed to parse vnstat data for %s: %v", interfaceName, err)
-		return
+		return BandwidthInfo{}, fmt.Errorf("failed to parse vnstat data for %s: %w", interfaceName, err)
 	}
-
-	display.DotLabel("Bandwidth (rx)")
-	fmt.Printf("%s%.2f GB / %.2f GB est%s\n", display.Blue, rxGB, rxEst, display.Reset)
-	display.DotLabel("Bandwidth (tx)")
-	fmt.Printf("%s%.2f GB / %.2f GB est%s\n", display.Blue, txGB, txEst, display.Reset)
+	return info, nil
 }
 
-func ShowUser(cfg ConfigAccessor, debug bool) {
-	cmd, cmdErr := util.SafeCommand("who")
-	if cmdErr != nil {
-		return
+// readInterfaceBandwidth returns month-to-date traffic for each of names
+// from vnstat, the only accounting available on macOS.
+func readInterfaceBandwidth(ctx context.Context, cfg ConfigAccessor, names []string) (map[string]BandwidthInfo, error) {
+	if bandwidthMode(cfg) == BandwidthNative {
+		return nil, fmt.Errorf("native bandwidth accounting is only supported on Linux")
+	}
+	if !util.HasCommand("vnstat") {
+		return nil, fmt.Errorf("vnstat not installed")
+	}
+	cmd, err := util.SafeCommandContext(ctx, "vnstat", "--json", "m")
+	if err != nil {
+		return nil, err
 	}
 	output, err := cmd.Output()
 	if err != nil {
-		return
+		return nil, fmt.Errorf("vnstat command failed: %w", err)
 	}
+	return parseVnstatInterfaceUsage(output, names, time.Now())
+}
 
-	count := countUniqueWhoUsers(output)
-	display.DotLabel("Logged in users")
-	fmt.Printf("%s%d%s\n", display.Blue, count, display.Reset)
+// interfaceLink has no kernel link details to offer on macOS; the interface
+// flags decide the state.
+func interfaceLink(name string) (string, int, bool) {
+	return "", 0, false
 }
 
-func ShowProcesses(cfg ConfigAccessor, debug bool) {
-	cmd, cmdErr := util.SafeCommand("ps", "-ax", "-o", "pid=")
-	if cmdErr != nil {
-		return
+func readUsers(ctx context.Context, cfg ConfigAccessor) (UserInfo, error) {
+	cmd, err := util.SafeCommandContext(ctx, "who")
+	if err != nil {
+		return UserInfo{}, err
 	}
 	output, err := cmd.Output()
 	if err != nil {
-		return
+		return UserInfo{}, err
 	}
+	return UserInfo{Count: countUniqueWhoUsers(output)}, nil
+}
 
-	count := countNonEmptyLines(output)
-	display.DotLabel("Processes")
-	fmt.Printf("%s%d%s\n", display.Blue, count, display.Reset)
+func readFailedLogins(ctx context.Context, cfg ConfigAccessor) (FailedLogins, error) {
+	return FailedLogins{}, fmt.Errorf("failed logins are not supported on macOS")
 }
 
-func ShowDisk(cfg ConfigAccessor, debug bool) {
-	showDFDisk("/", "Disk (/)")
-	if cfg.TankMount != "" {
-		showDFDisk(cfg.TankMount, fmt.Sprintf("Disk (%s)", cfg.TankMount))
+func readProcesses(ctx context.Context, cfg ConfigAccessor) (ProcessInfo, error) {
+	cmd, err := util.SafeCommandContext(ctx, "ps", "-ax", "-o", "pid=")
+	if err != nil {
+		return ProcessInfo{}, err
 	}
+	output, err := cmd.Output()
+	if err != nil {
+		return ProcessInfo{}, err
+	}
+	return ProcessInfo{Count: countNonEmptyLines(output)}, nil
 }
 
-func showDFDisk(path, label string) {
-	cmd, cmdErr := util.SafeCommand("df", "-k", path)
-	if cmdErr != nil {
-		return
+// readDisks reports every target that can be read; one unreadable mount,
+// such as a stale network share, does not hide the others.
+func readDisks(ctx context.Context, cfg ConfigAccessor) (DiskList, error) {
+	targets, err := diskTargets(cfg, func() ([]string, error) {
+		return nil, fmt.Errorf("disks.auto is only supported on Linux")
+	})
+	var disks DiskList
+	errs := []error{err}
+	for _, target := range targets {
+		disk, err := readDFDisk(ctx, target.Path, target.Label)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", target.Path, err))
+			continue
+		}
+		disks = append(disks, disk)
+	}
+	if len(disks) > 0 {
+		return disks, nil
+	}
+	return nil, errors.Join(errs...)
+}
+
+// readDFDisk parses `df -k -i`, whose iused and ifree columns follow the
+// capacity column on macOS.
+func readDFDisk(ctx context.Context, path, label string) (DiskUsage, error) {
+	cmd, err := util.SafeCommandContext(ctx, "df", "-k", "-i", path)
+	if err != nil {
+		return DiskUsage{}, err
 	}
 	output, err := cmd.Output()
 	if err != nil {
-		return
+		return DiskUsage{}, err
 	}
 
 	lines := strings.Split(string(output), "\n")
 	if len(lines) < 2 {
-		return
+		return DiskUsage{}, fmt.Errorf("unexpected df output")
 	}
 	fields := strings.Fields(lines[1])
 	if len(fields) < 5 {
-		return
+		return DiskUsage{}, fmt.Errorf("unexpected df output")
 	}
 
-	totalKB, totalErr := strconv.ParseFloat(fields[1], 64)
-	usedKB, usedErr := strconv.ParseFloat(fields[2], 64)
-	if totalErr != nil || usedErr != nil {
-		return
+	totalKB, totalErr := strconv.ParseUint(fields[1], 10, 64)
+	usedKB, usedErr := strconv.ParseUint(fields[2], 10, 64)
+	if err := errors.Join(totalErr, usedErr); err != nil {
+		return DiskUsage{}, err
 	}
 
-	display.DotLabel(label)
-	fmt.Printf("%s%.2f GB / %.2f GB (%s used)%s\n", display.Blue, usedKB/float64(MB), totalKB/float64(MB), fields[4], display.Reset)
+	usage := newDiskUsage(label, path, totalKB*KB, usedKB*KB)
+	if len(fields) >= 8 {
+		inodesUsed, usedErr := strconv.ParseUint(fields[5], 10, 64)
+		inodesFree, freeErr := strconv.ParseUint(fields[6], 10, 64)
+		if usedErr == nil && freeErr == nil {
+			usage.setInodes(inodesUsed+inodesFree, inodesFree)
+		}
+	}
+	return usage, nil
 }
 
-func ShowTemp(cfg ConfigAccessor, debug bool) {}
+func readTemperature(ctx context.Context, cfg ConfigAccessor) (TemperatureInfo, error) {
+	return TemperatureInfo{}, fmt.Errorf("temperature sensors are not supported on macOS")
+}
 
-func getDefaultInterface() string {
-	cmd, cmdErr := util.SafeCommand("route", "-n", "get", "default")
+func getDefaultInterface(ctx context.Context) string {
+	cmd, cmdErr := util.SafeCommandContext(ctx, "route", "-n", "get", "default")
 	if cmdErr != nil {
 		return ""
 	}
@@ -0,0 +1,141 @@
+package system
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"motd/config"
+)
+
+// pseudoFilesystems are skipped by disk discovery: they hold no persistent
+// data, or mirror a filesystem that is already reported.
+var pseudoFilesystems = map[string]bool{
+	"autofs": true, "binfmt_misc": true, "bpf": true, "cgroup": true, "cgroup2": true,
+	"configfs": true, "debugfs": true, "devpts": true, "devtmpfs": true, "efivarfs": true,
+	"fuse.gvfsd-fuse": true, "fuse.lxcfs": true, "fuse.portal": true, "fusectl": true,
+	"hugetlbfs": true, "mqueue": true, "nsfs": true, "overlay": true, "proc": true,
+	"pstore": true, "ramfs": true, "rpc_pipefs": true, "securityfs": true, "selinuxfs": true,
+	"squashfs": true, "sysfs": true, "tmpfs": true, "tracefs": true,
+}
+
+// networkFilesystems are also left to explicit disks.mounts entries: a hung
+// server blocks statfs, and the space is usually another host's to report.
+var networkFilesystems = map[string]bool{
+	"9p": true, "afs": true, "ceph": true, "cifs": true, "fuse.glusterfs": true,
+	"fuse.rclone": true, "fuse.s3fs": true, "fuse.sshfs": true, "glusterfs": true,
+	"lustre": true, "ncpfs": true, "nfs": true, "nfs4": true, "smb3": true, "smbfs": true,
+}
+
+// diskTarget is one mount the disk collector reports.
+type diskTarget struct {
+	Path  string
+	Label string
+}
+
+// ValidateDisksConfig reports a mount without an absolute path or a mount
+// listed twice.
+func ValidateDisksConfig(cfg *config.DisksConfig) error {
+	if cfg == nil {
+		return nil
+	}
+	seen := make(map[string]bool, len(cfg.Mounts))
+	for i, mount := range cfg.Mounts {
+		path := strings.TrimSpace(mount.Path)
+		if !filepath.IsAbs(path) {
+			return fmt.Errorf("disks.mounts[%d].path must be absolute", i)
+		}
+		path = filepath.Clean(path)
+		if seen[path] {
+			return fmt.Errorf("disks.mounts lists %s more than once", path)
+		}
+		seen[path] = true
+	}
+	return nil
+}
+
+// diskTargets returns the mounts to report: / and tank_mount without a disks
+// section, otherwise the listed mounts followed, in auto mode, by every
+// discovered mount not already listed. A discovery error is returned with
+// the listed mounts so they are still reported.
+func diskTargets(cfg ConfigAccessor, discover func() ([]string, error)) ([]diskTarget, error) {
+	if cfg.Disks == nil {
+		targets := []diskTarget{{Path: "/", Label: "Disk (/)"}}
+		if cfg.TankMount != "" {
+			targets = append(targets, diskTarget{Path: cfg.TankMount, Label: fmt.Sprintf("Disk (%s)", cfg.TankMount)})
+		}
+		return targets, nil
+	}
+
+	var targets []diskTarget
+	seen := make(map[string]bool)
+	add := func(path, label string) {
+		path = filepath.Clean(path)
+		if seen[path] {
+			return
+		}
+		seen[path] = true
+		if label == "" {
+			label = fmt.Sprintf("Disk (%s)", path)
+		}
+		targets = append(targets, diskTarget{Path: path, Label: label})
+	}
+	for _, mount := range cfg.Disks.Mounts {
+		add(strings.TrimSpace(mount.Path), strings.TrimSpace(mount.Label))
+	}
+	if !cfg.Disks.Auto {
+		return targets, nil
+	}
+	discovered, err := discover()
+	for _, path := range discovered {
+		add(path, "")
+	}
+	return targets, err
+}
+
+// parseMountinfo returns the mount points in /proc/self/mountinfo that hold
+// real local filesystems. Each device is reported once, at its first mount point,
+// so bind mounts and container volumes do not repeat a disk.
+func parseMountinfo(data []byte) ([]string, error) {
+	var mounts []string
+	devices := make(map[string]bool)
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		// 36 35 98:0 /mnt1 /mnt2 rw,noatime master:1 - ext3 /dev/root rw
+		pre, post, ok := strings.Cut(line, " - ")
+		fields, tail := strings.Fields(pre), strings.Fields(post)
+		if !ok || len(fields) < 5 || len(tail) < 1 {
+			return nil, errors.New("malformed mountinfo line")
+		}
+		if pseudoFilesystems[tail[0]] || networkFilesystems[tail[0]] || devices[fields[2]] {
+			continue
+		}
+		devices[fields[2]] = true
+		mounts = append(mounts, unescapeMountPath(fields[4]))
+	}
+	return mounts, nil
+}
+
+// unescapeMountPath decodes the octal escapes the kernel writes for spaces,
+// tabs, newlines and backslashes in mount paths.
+func unescapeMountPath(path string) string {
+	if !strings.Contains(path, `\`) {
+		return path
+	}
+	var b strings.Builder
+	for i := 0; i < len(path); i++ {
+		if path[i] == '\\' && i+3 < len(path) {
+			if value, err := strconv.ParseUint(path[i+1:i+4], 8, 8); err == nil {
+				b.WriteByte(byte(value))
+				i += 3
+				continue
+			}
+		}
+		b.WriteByte(path[i])
+	}
+	return b.String()
+}
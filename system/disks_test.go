@@ -0,0 +1,115 @@
+package system
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"motd/config"
+	"motd/display"
+)
+
+const mountinfoFixture = `22 1 8:1 / / rw,relatime shared:1 - ext4 /dev/sda1 rw,errors=remount-ro
+23 22 0:21 / /proc rw,nosuid,nodev,noexec,relatime shared:12 - proc proc rw
+24 22 0:5 / /dev rw,nosuid shared:2 - devtmpfs udev rw,size=8123456k
+25 22 0:22 / /run rw,nosuid,nodev,noexec,relatime shared:5 - tmpfs tmpfs rw,size=1626000k
+30 22 8:17 / /var/spool/mail rw,relatime shared:20 - xfs /dev/sdb1 rw
+31 22 0:45 / /mnt/tank rw,noatime shared:21 - zfs tank rw,xattr
+32 22 7:3 / /snap/core22/1380 ro,nodev,relatime shared:30 - squashfs /dev/loop3 ro
+33 22 0:60 / /var/lib/docker/overlay2/abc/merged rw,relatime - overlay overlay rw
+34 22 8:1 /srv/data /srv/data rw,relatime shared:1 - ext4 /dev/sda1 rw
+35 22 8:33 / /mnt/media\040library rw,relatime shared:22 - ext4 /dev/sdc1 rw
+36 22 0:70 / /mnt/backup rw,relatime shared:23 - nfs4 nas:/export/backup rw,vers=4.2
+37 22 0:71 / /mnt/share rw,relatime shared:24 - cifs //nas/share rw,vers=3.1.1
+38 22 0:72 / /mnt/remote rw,nosuid,nodev,relatime shared:25 - fuse.sshfs alice@host:/srv rw
+`
+
+func TestParseMountinfo(t *testing.T) {
+	mounts, err := parseMountinfo([]byte(mountinfoFixture))
+	if err != nil {
+		t.Fatalf("parseMountinfo failed: %v", err)
+	}
+	want := []string{"/", "/var/spool/mail", "/mnt/tank", "/mnt/media library"}
+	if !slices.Equal(mounts, want) {
+		t.Fatalf("got %q, want %q", mounts, want)
+	}
+
+	if _, err := parseMountinfo([]byte("22 1 8:1 / / rw\n")); err == nil {
+		t.Fatal("expected a line without the separator to be rejected")
+	}
+}
+
+func TestDiskTargets(t *testing.T) {
+	discover := func() ([]string, error) { return []string{"/", "/mnt/tank", "/var/spool/mail"}, nil }
+	tests := []struct {
+		name string
+		cfg  ConfigAccessor
+		want []diskTarget
+	}{
+		{"root only", ConfigAccessor{}, []diskTarget{{"/", "Disk (/)"}}},
+		{"tank mount", ConfigAccessor{TankMount: "/mnt/tank"}, []diskTarget{{"/", "Disk (/)"}, {"/mnt/tank", "Disk (/mnt/tank)"}}},
+		{"listed mounts", ConfigAccessor{TankMount: "/mnt/tank", Disks: &config.DisksConfig{Mounts: []config.MountConfig{
+			{Path: "/var/spool/mail/", Label: "Mail spool"},
+			{Path: "/"},
+		}}}, []diskTarget{{"/var/spool/mail", "Mail spool"}, {"/", "Disk (/)"}}},
+		{"auto after listed", ConfigAccessor{Disks: &config.DisksConfig{Auto: true, Mounts: []config.MountConfig{
+			{Path: "/mnt/tank", Label: "Tank"},
+		}}}, []diskTarget{{"/mnt/tank", "Tank"}, {"/", "Disk (/)"}, {"/var/spool/mail", "Disk (/var/spool/mail)"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := diskTargets(tt.cfg, discover)
+			if err != nil {
+				t.Fatalf("diskTargets failed: %v", err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiskTargetsKeepsListedMountsWhenDiscoveryFails(t *testing.T) {
+	cfg := ConfigAccessor{Disks: &config.DisksConfig{Auto: true, Mounts: []config.MountConfig{{Path: "/"}}}}
+	got, err := diskTargets(cfg, func() ([]string, error) { return nil, errors.New("no mountinfo") })
+	if err == nil || len(got) != 1 || got[0].Path != "/" {
+		t.Fatalf("expected / and the discovery error, got %+v, %v", got, err)
+	}
+}
+
+func TestValidateDisksConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		mounts []config.MountConfig
+		valid  bool
+	}{
+		{"absolute", []config.MountConfig{{Path: "/"}, {Path: "/var/spool/mail", Label: "Mail"}}, true},
+		{"relative", []config.MountConfig{{Path: "data"}}, false},
+		{"empty", []config.MountConfig{{Label: "Nothing"}}, false},
+		{"duplicate", []config.MountConfig{{Path: "/srv"}, {Path: "/srv/"}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateDisksConfig(&config.DisksConfig{Mounts: tt.mounts})
+			if (err == nil) != tt.valid {
+				t.Fatalf("ValidateDisksConfig(%+v) = %v, want valid=%v", tt.mounts, err, tt.valid)
+			}
+		})
+	}
+}
+
+func TestDiskInodesSeverity(t *testing.T) {
+	disk := newDiskUsage("Disk (/var/spool/mail)", "/var/spool/mail", 100*GB, 10*GB)
+	disk.setInodes(1000, 20)
+	snap := SystemSnapshot{Disks: DiskList{disk}}
+	snap.Evaluate(config.ThresholdsConfig{})
+
+	got := snap.Disks[0]
+	if got.InodesUsed != 980 || got.Severity != display.SeverityOK || got.InodeSeverity != display.SeverityCritical {
+		t.Fatalf("unexpected inode evaluation: %+v", got)
+	}
+	line := snap.Disks.Lines()[0]
+	if line.Value != "10.00 GB / 100.00 GB (10% used, 98% inodes)" || line.Color != display.Red {
+		t.Fatalf("expected full inodes to color the row red, got %+v", line)
+	}
+}
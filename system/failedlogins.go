@@ -0,0 +1,183 @@
+package system
+
+import (
+	"bufio"
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"motd/config"
+)
+
+const (
+	btmpPath = "/var/log/btmp"
+
+	// defaultFailedLoginSources is how many source addresses the summary
+	// names when failed_logins.top is unset.
+	defaultFailedLoginSources = 3
+
+	// failedLoginWindow bounds the count when there is no previous login to
+	// count from.
+	failedLoginWindow = 24 * time.Hour
+)
+
+// authLogPaths are the sshd logs of Debian-style and Red Hat-style systems.
+var authLogPaths = []string{"/var/log/auth.log", "/var/log/secure"}
+
+// FailedLogins counts the failed login attempts since Since, which is the
+// current user's previous login when SinceLastLogin is set and the start
+// of failedLoginWindow otherwise. Source is the file they were read from.
+type FailedLogins struct {
+	Count          int                 `json:"count"`
+	Since          time.Time           `json:"since"`
+	SinceLastLogin bool                `json:"since_last_login"`
+	Source         string              `json:"source"`
+	TopSources     []FailedLoginSource `json:"top_sources,omitempty"`
+}
+
+// FailedLoginSource is one remote address and its failed attempts.
+type FailedLoginSource struct {
+	Address string `json:"address"`
+	Count   int    `json:"count"`
+}
+
+// failedLoginAttempt is one failed login from btmp or the auth log.
+type failedLoginAttempt struct {
+	time    time.Time
+	address string
+}
+
+// failedLoginsEnabled runs the collector only when system.failed_logins is
+// configured, since its sources are readable by root alone on most hosts.
+func failedLoginsEnabled(cfg ConfigAccessor) bool {
+	return cfg.FailedLogins != nil
+}
+
+// ValidateFailedLoginsConfig rejects a negative top.
+func ValidateFailedLoginsConfig(failed *config.FailedLoginsConfig) error {
+	if failed != nil && failed.Top < 0 {
+		return errors.New("system.failed_logins.top must not be negative")
+	}
+	return nil
+}
+
+// FailedLoginsReadable reports whether motd can open btmp or an auth log,
+// which most hosts restrict to root and the adm group.
+func FailedLoginsReadable() bool {
+	for _, path := range append([]string{btmpPath}, authLogPaths...) {
+		if file, err := os.Open(path); err == nil {
+			file.Close()
+			return true
+		}
+	}
+	return false
+}
+
+// btmpAttempts takes the source address of each btmp record from its host.
+func btmpAttempts(records []UserSession) []failedLoginAttempt {
+	attempts := make([]failedLoginAttempt, 0, len(records))
+	for _, record := range records {
+		attempts = append(attempts, failedLoginAttempt{time: record.LoginTime, address: record.Host})
+	}
+	return attempts
+}
+
+var (
+	sshdFailed = regexp.MustCompile(`sshd\[\d+\]: Failed \S+ for (?:invalid user )?.* from (\S+) port \d+`)
+	isoStamp   = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}T`)
+)
+
+// parseAuthLog reads the sshd "Failed password for ... from ADDR port N"
+// lines of an auth log. Lines start with either a classic syslog stamp,
+// which has no year and is taken to be within the year before now, or an
+// RFC 3339 stamp as newer rsyslog defaults write.
+func parseAuthLog(r io.Reader, now time.Time) ([]failedLoginAttempt, error) {
+	var attempts []failedLoginAttempt
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		match := sshdFailed.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		at, ok := parseSyslogTime(line, now)
+		if !ok {
+			continue
+		}
+		attempts = append(attempts, failedLoginAttempt{time: at, address: match[1]})
+	}
+	return attempts, scanner.Err()
+}
+
+func parseSyslogTime(line string, now time.Time) (time.Time, bool) {
+	if isoStamp.MatchString(line) {
+		stamp, _, _ := strings.Cut(line, " ")
+		at, err := time.Parse(time.RFC3339Nano, stamp)
+		return at, err == nil
+	}
+	if len(line) < 15 {
+		return time.Time{}, false
+	}
+	at, err := time.ParseInLocation("Jan _2 15:04:05", line[:15], now.Location())
+	if err != nil {
+		return time.Time{}, false
+	}
+	at = at.AddDate(now.Year(), 0, 0)
+	if at.After(now.Add(24 * time.Hour)) {
+		at = at.AddDate(-1, 0, 0)
+	}
+	return at, true
+}
+
+// summarizeFailedLogins counts the attempts after since and names the top
+// source addresses, most attempts first.
+func summarizeFailedLogins(attempts []failedLoginAttempt, since time.Time, top int) FailedLogins {
+	summary := FailedLogins{Since: since}
+	counts := make(map[string]int)
+	for _, attempt := range attempts {
+		if !attempt.time.After(since) {
+			continue
+		}
+		summary.Count++
+		if attempt.address != "" {
+			counts[attempt.address]++
+		}
+	}
+	for address, count := range counts {
+		summary.TopSources = append(summary.TopSources, FailedLoginSource{Address: address, Count: count})
+	}
+	slices.SortFunc(summary.TopSources, func(a, b FailedLoginSource) int {
+		if c := cmp.Compare(b.Count, a.Count); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Address, b.Address)
+	})
+	if len(summary.TopSources) > top {
+		summary.TopSources = summary.TopSources[:top]
+	}
+	return summary
+}
+
+// Value renders the summary as "37 since last login: 203.0.113.9 (20),
+// 198.51.100.4 (9)".
+func (f FailedLogins) Value() string {
+	value := fmt.Sprintf("%d since last login", f.Count)
+	if !f.SinceLastLogin {
+		value = fmt.Sprintf("%d in the last %d hours", f.Count, int(failedLoginWindow.Hours()))
+	}
+	if len(f.TopSources) == 0 {
+		return value
+	}
+	sources := make([]string, 0, len(f.TopSources))
+	for _, source := range f.TopSources {
+		sources = append(sources, fmt.Sprintf("%s (%d)", source.Address, source.Count))
+	}
+	return value + ": " + strings.Join(sources, ", ")
+}
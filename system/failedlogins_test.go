@@ -0,0 +1,146 @@
+package system
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+	"time"
+
+	"motd/config"
+)
+
+func TestParseBtmp(t *testing.T) {
+	since := time.Date(2026, 10, 13, 9, 12, 0, 0, time.Local)
+	var data bytes.Buffer
+	data.Write(utmpFixture(6, "ssh:notty", "root", "203.0.113.9", since.Add(-time.Hour)))
+	data.Write(utmpFixture(6, "ssh:notty", "admin", "203.0.113.9", since.Add(time.Hour)))
+	data.Write(utmpFixture(6, "ssh:notty", "root", "198.51.100.4", since.Add(2*time.Hour)))
+	data.Write(utmpFixture(7, "pts/0", "alice", "192.0.2.7", since.Add(3*time.Hour)))
+	data.Write(utmpFixture(6, "ssh:notty", "oracle", "203.0.113.9", since.Add(4*time.Hour)))
+	data.Write(utmpFixture(6, "tty1", "bob", "", since.Add(5*time.Hour)))
+
+	records, err := parseUtmp(&data, binary.LittleEndian, utmpLoginProcess)
+	if err != nil {
+		t.Fatalf("parseUtmp failed: %v", err)
+	}
+	summary := summarizeFailedLogins(btmpAttempts(records), since, 1)
+	if summary.Count != 4 {
+		t.Fatalf("expected 4 failed logins since the last login, got %d", summary.Count)
+	}
+	if len(summary.TopSources) != 1 || summary.TopSources[0] != (FailedLoginSource{Address: "203.0.113.9", Count: 2}) {
+		t.Fatalf("expected 203.0.113.9 as the top source, got %+v", summary.TopSources)
+	}
+}
+
+const authLogFixture = `Oct 12 23:59:58 host sshd[811]: Failed password for root from 203.0.113.9 port 50122 ssh2
+Oct 13 09:12:01 host sshd[902]: Accepted publickey for alice from 192.0.2.7 port 41022 ssh2: ED25519 SHA256:abc
+Oct 13 10:01:17 host sshd[913]: Failed password for invalid user oracle from 203.0.113.9 port 50234 ssh2
+Oct 13 10:01:19 host sshd[913]: Failed password for invalid user oracle from 203.0.113.9 port 50234 ssh2
+Oct 13 10:02:40 host sshd[917]: Failed publickey for root from 2001:db8::7 port 60112 ssh2: RSA SHA256:def
+Oct 13 10:05:02 host sshd[920]: Invalid user test from 198.51.100.4 port 33012
+Oct  3 10:06:00 host sudo: pam_unix(sudo:auth): authentication failure; logname=alice
+Oct 13 10:07:33 host sshd[925]: Failed password for bob from 198.51.100.4 port 33020 ssh2
+`
+
+const secureFixture = `2026-10-13T10:01:17.123456+00:00 host sshd[913]: Failed password for invalid user oracle from 203.0.113.9 port 50234 ssh2
+2026-10-13T10:02:40.000001+00:00 host sshd[917]: Failed password for root from 198.51.100.4 port 60112 ssh2
+2026-10-13T08:00:00.000000+00:00 host sshd[890]: Failed password for root from 198.51.100.4 port 60001 ssh2
+`
+
+func TestParseAuthLog(t *testing.T) {
+	now := time.Date(2026, 10, 13, 12, 0, 0, 0, time.UTC)
+	since := time.Date(2026, 10, 13, 9, 12, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		log      string
+		attempts int
+		count    int
+		top      []FailedLoginSource
+	}{
+		{
+			name:     "auth.log",
+			log:      authLogFixture,
+			attempts: 5,
+			count:    4,
+			top:      []FailedLoginSource{{Address: "203.0.113.9", Count: 2}, {Address: "198.51.100.4", Count: 1}, {Address: "2001:db8::7", Count: 1}},
+		},
+		{
+			name:     "rsyslog RFC 3339 stamps",
+			log:      secureFixture,
+			attempts: 3,
+			count:    2,
+			top:      []FailedLoginSource{{Address: "198.51.100.4", Count: 1}, {Address: "203.0.113.9", Count: 1}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts, err := parseAuthLog(strings.NewReader(tt.log), now)
+			if err != nil {
+				t.Fatalf("parseAuthLog failed: %v", err)
+			}
+			if len(attempts) != tt.attempts {
+				t.Fatalf("expected %d attempts, got %+v", tt.attempts, attempts)
+			}
+			summary := summarizeFailedLogins(attempts, since, defaultFailedLoginSources)
+			if summary.Count != tt.count {
+				t.Fatalf("expected %d since the last login, got %d", tt.count, summary.Count)
+			}
+			if len(summary.TopSources) != len(tt.top) {
+				t.Fatalf("expected top sources %+v, got %+v", tt.top, summary.TopSources)
+			}
+			for i := range tt.top {
+				if summary.TopSources[i] != tt.top[i] {
+					t.Fatalf("top source %d = %+v, want %+v", i, summary.TopSources[i], tt.top[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseSyslogTimeAcrossNewYear(t *testing.T) {
+	now := time.Date(2027, 1, 1, 0, 5, 0, 0, time.UTC)
+	at, ok := parseSyslogTime("Dec 31 23:59:58 host sshd[811]: Failed password", now)
+	if !ok || !at.Equal(time.Date(2026, 12, 31, 23, 59, 58, 0, time.UTC)) {
+		t.Fatalf("expected the stamp to fall in the previous year, got %v, %v", at, ok)
+	}
+}
+
+func TestFailedLoginsLines(t *testing.T) {
+	tests := []struct {
+		name   string
+		failed FailedLogins
+		want   string
+	}{
+		{
+			name:   "since last login",
+			failed: FailedLogins{Count: 37, SinceLastLogin: true, TopSources: []FailedLoginSource{{Address: "203.0.113.9", Count: 20}, {Address: "198.51.100.4", Count: 9}}},
+			want:   "Failed logins=37 since last login: 203.0.113.9 (20), 198.51.100.4 (9)",
+		},
+		{
+			name:   "no previous login",
+			failed: FailedLogins{},
+			want:   "Failed logins=0 in the last 24 hours",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines := tt.failed.Lines()
+			if len(lines) != 1 {
+				t.Fatalf("expected one line, got %+v", lines)
+			}
+			if got := lines[0].Label + "=" + lines[0].Value; got != tt.want {
+				t.Fatalf("line = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateFailedLoginsConfig(t *testing.T) {
+	if err := ValidateFailedLoginsConfig(&config.FailedLoginsConfig{Top: -1}); err == nil {
+		t.Fatal("expected a negative top to be rejected")
+	}
+	if err := ValidateFailedLoginsConfig(&config.FailedLoginsConfig{}); err != nil {
+		t.Fatalf("expected the default top to be accepted, got %v", err)
+	}
+}
@@ -0,0 +1,230 @@
+package system
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"motd/config"
+	"motd/display"
+)
+
+const (
+	hwmonPath = "/sys/class/hwmon"
+
+	// CPUPackageSensor selects the sensor for the whole CPU package, whatever
+	// the driver calls it.
+	CPUPackageSensor = "CPU Package"
+)
+
+var hwmonTempInput = regexp.MustCompile(`^temp(\d+)_input$`)
+
+// TemperatureSensor is one hwmon temperature reading. MaxCelsius and
+// CritCelsius are the chip's own limits, nil when the driver reports none.
+// Device is the hwmon directory, which tells apart identical chips such as
+// two NVMe drives.
+type TemperatureSensor struct {
+	Name        string           `json:"name"`
+	Chip        string           `json:"chip"`
+	Label       string           `json:"label,omitempty"`
+	Device      string           `json:"device"`
+	Celsius     float64          `json:"celsius"`
+	MaxCelsius  *float64         `json:"max_celsius,omitempty"`
+	CritCelsius *float64         `json:"crit_celsius,omitempty"`
+	Severity    display.Severity `json:"severity,omitempty"`
+}
+
+// Levels uses the sensor's own max and crit as its warn and critical levels,
+// taking whichever the chip does not report from fallback. A fallback warn
+// above the chip's crit is dropped.
+func (s TemperatureSensor) Levels(fallback config.Threshold) config.Threshold {
+	levels := fallback
+	if s.MaxCelsius != nil {
+		levels.Warn = s.MaxCelsius
+	}
+	if s.CritCelsius != nil {
+		levels.Critical = s.CritCelsius
+		if levels.Warn != nil && *levels.Warn > *levels.Critical {
+			levels.Warn = nil
+		}
+	}
+	return levels
+}
+
+// ValidateTemperatureConfig rejects blank sensor selectors.
+func ValidateTemperatureConfig(temperature config.TemperatureConfig) error {
+	for _, sensor := range temperature.Sensors {
+		if strings.TrimSpace(sensor) == "" {
+			return errors.New("system.temperature.sensors must not contain empty names")
+		}
+	}
+	return nil
+}
+
+// scanHwmon reads every temp*_input under /sys/class/hwmon, given as fsys,
+// in hwmon and sensor order. Older drivers keep their files in the device
+// subdirectory. Sensors that cannot be read, such as a drive in standby,
+// are skipped.
+func scanHwmon(fsys fs.FS) ([]TemperatureSensor, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(entries, func(a, b fs.DirEntry) int {
+		return naturalCompare(a.Name(), b.Name())
+	})
+
+	var sensors []TemperatureSensor
+	for _, entry := range entries {
+		device := entry.Name()
+		chip, err := fs.ReadFile(fsys, path.Join(device, "name"))
+		if err != nil {
+			continue
+		}
+		dir := device
+		inputs := hwmonInputs(fsys, dir)
+		if len(inputs) == 0 {
+			dir = path.Join(device, "device")
+			inputs = hwmonInputs(fsys, dir)
+		}
+		for _, prefix := range inputs {
+			celsius, ok := readMillidegrees(fsys, path.Join(dir, prefix+"_input"))
+			if !ok {
+				continue
+			}
+			sensor := TemperatureSensor{Chip: strings.TrimSpace(string(chip)), Device: device, Celsius: celsius}
+			if label, err := fs.ReadFile(fsys, path.Join(dir, prefix+"_label")); err == nil {
+				sensor.Label = strings.TrimSpace(string(label))
+			}
+			if max, ok := readMillidegrees(fsys, path.Join(dir, prefix+"_max")); ok && max > 0 {
+				sensor.MaxCelsius = &max
+			}
+			if crit, ok := readMillidegrees(fsys, path.Join(dir, prefix+"_crit")); ok && crit > 0 {
+				sensor.CritCelsius = &crit
+			}
+			sensors = append(sensors, sensor)
+		}
+	}
+	return sensors, nil
+}
+
+// hwmonInputs returns the "tempN" prefixes in dir in numeric order.
+func hwmonInputs(fsys fs.FS, dir string) []string {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil
+	}
+	var prefixes []string
+	for _, entry := range entries {
+		if match := hwmonTempInput.FindStringSubmatch(entry.Name()); match != nil {
+			prefixes = append(prefixes, "temp"+match[1])
+		}
+	}
+	slices.SortFunc(prefixes, naturalCompare)
+	return prefixes
+}
+
+func readMillidegrees(fsys fs.FS, name string) (float64, bool) {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return 0, false
+	}
+	value, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return float64(value) / 1000, true
+}
+
+// naturalCompare orders "hwmon2" before "hwmon10".
+func naturalCompare(a, b string) int {
+	aPrefix, aNumber := splitTrailingNumber(a)
+	bPrefix, bNumber := splitTrailingNumber(b)
+	if c := strings.Compare(aPrefix, bPrefix); c != 0 {
+		return c
+	}
+	return aNumber - bNumber
+}
+
+func splitTrailingNumber(s string) (string, int) {
+	i := len(s)
+	for i > 0 && s[i-1] >= '0' && s[i-1] <= '9' {
+		i--
+	}
+	number, _ := strconv.Atoi(s[i:])
+	return s[:i], number
+}
+
+// cpuPackageSensors returns the indexes of the sensors that measure the CPU
+// package: every coretemp "Package id N", otherwise the k10temp or zenpower
+// Tdie (or Tctl when there is no Tdie), otherwise an SoC thermal sensor.
+func cpuPackageSensors(sensors []TemperatureSensor) []int {
+	var packages, tdie, tctl, soc []int
+	for i, sensor := range sensors {
+		switch {
+		case sensor.Chip == "coretemp" && strings.HasPrefix(sensor.Label, "Package id"):
+			packages = append(packages, i)
+		case (sensor.Chip == "k10temp" || sensor.Chip == "zenpower") && sensor.Label == "Tdie":
+			tdie = append(tdie, i)
+		case (sensor.Chip == "k10temp" || sensor.Chip == "zenpower") && sensor.Label == "Tctl":
+			tctl = append(tctl, i)
+		case sensor.Chip == "cpu_thermal" || sensor.Chip == "soc_thermal":
+			soc = append(soc, i)
+		}
+	}
+	for _, candidates := range [][]int{packages, tdie, tctl, soc} {
+		if len(candidates) > 0 {
+			return candidates
+		}
+	}
+	return nil
+}
+
+// selectSensors returns the sensors each selector matches, in selector
+// order and without repeats. A selector is CPUPackageSensor, a chip name
+// such as "drivetemp", a sensor label, or "chip label" such as
+// "nvme Composite", all compared case-insensitively. Sensors that would
+// share a name are told apart by their hwmon device.
+func selectSensors(sensors []TemperatureSensor, selectors []string) []TemperatureSensor {
+	cpu := cpuPackageSensors(sensors)
+	var picked []int
+	for _, selector := range selectors {
+		selector = strings.TrimSpace(selector)
+		for i, sensor := range sensors {
+			matches := strings.EqualFold(selector, CPUPackageSensor) && slices.Contains(cpu, i)
+			matches = matches || strings.EqualFold(selector, sensor.Chip) ||
+				(sensor.Label != "" && strings.EqualFold(selector, sensor.Label)) ||
+				(sensor.Label != "" && strings.EqualFold(selector, sensor.Chip+" "+sensor.Label))
+			if matches && !slices.Contains(picked, i) {
+				picked = append(picked, i)
+			}
+		}
+	}
+
+	selected := make([]TemperatureSensor, 0, len(picked))
+	counts := make(map[string]int)
+	for _, i := range picked {
+		sensor := sensors[i]
+		switch {
+		case slices.Contains(cpu, i):
+			sensor.Name = CPUPackageSensor
+		case sensor.Label != "":
+			sensor.Name = sensor.Chip + " " + sensor.Label
+		default:
+			sensor.Name = sensor.Chip
+		}
+		counts[sensor.Name]++
+		selected = append(selected, sensor)
+	}
+	for i := range selected {
+		if counts[selected[i].Name] > 1 {
+			selected[i].Name = fmt.Sprintf("%s (%s)", selected[i].Name, selected[i].Device)
+		}
+	}
+	return selected
+}
@@ -0,0 +1,145 @@
+package system
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"motd/config"
+	"motd/display"
+)
+
+func hwmonFixture() fstest.MapFS {
+	return fstest.MapFS{
+		"hwmon0/name":                {Data: []byte("acpitz\n")},
+		"hwmon0/temp1_input":         {Data: []byte("27800\n")},
+		"hwmon2/name":                {Data: []byte("coretemp\n")},
+		"hwmon2/temp1_input":         {Data: []byte("58000\n")},
+		"hwmon2/temp1_label":         {Data: []byte("Package id 0\n")},
+		"hwmon2/temp1_max":           {Data: []byte("80000\n")},
+		"hwmon2/temp1_crit":          {Data: []byte("100000\n")},
+		"hwmon2/temp2_input":         {Data: []byte("55000\n")},
+		"hwmon2/temp2_label":         {Data: []byte("Core 0\n")},
+		"hwmon3/name":                {Data: []byte("nvme\n")},
+		"hwmon3/temp1_input":         {Data: []byte("83850\n")},
+		"hwmon3/temp1_label":         {Data: []byte("Composite\n")},
+		"hwmon3/temp1_max":           {Data: []byte("81850\n")},
+		"hwmon3/temp1_crit":          {Data: []byte("84850\n")},
+		"hwmon3/temp2_input":         {Data: []byte("40850\n")},
+		"hwmon3/temp2_label":         {Data: []byte("Sensor 1\n")},
+		"hwmon10/name":               {Data: []byte("nvme\n")},
+		"hwmon10/temp1_input":        {Data: []byte("38850\n")},
+		"hwmon10/temp1_label":        {Data: []byte("Composite\n")},
+		"hwmon10/temp1_crit":         {Data: []byte("84850\n")},
+		"hwmon11/name":               {Data: []byte("drivetemp\n")},
+		"hwmon11/temp1_input":        {Data: []byte("36000\n")},
+		"hwmon11/temp1_crit":         {Data: []byte("0\n")},
+		"hwmon12/name":               {Data: []byte("drivetemp\n")},
+		"hwmon12/temp1_input":        {Data: []byte("")},
+		"hwmon13/name":               {Data: []byte("it8721\n")},
+		"hwmon13/device/temp1_input": {Data: []byte("45000\n")},
+	}
+}
+
+func TestScanHwmon(t *testing.T) {
+	sensors, err := scanHwmon(hwmonFixture())
+	if err != nil {
+		t.Fatalf("scanHwmon failed: %v", err)
+	}
+	var devices []string
+	for _, sensor := range sensors {
+		devices = append(devices, sensor.Device+"/"+sensor.Label)
+	}
+	want := []string{"hwmon0/", "hwmon2/Package id 0", "hwmon2/Core 0", "hwmon3/Composite", "hwmon3/Sensor 1", "hwmon10/Composite", "hwmon11/", "hwmon13/"}
+	if len(devices) != len(want) {
+		t.Fatalf("sensors = %v, want %v", devices, want)
+	}
+	for i := range want {
+		if devices[i] != want[i] {
+			t.Fatalf("sensors = %v, want %v", devices, want)
+		}
+	}
+	if sensors[1].MaxCelsius == nil || *sensors[1].MaxCelsius != 80 || *sensors[1].CritCelsius != 100 {
+		t.Fatalf("expected coretemp limits 80/100, got %+v", sensors[1])
+	}
+	if sensors[6].CritCelsius != nil {
+		t.Fatalf("expected a zero crit to be ignored, got %v", *sensors[6].CritCelsius)
+	}
+}
+
+func TestSelectSensors(t *testing.T) {
+	sensors, err := scanHwmon(hwmonFixture())
+	if err != nil {
+		t.Fatalf("scanHwmon failed: %v", err)
+	}
+	selected := selectSensors(sensors, []string{"CPU Package", "nvme composite", "drivetemp", "coretemp package id 0", "missing"})
+	snap := SystemSnapshot{Temperature: &TemperatureInfo{Celsius: selected[0].Celsius, Sensors: selected}}
+	snap.Evaluate(config.ThresholdsConfig{})
+
+	want := []struct {
+		line     string
+		severity display.Severity
+	}{
+		{"CPU Package=58°C", display.SeverityOK},
+		{"nvme Composite (hwmon3)=84°C", display.SeverityWarning},
+		{"nvme Composite (hwmon10)=39°C", display.SeverityOK},
+		{"drivetemp=36°C", display.SeverityOK},
+	}
+	lines := snap.Temperature.Lines()
+	if len(lines) != len(want) {
+		t.Fatalf("expected %d lines, got %+v", len(want), lines)
+	}
+	for i, line := range lines {
+		if got := line.Label + "=" + line.Value; got != want[i].line {
+			t.Fatalf("line %d = %q, want %q", i, got, want[i].line)
+		}
+		if snap.Temperature.Sensors[i].Severity != want[i].severity {
+			t.Fatalf("line %d severity = %q, want %q", i, snap.Temperature.Sensors[i].Severity, want[i].severity)
+		}
+	}
+	if snap.Temperature.Severity != display.SeverityWarning {
+		t.Fatalf("expected the worst sensor to set the severity, got %q", snap.Temperature.Severity)
+	}
+}
+
+func TestSelectSensorsPrefersTdie(t *testing.T) {
+	sensors := []TemperatureSensor{
+		{Chip: "k10temp", Label: "Tctl", Device: "hwmon1", Celsius: 71},
+		{Chip: "k10temp", Label: "Tdie", Device: "hwmon1", Celsius: 61},
+		{Chip: "k10temp", Label: "Tccd1", Device: "hwmon1", Celsius: 59},
+	}
+	selected := selectSensors(sensors, []string{CPUPackageSensor})
+	if len(selected) != 1 || selected[0].Label != "Tdie" || selected[0].Name != CPUPackageSensor {
+		t.Fatalf("expected Tdie as the CPU package, got %+v", selected)
+	}
+}
+
+func TestTemperatureSensorLevels(t *testing.T) {
+	crit := 60.0
+	tests := []struct {
+		name   string
+		sensor TemperatureSensor
+		value  float64
+		want   display.Severity
+	}{
+		{"fallback without limits", TemperatureSensor{}, 72, display.SeverityWarning},
+		{"chip crit replaces fallback critical", TemperatureSensor{CritCelsius: &crit}, 61, display.SeverityCritical},
+		{"fallback warn above chip crit dropped", TemperatureSensor{CritCelsius: &crit}, 59, display.SeverityOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			levels := tt.sensor.Levels(config.ThresholdsConfig{}.TemperatureLevels())
+			if got := levels.Evaluate(tt.value); got != tt.want {
+				t.Fatalf("Evaluate(%v) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateTemperatureConfig(t *testing.T) {
+	if err := ValidateTemperatureConfig(config.TemperatureConfig{Sensors: []string{"CPU Package", " "}}); err == nil {
+		t.Fatalf("expected a blank sensor to be rejected")
+	}
+	if err := ValidateTemperatureConfig(config.TemperatureConfig{Sensors: []string{"drivetemp"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,144 @@
+package system
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"motd/display"
+)
+
+// InterfacesAll in network.interfaces selects every non-loopback interface.
+const InterfacesAll = "all"
+
+// Link states reported in InterfaceInfo.State besides the kernel operstate.
+const (
+	LinkUp       = "up"
+	LinkDown     = "down"
+	LinkNotFound = "not found"
+)
+
+// InterfaceList is the set of interfaces selected by network.interfaces.
+type InterfaceList []InterfaceInfo
+
+// InterfaceInfo describes one network interface. SpeedMbps is zero when the
+// driver reports no link speed, and Bandwidth is nil when no accounting is
+// available for the interface. Severity is only set for interfaces listed by
+// name, so a down interface picked up by "all" is not an alert.
+type InterfaceInfo struct {
+	Name      string           `json:"name"`
+	State     string           `json:"state"`
+	SpeedMbps int              `json:"speed_mbps,omitempty"`
+	IPv4      []string         `json:"ipv4,omitempty"`
+	IPv6      []string         `json:"ipv6,omitempty"`
+	Bandwidth *BandwidthInfo   `json:"bandwidth,omitempty"`
+	Severity  display.Severity `json:"severity,omitempty"`
+}
+
+func interfacesEnabled(cfg ConfigAccessor) bool {
+	return len(cfg.NetworkInterfaces) > 0
+}
+
+// bandwidthEnabled hands bandwidth over to the interfaces collector, which
+// reports it per interface, when network.interfaces is set.
+func bandwidthEnabled(cfg ConfigAccessor) bool {
+	return !interfacesEnabled(cfg)
+}
+
+// readInterfaces reports link state, addresses and month-to-date traffic for
+// each interface in network.interfaces. A listed interface that does not
+// exist is reported as not found. Bandwidth that cannot be read is left out
+// rather than failing the whole collector.
+func readInterfaces(ctx context.Context, cfg ConfigAccessor) (InterfaceList, error) {
+	var list InterfaceList
+	if len(cfg.NetworkInterfaces) == 1 && strings.TrimSpace(cfg.NetworkInterfaces[0]) == InterfacesAll {
+		present, err := net.Interfaces()
+		if err != nil {
+			return nil, err
+		}
+		for _, iface := range present {
+			if iface.Flags&net.FlagLoopback == 0 {
+				list = append(list, describeInterface(iface))
+			}
+		}
+	} else {
+		for _, name := range cfg.NetworkInterfaces {
+			name = strings.TrimSpace(name)
+			iface, err := net.InterfaceByName(name)
+			if err != nil {
+				list = append(list, InterfaceInfo{Name: name, State: LinkNotFound, Severity: display.SeverityWarning})
+				continue
+			}
+			info := describeInterface(*iface)
+			info.Severity = display.SeverityWarning
+			if info.State == LinkUp {
+				info.Severity = display.SeverityOK
+			}
+			list = append(list, info)
+		}
+	}
+
+	names := make([]string, 0, len(list))
+	for _, info := range list {
+		if info.State != LinkNotFound {
+			names = append(names, info.Name)
+		}
+	}
+	if len(names) > 0 {
+		usage, _ := readInterfaceBandwidth(ctx, cfg, names)
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		for i := range list {
+			if bandwidth, ok := usage[list[i].Name]; ok {
+				list[i].Bandwidth = &bandwidth
+			}
+		}
+	}
+	return list, nil
+}
+
+// describeInterface prefers the kernel's operstate and speed where the
+// platform has them and falls back to the interface flags.
+func describeInterface(iface net.Interface) InterfaceInfo {
+	info := InterfaceInfo{Name: iface.Name}
+	if state, speed, ok := interfaceLink(iface.Name); ok {
+		info.State, info.SpeedMbps = state, speed
+	} else if iface.Flags&net.FlagUp != 0 && iface.Flags&net.FlagRunning != 0 {
+		info.State = LinkUp
+	} else {
+		info.State = LinkDown
+	}
+	if addrs, err := iface.Addrs(); err == nil {
+		info.IPv4, info.IPv6 = interfaceAddresses(addrs)
+	}
+	return info
+}
+
+// interfaceAddresses splits addrs into IPv4 and IPv6 CIDR strings. Link-local
+// addresses are skipped; every IPv6 interface has one and it says nothing
+// about how the host is reached.
+func interfaceAddresses(addrs []net.Addr) (ipv4, ipv6 []string) {
+	for _, addr := range addrs {
+		prefix, ok := addr.(*net.IPNet)
+		if !ok || prefix.IP.IsLinkLocalUnicast() {
+			continue
+		}
+		if prefix.IP.To4() != nil {
+			ipv4 = append(ipv4, prefix.String())
+		} else {
+			ipv6 = append(ipv6, prefix.String())
+		}
+	}
+	return ipv4, ipv6
+}
+
+// formatLinkSpeed renders a speed in Mb/s as "100 Mb/s" or "2.5 Gb/s".
+func formatLinkSpeed(mbps int) string {
+	if mbps >= 1000 {
+		return strconv.FormatFloat(float64(mbps)/1000, 'f', -1, 64) + " Gb/s"
+	}
+	return fmt.Sprintf("%d Mb/s", mbps)
+}
@@ -0,0 +1,59 @@
+package system
+
+import (
+	"context"
+	"net"
+	"slices"
+	"testing"
+
+	"motd/display"
+)
+
+func TestInterfaceAddresses(t *testing.T) {
+	var addrs []net.Addr
+	for _, cidr := range []string{"192.0.2.10/24", "169.254.1.1/16", "fe80::1/64", "2001:db8::10/64", "fd00::1/8"} {
+		ip, prefix, err := net.ParseCIDR(cidr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		prefix.IP = ip
+		addrs = append(addrs, prefix)
+	}
+
+	ipv4, ipv6 := interfaceAddresses(addrs)
+	if !slices.Equal(ipv4, []string{"192.0.2.10/24"}) {
+		t.Fatalf("unexpected IPv4 addresses %v", ipv4)
+	}
+	if !slices.Equal(ipv6, []string{"2001:db8::10/64", "fd00::1/8"}) {
+		t.Fatalf("unexpected IPv6 addresses %v", ipv6)
+	}
+}
+
+func TestFormatLinkSpeed(t *testing.T) {
+	tests := map[int]string{100: "100 Mb/s", 1000: "1 Gb/s", 2500: "2.5 Gb/s", 10000: "10 Gb/s"}
+	for mbps, want := range tests {
+		if got := formatLinkSpeed(mbps); got != want {
+			t.Fatalf("formatLinkSpeed(%d) = %q, want %q", mbps, got, want)
+		}
+	}
+}
+
+func TestReadInterfacesReportsMissingInterface(t *testing.T) {
+	list, err := readInterfaces(context.Background(), ConfigAccessor{NetworkInterfaces: []string{"motd-test-missing0"}})
+	if err != nil {
+		t.Fatalf("readInterfaces failed: %v", err)
+	}
+	if len(list) != 1 || list[0].State != LinkNotFound || list[0].Severity != display.SeverityWarning {
+		t.Fatalf("expected a not found warning, got %+v", list)
+	}
+}
+
+func TestBandwidthCollectorYieldsToInterfaces(t *testing.T) {
+	cfg := ConfigAccessor{NetworkInterfaces: []string{InterfacesAll}}
+	if bandwidthEnabled(cfg) || !interfacesEnabled(cfg) {
+		t.Fatal("expected network.interfaces to replace the bandwidth collector")
+	}
+	if !bandwidthEnabled(ConfigAccessor{}) || interfacesEnabled(ConfigAccessor{}) {
+		t.Fatal("expected the bandwidth collector without network.interfaces")
+	}
+}
@@ -0,0 +1,200 @@
+package system
+
+import (
+	"context"
+	"errors"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"motd/display"
+)
+
+const mdstatPath = "/proc/mdstat"
+
+// RAIDArrayList is every md array in /proc/mdstat, in kernel order.
+type RAIDArrayList []RAIDArray
+
+// RAIDArray is one Linux software RAID array. Status is the kernel's member
+// map such as "UU_", where "_" is a missing member. SyncAction is the
+// running resync, recovery, reshape, check or repair, if any; SyncPercent
+// and SyncETASeconds are nil while it is delayed or pending.
+type RAIDArray struct {
+	Name           string           `json:"name"`
+	Level          string           `json:"level,omitempty"`
+	State          string           `json:"state"`
+	Devices        int              `json:"devices,omitempty"`
+	ActiveDevices  int              `json:"active_devices,omitempty"`
+	Status         string           `json:"status,omitempty"`
+	Members        []string         `json:"members,omitempty"`
+	FailedMembers  []string         `json:"failed_members,omitempty"`
+	SpareMembers   []string         `json:"spare_members,omitempty"`
+	Degraded       bool             `json:"degraded"`
+	SyncAction     string           `json:"sync_action,omitempty"`
+	SyncPercent    *float64         `json:"sync_percent,omitempty"`
+	SyncETASeconds *float64         `json:"sync_eta_seconds,omitempty"`
+	Severity       display.Severity `json:"severity,omitempty"`
+}
+
+var (
+	mdstatCounts   = regexp.MustCompile(`\[(\d+)/(\d+)\]\s+\[([U_]+)\]`)
+	mdstatProgress = regexp.MustCompile(`\b(resync|recovery|reshape|check|repair)\s*=\s*([\d.]+)%`)
+	mdstatWaiting  = regexp.MustCompile(`\b(resync|recovery|reshape|check|repair)\s*=\s*(DELAYED|PENDING)`)
+	mdstatFinish   = regexp.MustCompile(`\bfinish=([\d.]+)min`)
+	mdstatMember   = regexp.MustCompile(`^(\S+)\[\d+\]((?:\([A-Z]\))*)$`)
+)
+
+// mdraidEnabled skips the collector on hosts without the md driver loaded.
+func mdraidEnabled(cfg ConfigAccessor) bool {
+	_, err := os.Stat(mdstatPath)
+	return err == nil
+}
+
+func readRAIDArrays(ctx context.Context, cfg ConfigAccessor) (RAIDArrayList, error) {
+	data, err := os.ReadFile(mdstatPath)
+	if err != nil {
+		return nil, err
+	}
+	arrays := parseMdstat(data)
+	if len(arrays) == 0 {
+		return nil, errors.New("no md arrays assembled")
+	}
+	return arrays, nil
+}
+
+// parseMdstat parses /proc/mdstat. Each array starts on an unindented
+// "mdN : state level members" line and continues on indented lines with the
+// member counts and any sync progress.
+func parseMdstat(data []byte) RAIDArrayList {
+	var arrays RAIDArrayList
+	var current *RAIDArray
+	for _, line := range strings.Split(string(data), "\n") {
+		if line == "" {
+			current = nil
+			continue
+		}
+		if line[0] != ' ' && line[0] != '\t' {
+			current = nil
+			name, rest, ok := strings.Cut(line, " : ")
+			if !ok || strings.HasPrefix(name, "Personalities") || strings.HasPrefix(name, "unused devices") {
+				continue
+			}
+			arrays = append(arrays, parseMdstatHeader(strings.TrimSpace(name), strings.Fields(rest)))
+			current = &arrays[len(arrays)-1]
+			continue
+		}
+		if current == nil {
+			continue
+		}
+		if match := mdstatCounts.FindStringSubmatch(line); match != nil {
+			current.Devices, _ = strconv.Atoi(match[1])
+			current.ActiveDevices, _ = strconv.Atoi(match[2])
+			current.Status = match[3]
+		}
+		if match := mdstatProgress.FindStringSubmatch(line); match != nil {
+			current.SyncAction = match[1]
+			if percent, err := strconv.ParseFloat(match[2], 64); err == nil {
+				current.SyncPercent = &percent
+			}
+			if finish := mdstatFinish.FindStringSubmatch(line); finish != nil {
+				if minutes, err := strconv.ParseFloat(finish[1], 64); err == nil {
+					seconds := minutes * 60
+					current.SyncETASeconds = &seconds
+				}
+			}
+		} else if match := mdstatWaiting.FindStringSubmatch(line); match != nil {
+			current.SyncAction = match[1]
+		}
+	}
+
+	for i := range arrays {
+		array := &arrays[i]
+		array.Degraded = len(array.FailedMembers) > 0 || strings.Contains(array.Status, "_") ||
+			(array.Devices > 0 && array.ActiveDevices < array.Devices)
+	}
+	return arrays
+}
+
+// parseMdstatHeader reads "active raid1 sdb1[1] sda1[0](F)". The state may
+// carry a "(read-only)" or "(auto-read-only)" suffix, and inactive arrays
+// have no level.
+func parseMdstatHeader(name string, fields []string) RAIDArray {
+	array := RAIDArray{Name: name}
+	if len(fields) == 0 {
+		return array
+	}
+	array.State, fields = fields[0], fields[1:]
+	if len(fields) > 0 && strings.HasPrefix(fields[0], "(") {
+		array.State += " " + fields[0]
+		fields = fields[1:]
+	}
+	for _, field := range fields {
+		match := mdstatMember.FindStringSubmatch(field)
+		if match == nil {
+			if array.Level == "" {
+				array.Level = field
+			}
+			continue
+		}
+		switch {
+		case strings.Contains(match[2], "(F)"):
+			array.FailedMembers = append(array.FailedMembers, match[1])
+		case strings.Contains(match[2], "(S)"):
+			array.SpareMembers = append(array.SpareMembers, match[1])
+		default:
+			array.Members = append(array.Members, match[1])
+		}
+	}
+	return array
+}
+
+// raidSeverity treats a degraded or stopped array as critical: it has no
+// redundancy left, or is not serving data at all.
+func raidSeverity(array RAIDArray) display.Severity {
+	if array.Degraded || strings.HasPrefix(array.State, "inactive") {
+		return display.SeverityCritical
+	}
+	return display.SeverityOK
+}
+
+// raidSyncVerbs describes each md sync action in the banner.
+var raidSyncVerbs = map[string]string{
+	"resync":   "resyncing",
+	"recovery": "rebuilding",
+	"reshape":  "reshaping",
+	"check":    "checking",
+	"repair":   "repairing",
+}
+
+// raidValue renders an array as "raid1 degraded [U_], rebuilding 43%
+// (1 hour, 32 minutes left)".
+func raidValue(array RAIDArray) string {
+	state := "active"
+	switch {
+	case strings.HasPrefix(array.State, "inactive"):
+		state = "inactive"
+	case array.Degraded:
+		state = "degraded"
+	}
+	value := state
+	if array.Level != "" {
+		value = array.Level + " " + state
+	}
+	if array.Status != "" {
+		value += " [" + array.Status + "]"
+	}
+	if array.SyncAction == "" {
+		return value
+	}
+	value += ", " + raidSyncVerbs[array.SyncAction]
+	if array.SyncPercent == nil {
+		return value + " (pending)"
+	}
+	value += " " + strconv.FormatFloat(*array.SyncPercent, 'f', 0, 64) + "%"
+	if array.SyncETASeconds != nil {
+		value += " (" + FormatDuration(time.Duration(*array.SyncETASeconds*float64(time.Second))) + " left)"
+	}
+	return value
+}
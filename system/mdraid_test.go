@@ -0,0 +1,84 @@
+package system
+
+import (
+	"slices"
+	"testing"
+
+	"motd/config"
+	"motd/display"
+)
+
+const mdstatFixture = `Personalities : [raid1] [raid6] [raid5] [raid4] [linear] [multipath] [raid0] [raid10]
+md0 : active raid1 sdb1[1] sda1[0]
+      976630464 blocks super 1.2 [2/2] [UU]
+      bitmap: 0/8 pages [0KB], 65536KB chunk
+
+md1 : active raid5 sde1[3] sdd1[1] sdc1[0]
+      1953260544 blocks super 1.2 level 5, 512k chunk, algorithm 2 [3/2] [UU_]
+      [========>............]  recovery = 43.2% (421888000/976630272) finish=92.1min speed=100288K/sec
+      bitmap: 2/8 pages [8KB], 65536KB chunk
+
+md2 : active raid1 sdg1[1](F) sdf1[0]
+      488253440 blocks super 1.2 [2/1] [U_]
+
+md3 : active (auto-read-only) raid1 sdi1[1] sdh1[0] sdj1[2](S)
+      243069952 blocks super 1.2 [2/2] [UU]
+      	resync=PENDING
+
+md4 : inactive sdk1[0](S)
+      976630488 blocks super 1.2
+
+unused devices: <none>
+`
+
+func TestParseMdstat(t *testing.T) {
+	arrays := parseMdstat([]byte(mdstatFixture))
+	if len(arrays) != 5 {
+		t.Fatalf("expected 5 arrays, got %+v", arrays)
+	}
+
+	md0, md1, md2, md3, md4 := arrays[0], arrays[1], arrays[2], arrays[3], arrays[4]
+	if md0.Name != "md0" || md0.Level != "raid1" || md0.State != "active" || md0.Status != "UU" || md0.Degraded || !slices.Equal(md0.Members, []string{"sdb1", "sda1"}) {
+		t.Fatalf("unexpected md0: %+v", md0)
+	}
+	if !md1.Degraded || md1.Devices != 3 || md1.ActiveDevices != 2 || md1.SyncAction != "recovery" || md1.SyncPercent == nil || *md1.SyncPercent != 43.2 || md1.SyncETASeconds == nil || *md1.SyncETASeconds != 5526 {
+		t.Fatalf("unexpected md1: %+v", md1)
+	}
+	if !md2.Degraded || !slices.Equal(md2.FailedMembers, []string{"sdg1"}) {
+		t.Fatalf("unexpected md2: %+v", md2)
+	}
+	if md3.State != "active (auto-read-only)" || md3.Degraded || md3.SyncAction != "resync" || md3.SyncPercent != nil || !slices.Equal(md3.SpareMembers, []string{"sdj1"}) {
+		t.Fatalf("unexpected md3: %+v", md3)
+	}
+	if md4.State != "inactive" || md4.Level != "" {
+		t.Fatalf("unexpected md4: %+v", md4)
+	}
+}
+
+func TestRAIDArrayLines(t *testing.T) {
+	snap := SystemSnapshot{RAIDArrays: parseMdstat([]byte(mdstatFixture))}
+	snap.Evaluate(config.ThresholdsConfig{})
+
+	want := []struct {
+		line     string
+		severity display.Severity
+	}{
+		{"RAID (md0)=raid1 active [UU]", display.SeverityOK},
+		{"RAID (md1)=raid5 degraded [UU_], rebuilding 43% (1 hour, 32 minutes left)", display.SeverityCritical},
+		{"RAID (md2)=raid1 degraded [U_]", display.SeverityCritical},
+		{"RAID (md3)=raid1 active [UU], resyncing (pending)", display.SeverityOK},
+		{"RAID (md4)=inactive", display.SeverityCritical},
+	}
+	lines := snap.RAIDArrays.Lines()
+	for i, line := range lines {
+		if got := line.Label + "=" + line.Value; got != want[i].line {
+			t.Fatalf("line %d = %q, want %q", i, got, want[i].line)
+		}
+		if snap.RAIDArrays[i].Severity != want[i].severity {
+			t.Fatalf("line %d severity = %q, want %q", i, snap.RAIDArrays[i].Severity, want[i].severity)
+		}
+	}
+	if lines[1].Color != display.Red {
+		t.Fatalf("expected a degraded array in red, got %q", lines[1].Color)
+	}
+}
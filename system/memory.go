@@ -0,0 +1,114 @@
+package system
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// ZRAMDevice is the compression of one zram device from its mm_stat.
+// Ratio is OriginalBytes over CompressedBytes, zero while the device holds
+// no data.
+type ZRAMDevice struct {
+	Name            string  `json:"name"`
+	OriginalBytes   uint64  `json:"original_bytes"`
+	CompressedBytes uint64  `json:"compressed_bytes"`
+	MemUsedBytes    uint64  `json:"mem_used_bytes"`
+	Ratio           float64 `json:"ratio,omitempty"`
+}
+
+// meminfo is the part of /proc/meminfo the memory row uses, in bytes.
+type meminfo struct {
+	total, available, swapTotal, swapFree uint64
+}
+
+// parseMeminfo reads /proc/meminfo, whose values are in kB.
+func parseMeminfo(data []byte) (meminfo, error) {
+	var info meminfo
+	fields := map[string]*uint64{
+		"MemTotal:":     &info.total,
+		"MemAvailable:": &info.available,
+		"SwapTotal:":    &info.swapTotal,
+		"SwapFree:":     &info.swapFree,
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		parts := strings.Fields(line)
+		if len(parts) < 2 {
+			continue
+		}
+		if field, ok := fields[parts[0]]; ok {
+			value, _ := strconv.ParseUint(parts[1], 10, 64)
+			*field = value * KB
+		}
+	}
+	if info.total == 0 {
+		return meminfo{}, fmt.Errorf("MemTotal missing from /proc/meminfo")
+	}
+	info.available = min(info.available, info.total)
+	info.swapFree = min(info.swapFree, info.swapTotal)
+	return info, nil
+}
+
+// parseVmstatOOMKills reads the oom_kill counter from /proc/vmstat, which
+// Linux 4.13 and newer keep since boot.
+func parseVmstatOOMKills(data []byte) (uint64, bool) {
+	for _, line := range strings.Split(string(data), "\n") {
+		name, value, ok := strings.Cut(line, " ")
+		if !ok || name != "oom_kill" {
+			continue
+		}
+		count, err := strconv.ParseUint(strings.TrimSpace(value), 10, 64)
+		return count, err == nil
+	}
+	return 0, false
+}
+
+// scanZRAM reads mm_stat for every zram device under /sys/block, given as
+// fsys. Devices without a disksize have not been set up and are skipped.
+func scanZRAM(fsys fs.FS) []ZRAMDevice {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil
+	}
+	var devices []ZRAMDevice
+	for _, entry := range entries {
+		if !strings.HasPrefix(entry.Name(), "zram") {
+			continue
+		}
+		if size, err := fs.ReadFile(fsys, path.Join(entry.Name(), "disksize")); err != nil || strings.TrimSpace(string(size)) == "0" {
+			continue
+		}
+		data, err := fs.ReadFile(fsys, path.Join(entry.Name(), "mm_stat"))
+		if err != nil {
+			continue
+		}
+		if device, ok := parseZRAMMMStat(entry.Name(), data); ok {
+			devices = append(devices, device)
+		}
+	}
+	return devices
+}
+
+// parseZRAMMMStat reads the first three mm_stat columns: the original and
+// compressed data sizes and the memory the device uses, all in bytes.
+func parseZRAMMMStat(name string, data []byte) (ZRAMDevice, bool) {
+	fields := strings.Fields(string(data))
+	if len(fields) < 3 {
+		return ZRAMDevice{}, false
+	}
+	var values [3]uint64
+	for i := range values {
+		value, err := strconv.ParseUint(fields[i], 10, 64)
+		if err != nil {
+			return ZRAMDevice{}, false
+		}
+		values[i] = value
+	}
+	device := ZRAMDevice{Name: name, OriginalBytes: values[0], CompressedBytes: values[1], MemUsedBytes: values[2]}
+	if device.CompressedBytes > 0 {
+		device.Ratio = float64(device.OriginalBytes) / float64(device.CompressedBytes)
+	}
+	return device, true
+}
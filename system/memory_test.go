@@ -0,0 +1,83 @@
+package system
+
+import (
+	"testing"
+	"testing/fstest"
+)
+
+const meminfoFixture = `MemTotal:        8052284 kB
+MemFree:          412608 kB
+MemAvailable:    5120000 kB
+Buffers:          102400 kB
+SwapCached:        10240 kB
+SwapTotal:       4194300 kB
+SwapFree:        3145724 kB
+`
+
+func TestParseMeminfo(t *testing.T) {
+	info, err := parseMeminfo([]byte(meminfoFixture))
+	if err != nil {
+		t.Fatalf("parseMeminfo failed: %v", err)
+	}
+	if info.total != 8052284*KB || info.available != 5120000*KB {
+		t.Fatalf("unexpected memory: %+v", info)
+	}
+	if info.swapTotal != 4194300*KB || info.swapTotal-info.swapFree != 1048576*KB {
+		t.Fatalf("unexpected swap: %+v", info)
+	}
+	if _, err := parseMeminfo([]byte("SwapTotal: 0 kB\n")); err == nil {
+		t.Fatal("expected error without MemTotal")
+	}
+}
+
+func TestParseVmstatOOMKills(t *testing.T) {
+	kills, ok := parseVmstatOOMKills([]byte("pgfault 123456\noom_kill 3\nunevictable_pgs_culled 0\n"))
+	if !ok || kills != 3 {
+		t.Fatalf("parseVmstatOOMKills = %d, %v; want 3, true", kills, ok)
+	}
+	if _, ok := parseVmstatOOMKills([]byte("pgfault 123456\n")); ok {
+		t.Fatal("expected no count on kernels without oom_kill")
+	}
+}
+
+func TestScanZRAM(t *testing.T) {
+	fsys := fstest.MapFS{
+		"sda/size":          {Data: []byte("1953525168\n")},
+		"zram0/disksize":    {Data: []byte("4294967296\n")},
+		"zram0/mm_stat":     {Data: []byte("1073741824 268435456 285212672        0 301989888     1024       12        0        0\n")},
+		"zram1/disksize":    {Data: []byte("0\n")},
+		"zram1/mm_stat":     {Data: []byte("0 0 0 0 0 0 0 0 0\n")},
+		"zram2/disksize":    {Data: []byte("1073741824\n")},
+		"zram2/mm_stat":     {Data: []byte("0 0 0 0 0 0 0 0 0\n")},
+		"loop0/backing_dev": {Data: []byte("/var/lib/snapd/snaps/core.snap\n")},
+	}
+	devices := scanZRAM(fsys)
+	if len(devices) != 2 {
+		t.Fatalf("expected 2 zram devices, got %+v", devices)
+	}
+	if devices[0].Name != "zram0" || devices[0].Ratio != 4 || devices[0].MemUsedBytes != 285212672 {
+		t.Fatalf("unexpected zram0: %+v", devices[0])
+	}
+	if devices[1].Ratio != 0 {
+		t.Fatalf("expected an empty device to have no ratio, got %+v", devices[1])
+	}
+
+	kills := uint64(3)
+	info := MemoryInfo{TotalBytes: 8 * GB, UsedBytes: 2 * GB, SwapTotalBytes: 4 * GB, SwapUsedBytes: GB, ZRAM: devices, OOMKills: &kills}
+	want := []string{
+		"Memory=2.00 GB / 8.00 GB",
+		"Swap=1.00 GB / 4.00 GB",
+		"ZRAM (zram0)=1.00 GB in 0.27 GB (4.0x)",
+		"ZRAM (zram2)=empty",
+		"OOM Kills=3 since boot",
+	}
+	lines := info.Lines()
+	if len(lines) != len(want) {
+		t.Fatalf("expected %d lines, got %+v", len(want), lines)
+	}
+	for i, line := range lines {
+		if got := line.Label + "=" + line.Value; got != want[i] {
+			t.Fatalf("line %d = %q, want %q", i, got, want[i])
+		}
+	}
+}
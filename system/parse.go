@@ -3,6 +3,8 @@ package system
 import (
 	"encoding/json"
 	"fmt"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -61,35 +63,157 @@ func pickVnstatInterface(data vnstatData, preferred string) (vnstatInterface, bo
 	return data.Interfaces[0], true
 }
 
-func parseVnstatMonthlyUsage(output []byte, preferredInterface string, now time.Time) (rxGB, txGB, rxEst, txEst float64, err error) {
+func parseVnstatMonthlyUsage(output []byte, preferredInterface string, now time.Time) (BandwidthInfo, error) {
 	var parsed vnstatData
 	if err := json.Unmarshal(output, &parsed); err != nil {
-		return 0, 0, 0, 0, err
+		return BandwidthInfo{}, err
 	}
 
 	iface, ok := pickVnstatInterface(parsed, preferredInterface)
 	if !ok || len(iface.Traffic.Month) == 0 {
-		return 0, 0, 0, 0, fmt.Errorf("no vnstat interface/monthly data available")
+		return BandwidthInfo{}, fmt.Errorf("no vnstat interface/monthly data available")
 	}
+	return vnstatMonthlyUsage(iface, now)
+}
+
+// parseVnstatInterfaceUsage returns the month-to-date usage of each named
+// interface that vnstat has monthly data for. Unlike
+// parseVnstatMonthlyUsage it never substitutes another interface.
+func parseVnstatInterfaceUsage(output []byte, names []string, now time.Time) (map[string]BandwidthInfo, error) {
+	var parsed vnstatData
+	if err := json.Unmarshal(output, &parsed); err != nil {
+		return nil, err
+	}
+
+	usage := make(map[string]BandwidthInfo, len(names))
+	for _, iface := range parsed.Interfaces {
+		if !slices.Contains(names, iface.ID) {
+			continue
+		}
+		if info, err := vnstatMonthlyUsage(iface, now); err == nil {
+			usage[iface.ID] = info
+		}
+	}
+	return usage, nil
+}
 
+func vnstatMonthlyUsage(iface vnstatInterface, now time.Time) (BandwidthInfo, error) {
 	month, ok := pickLatestVnstatMonth(iface.Traffic.Month, now)
 	if !ok {
-		return 0, 0, 0, 0, fmt.Errorf("no vnstat monthly entry available")
+		return BandwidthInfo{}, fmt.Errorf("no vnstat monthly entry available")
 	}
 
-	rxGB = float64(month.Rx) / float64(GB)
-	txGB = float64(month.Tx) / float64(GB)
+	info := monthlyBandwidth(iface.ID, month.Rx, month.Tx, now)
+	info.Source = BandwidthVnstat
+	return info, nil
+}
 
+// monthlyBandwidth projects month-to-date counters to the end of the month.
+func monthlyBandwidth(interfaceName string, rxBytes, txBytes uint64, now time.Time) BandwidthInfo {
 	day := float64(now.Day())
 	if day < 1 {
 		day = 1
 	}
+	scale := float64(daysInMonth(now)) / day
+
+	return BandwidthInfo{
+		Interface:       interfaceName,
+		RxBytes:         rxBytes,
+		TxBytes:         txBytes,
+		RxEstimateBytes: uint64(float64(rxBytes) * scale),
+		TxEstimateBytes: uint64(float64(txBytes) * scale),
+	}
+}
 
-	daysInMonth := float64(daysInMonth(now))
-	rxEst = rxGB * (daysInMonth / day)
-	txEst = txGB * (daysInMonth / day)
+// parseLoadAverages parses the first three fields of /proc/loadavg or
+// `sysctl -n vm.loadavg` into 1, 5 and 15 minute averages.
+func parseLoadAverages(fields []string) ([]float64, error) {
+	if len(fields) < 3 {
+		return nil, fmt.Errorf("expected 3 load averages, got %d fields", len(fields))
+	}
+	averages := make([]float64, 0, 3)
+	for _, field := range fields[:3] {
+		value, err := strconv.ParseFloat(field, 64)
+		if err != nil {
+			return nil, err
+		}
+		averages = append(averages, value)
+	}
+	return averages, nil
+}
+
+// cpuTimes is the aggregate "cpu" line of /proc/stat in clock ticks.
+type cpuTimes struct {
+	total, idle, iowait, steal uint64
+}
 
-	return rxGB, txGB, rxEst, txEst, nil
+// parseProcStatCPU reads the aggregate "cpu" line of /proc/stat. The guest
+// columns are already counted in user and nice, so the total stops at steal.
+func parseProcStatCPU(data []byte) (cpuTimes, error) {
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || fields[0] != "cpu" {
+			continue
+		}
+		if len(fields) < 5 {
+			return cpuTimes{}, fmt.Errorf("short /proc/stat cpu line %q", line)
+		}
+		var values [8]uint64
+		for i := range values {
+			if i+1 >= len(fields) {
+				break
+			}
+			value, err := strconv.ParseUint(fields[i+1], 10, 64)
+			if err != nil {
+				return cpuTimes{}, err
+			}
+			values[i] = value
+		}
+		var times cpuTimes
+		for _, value := range values {
+			times.total += value
+		}
+		times.idle, times.iowait, times.steal = values[3], values[4], values[7]
+		return times, nil
+	}
+	return cpuTimes{}, fmt.Errorf("no cpu line in /proc/stat")
+}
+
+// countProcStatCPUs counts the per-CPU "cpuN" lines of /proc/stat, which
+// list every online CPU whatever the process affinity or cgroup quota.
+func countProcStatCPUs(data []byte) int {
+	count := 0
+	for _, line := range strings.Split(string(data), "\n") {
+		name, _, _ := strings.Cut(line, " ")
+		if digits, ok := strings.CutPrefix(name, "cpu"); ok && digits != "" {
+			if _, err := strconv.Atoi(digits); err == nil {
+				count++
+			}
+		}
+	}
+	return count
+}
+
+// cpuUtilization returns the busy, iowait and steal percentages between two
+// /proc/stat samples. Busy time excludes idle and iowait.
+func cpuUtilization(before, after cpuTimes) (busy, iowait, steal float64, ok bool) {
+	if after.total <= before.total {
+		return 0, 0, 0, false
+	}
+	total := float64(after.total - before.total)
+	idle := float64((after.idle - before.idle) + (after.iowait - before.iowait))
+	busy = max(0, (total-idle)/total*100)
+	iowait = float64(after.iowait-before.iowait) / total * 100
+	steal = float64(after.steal-before.steal) / total * 100
+	return busy, iowait, steal, true
+}
+
+func formatLoadAverages(averages []float64) string {
+	parts := make([]string, 0, len(averages))
+	for _, value := range averages {
+		parts = append(parts, strconv.FormatFloat(value, 'f', 2, 64))
+	}
+	return strings.Join(parts, ", ")
 }
 
 func countUniqueWhoUsers(output []byte) int {
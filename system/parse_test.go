@@ -35,19 +35,19 @@ func TestParseVnstatMonthlyUsage(t *testing.T) {
 		]
 	}`)
 
-	rxGB, txGB, rxEst, txEst, err := parseVnstatMonthlyUsage(payload, "eth0", now)
+	usage, err := parseVnstatMonthlyUsage(payload, "eth0", now)
 	if err != nil {
 		t.Fatalf("parseVnstatMonthlyUsage failed: %v", err)
 	}
 
-	if rxGB != 1.0 || txGB != 2.0 {
-		t.Fatalf("unexpected usage values rx=%.2f tx=%.2f", rxGB, txGB)
+	if usage.Interface != "eth0" || usage.RxBytes != GB || usage.TxBytes != 2*GB {
+		t.Fatalf("unexpected usage values: %+v", usage)
 	}
 
-	expectedRxEst := 1.0 * (30.0 / 10.0)
-	expectedTxEst := 2.0 * (30.0 / 10.0)
-	if rxEst != expectedRxEst || txEst != expectedTxEst {
-		t.Fatalf("unexpected estimates rx=%.2f tx=%.2f", rxEst, txEst)
+	expectedRxEst := uint64(GB * (30.0 / 10.0))
+	expectedTxEst := uint64(2 * GB * (30.0 / 10.0))
+	if usage.RxEstimateBytes != expectedRxEst || usage.TxEstimateBytes != expectedTxEst {
+		t.Fatalf("unexpected estimates rx=%d tx=%d", usage.RxEstimateBytes, usage.TxEstimateBytes)
 	}
 }
 
@@ -60,20 +60,39 @@ func TestParseVnstatMonthlyUsage_InterfaceSelection(t *testing.T) {
 		]
 	}`)
 
-	rxGB, txGB, _, _, err := parseVnstatMonthlyUsage(payload, "wlan0", now)
+	usage, err := parseVnstatMonthlyUsage(payload, "wlan0", now)
 	if err != nil {
 		t.Fatalf("parseVnstatMonthlyUsage failed: %v", err)
 	}
-	if rxGB != 2.0 || txGB != 2.0 {
-		t.Fatalf("expected preferred wlan0 values, got rx=%.2f tx=%.2f", rxGB, txGB)
+	if usage.RxBytes != 2*GB || usage.TxBytes != 2*GB {
+		t.Fatalf("expected preferred wlan0 values, got %+v", usage)
 	}
 
-	_, _, _, _, err = parseVnstatMonthlyUsage([]byte(`{"interfaces":[{"id":"eth0","traffic":{"month":[]}}]}`), "", now)
+	_, err = parseVnstatMonthlyUsage([]byte(`{"interfaces":[{"id":"eth0","traffic":{"month":[]}}]}`), "", now)
 	if err == nil {
 		t.Fatal("expected error when no monthly data is available")
 	}
 }
 
+func TestParseVnstatInterfaceUsage(t *testing.T) {
+	now := time.Date(2026, time.April, 10, 12, 0, 0, 0, time.UTC)
+	payload := []byte(`{
+		"interfaces":[
+			{"id":"eth0","traffic":{"month":[{"rx":1073741824,"tx":1073741824,"date":{"year":2026,"month":4}}]}},
+			{"id":"eth1","traffic":{"month":[]}},
+			{"id":"wlan0","traffic":{"month":[{"rx":2147483648,"tx":2147483648,"date":{"year":2026,"month":4}}]}}
+		]
+	}`)
+
+	usage, err := parseVnstatInterfaceUsage(payload, []string{"eth0", "eth1", "bond0"}, now)
+	if err != nil {
+		t.Fatalf("parseVnstatInterfaceUsage failed: %v", err)
+	}
+	if len(usage) != 1 || usage["eth0"].RxBytes != GB || usage["eth0"].Source != BandwidthVnstat {
+		t.Fatalf("expected only eth0 usage, got %+v", usage)
+	}
+}
+
 func TestCountUniqueWhoUsers(t *testing.T) {
 	output := []byte("alice pts/0 2026-04-30 10:00\nbob pts/1 2026-04-30 10:01\nalice pts/2 2026-04-30 10:02\n")
 	if got := countUniqueWhoUsers(output); got != 2 {
@@ -130,3 +149,51 @@ func TestFormatDuration_ExactDay(t *testing.T) {
 		t.Fatalf("expected '2 days', got %q", got)
 	}
 }
+
+func TestParseLoadAverages(t *testing.T) {
+	averages, err := parseLoadAverages([]string{"0.52", "0.48", "1.40", "2/345", "6789"})
+	if err != nil {
+		t.Fatalf("parseLoadAverages failed: %v", err)
+	}
+	if got := formatLoadAverages(averages); got != "0.52, 0.48, 1.40" {
+		t.Fatalf("unexpected load averages: %q", got)
+	}
+	if _, err := parseLoadAverages([]string{"0.52"}); err == nil {
+		t.Fatal("expected error for truncated load averages")
+	}
+}
+
+func TestCPUUtilizationFromProcStat(t *testing.T) {
+	before, err := parseProcStatCPU([]byte("cpu  1000 50 400 8000 200 10 40 100 30 0\ncpu0 500 25 200 4000 100 5 20 50 15 0\nintr 12345\n"))
+	if err != nil {
+		t.Fatalf("parseProcStatCPU failed: %v", err)
+	}
+	after, err := parseProcStatCPU([]byte("cpu  1200 50 450 8600 300 10 40 150 40 0\ncpu0 600 25 225 4300 150 5 20 75 20 0\n"))
+	if err != nil {
+		t.Fatalf("parseProcStatCPU failed: %v", err)
+	}
+	busy, iowait, steal, ok := cpuUtilization(before, after)
+	if !ok {
+		t.Fatal("expected utilization between two samples")
+	}
+	// 1000 ticks elapsed: 200 user, 50 system, 600 idle, 100 iowait, 50 steal.
+	if busy != 30 || iowait != 10 || steal != 5 {
+		t.Fatalf("utilization = %v busy, %v iowait, %v steal; want 30, 10, 5", busy, iowait, steal)
+	}
+	if _, _, _, ok := cpuUtilization(after, after); ok {
+		t.Fatal("expected no utilization without elapsed ticks")
+	}
+	if _, err := parseProcStatCPU([]byte("intr 12345\n")); err == nil {
+		t.Fatal("expected error without a cpu line")
+	}
+}
+
+func TestCountProcStatCPUs(t *testing.T) {
+	data := "cpu  1000 50 400 8000 200 10 40 100 30 0\ncpu0 500 25 200 4000 100 5 20 50 15 0\ncpu1 500 25 200 4000 100 5 20 50 15 0\ncpu12 1 0 0 1 0 0 0 0 0 0\nintr 12345\nctxt 998877\n"
+	if got := countProcStatCPUs([]byte(data)); got != 3 {
+		t.Fatalf("expected 3 CPUs, got %d", got)
+	}
+	if got := countProcStatCPUs([]byte("intr 12345\n")); got != 0 {
+		t.Fatalf("expected no CPUs, got %d", got)
+	}
+}
@@ -0,0 +1,122 @@
+package system
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"motd/display"
+)
+
+const pressurePath = "/proc/pressure"
+
+// PressureInfo is the Linux pressure stall information for CPU, memory and
+// I/O. A resource is nil when the kernel does not report it.
+type PressureInfo struct {
+	CPU      *PressureStall   `json:"cpu,omitempty"`
+	Memory   *PressureStall   `json:"memory,omitempty"`
+	IO       *PressureStall   `json:"io,omitempty"`
+	Severity display.Severity `json:"severity,omitempty"`
+}
+
+// PressureStall is one /proc/pressure file. Some is the share of time at
+// least one task was stalled on the resource, Full the share all non-idle
+// tasks were; Full is nil for CPU on kernels before 5.13.
+type PressureStall struct {
+	Some     PressureAverages  `json:"some"`
+	Full     *PressureAverages `json:"full,omitempty"`
+	Severity display.Severity  `json:"severity,omitempty"`
+}
+
+// PressureAverages are the 10 second, 1 minute and 5 minute stall
+// percentages.
+type PressureAverages struct {
+	Avg10  float64 `json:"avg10"`
+	Avg60  float64 `json:"avg60"`
+	Avg300 float64 `json:"avg300"`
+}
+
+// PressureResource names one reported resource.
+type PressureResource struct {
+	Name  string
+	Stall *PressureStall
+}
+
+// Resources returns the reported resources in display order.
+func (p *PressureInfo) Resources() []PressureResource {
+	var resources []PressureResource
+	for _, resource := range []PressureResource{{"cpu", p.CPU}, {"memory", p.Memory}, {"io", p.IO}} {
+		if resource.Stall != nil {
+			resources = append(resources, resource)
+		}
+	}
+	return resources
+}
+
+// pressureEnabled skips the collector on kernels built without PSI.
+func pressureEnabled(cfg ConfigAccessor) bool {
+	_, err := os.Stat(pressurePath)
+	return err == nil
+}
+
+func readPressure(ctx context.Context, cfg ConfigAccessor) (PressureInfo, error) {
+	var info PressureInfo
+	for name, target := range map[string]**PressureStall{"cpu": &info.CPU, "memory": &info.Memory, "io": &info.IO} {
+		data, err := os.ReadFile(filepath.Join(pressurePath, name))
+		if err != nil {
+			continue
+		}
+		if stall, err := parsePressure(data); err == nil {
+			*target = &stall
+		}
+	}
+	if info.CPU == nil && info.Memory == nil && info.IO == nil {
+		return PressureInfo{}, errors.New("no pressure stall information available")
+	}
+	return info, nil
+}
+
+// parsePressure parses a /proc/pressure file:
+//
+//	some avg10=1.23 avg60=0.87 avg300=0.41 total=123456
+//	full avg10=0.00 avg60=0.00 avg300=0.00 total=0
+func parsePressure(data []byte) (PressureStall, error) {
+	var stall PressureStall
+	found := false
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || (fields[0] != "some" && fields[0] != "full") {
+			continue
+		}
+		var averages PressureAverages
+		for _, field := range fields[1:] {
+			key, value, ok := strings.Cut(field, "=")
+			if !ok {
+				continue
+			}
+			target := map[string]*float64{"avg10": &averages.Avg10, "avg60": &averages.Avg60, "avg300": &averages.Avg300}[key]
+			if target == nil {
+				continue
+			}
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return PressureStall{}, fmt.Errorf("invalid pressure %s: %w", field, err)
+			}
+			*target = parsed
+		}
+		if fields[0] == "some" {
+			stall.Some = averages
+			found = true
+		} else {
+			stall.Full = &averages
+		}
+	}
+	if !found {
+		return PressureStall{}, errors.New("no some line in pressure file")
+	}
+	return stall, nil
+}
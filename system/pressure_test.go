@@ -0,0 +1,52 @@
+package system
+
+import (
+	"testing"
+
+	"motd/config"
+	"motd/display"
+)
+
+func TestParsePressure(t *testing.T) {
+	stall, err := parsePressure([]byte("some avg10=12.50 avg60=4.20 avg300=1.05 total=987654321\nfull avg10=3.10 avg60=0.90 avg300=0.22 total=123456789\n"))
+	if err != nil {
+		t.Fatalf("parsePressure failed: %v", err)
+	}
+	if stall.Some != (PressureAverages{Avg10: 12.5, Avg60: 4.2, Avg300: 1.05}) {
+		t.Fatalf("unexpected some: %+v", stall.Some)
+	}
+	if stall.Full == nil || stall.Full.Avg10 != 3.1 {
+		t.Fatalf("unexpected full: %+v", stall.Full)
+	}
+
+	cpu, err := parsePressure([]byte("some avg10=0.75 avg60=0.50 avg300=0.25 total=4242\n"))
+	if err != nil || cpu.Full != nil {
+		t.Fatalf("expected an older kernel's cpu file without full, got %+v, %v", cpu, err)
+	}
+	if _, err := parsePressure([]byte("")); err == nil {
+		t.Fatal("expected error without a some line")
+	}
+	if _, err := parsePressure([]byte("some avg10=x avg60=0 avg300=0 total=0\n")); err == nil {
+		t.Fatal("expected error for an unparsable average")
+	}
+}
+
+func TestPressureLinesAndSeverity(t *testing.T) {
+	snap := SystemSnapshot{Pressure: &PressureInfo{
+		CPU:    &PressureStall{Some: PressureAverages{Avg10: 2.14}, Full: &PressureAverages{}},
+		Memory: &PressureStall{Some: PressureAverages{Avg10: 0}, Full: &PressureAverages{Avg10: 0}},
+		IO:     &PressureStall{Some: PressureAverages{Avg10: 12.5}, Full: &PressureAverages{Avg10: 3.1}},
+	}}
+	snap.Evaluate(config.ThresholdsConfig{})
+
+	line := snap.Pressure.Lines()[0]
+	if got, want := line.Label+"="+line.Value, "Pressure (avg10)=cpu 2.1%, memory 0.0%/0.0%, io 12.5%/3.1%"; got != want {
+		t.Fatalf("line = %q, want %q", got, want)
+	}
+	if snap.Pressure.IO.Severity != display.SeverityWarning || snap.Pressure.CPU.Severity != display.SeverityOK {
+		t.Fatalf("unexpected resource severities: cpu %q, io %q", snap.Pressure.CPU.Severity, snap.Pressure.IO.Severity)
+	}
+	if snap.Pressure.Severity != display.SeverityWarning {
+		t.Fatalf("expected the worst resource to set the severity, got %q", snap.Pressure.Severity)
+	}
+}
@@ -0,0 +1,309 @@
+package system
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"motd/display"
+	"motd/util"
+)
+
+// SMARTDriveList is every drive `smartctl --scan` finds, in scan order.
+type SMARTDriveList []SMARTDrive
+
+// SMARTDrive is the health smartctl reports for one drive. Counters the
+// drive does not report are nil: ATA drives have reallocated and pending
+// sectors, NVMe drives a percentage used. Standby drives are not woken, so
+// they carry no readings; Error is set for drives smartctl could not open.
+type SMARTDrive struct {
+	Device             string           `json:"device"`
+	Model              string           `json:"model,omitempty"`
+	Serial             string           `json:"serial,omitempty"`
+	Passed             *bool            `json:"passed,omitempty"`
+	ReallocatedSectors *uint64          `json:"reallocated_sectors,omitempty"`
+	PendingSectors     *uint64          `json:"pending_sectors,omitempty"`
+	PercentageUsed     *float64         `json:"percentage_used,omitempty"`
+	PowerOnHours       *uint64          `json:"power_on_hours,omitempty"`
+	Standby            bool             `json:"standby,omitempty"`
+	Error              string           `json:"error,omitempty"`
+	Severity           display.Severity `json:"severity,omitempty"`
+	WearSeverity       display.Severity `json:"wear_severity,omitempty"`
+}
+
+// smartctl exit status bits: the command line did not parse, or the device
+// could not be opened or was in standby.
+const (
+	smartctlExitUsage = 1 << 0
+	smartctlExitOpen  = 1 << 1
+)
+
+// ATA attribute IDs.
+const (
+	smartReallocatedSectors = 5
+	smartPendingSectors     = 197
+)
+
+// smartEnabled skips the collector on hosts without smartmontools.
+func smartEnabled(cfg ConfigAccessor) bool {
+	return util.HasCommand("smartctl")
+}
+
+func readSMARTDrives(ctx context.Context, cfg ConfigAccessor) (SMARTDriveList, error) {
+	cmd, err := util.SafeCommandContext(ctx, "smartctl", "--scan", "--json")
+	if err != nil {
+		return nil, err
+	}
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("smartctl --scan failed: %w", err)
+	}
+	devices, err := parseSmartctlScan(output)
+	if err != nil {
+		return nil, err
+	}
+	if len(devices) == 0 {
+		return nil, errors.New("smartctl found no drives")
+	}
+
+	drives := make(SMARTDriveList, 0, len(devices))
+	readable := false
+	var firstErr error
+	for _, device := range devices {
+		drive, err := readSMARTDrive(ctx, device)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			if firstErr == nil {
+				firstErr = err
+			}
+			drive = SMARTDrive{Device: device.display, Error: err.Error()}
+		} else {
+			readable = true
+		}
+		drives = append(drives, drive)
+	}
+	if !readable {
+		return nil, fmt.Errorf("smartctl could not read any drive: %w", firstErr)
+	}
+	return drives, nil
+}
+
+// readSMARTDrive runs `smartctl --json -a` for one drive without waking it
+// from standby. smartctl sets exit status bits for failing drives too, so
+// its output is parsed whatever the exit code.
+func readSMARTDrive(ctx context.Context, device smartctlDevice) (SMARTDrive, error) {
+	cmd, err := util.SafeCommandContext(ctx, "smartctl", "--json", "-a", "-n", "standby", "-d", device.kind, device.name)
+	if err != nil {
+		return SMARTDrive{}, err
+	}
+	output, err := cmd.Output()
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		return SMARTDrive{}, err
+	}
+	drive, err := parseSmartctl(output)
+	if err != nil {
+		return SMARTDrive{}, fmt.Errorf("%s: %w", device.display, err)
+	}
+	drive.Device = device.display
+	return drive, nil
+}
+
+// smartctlDevice is one `smartctl --scan` entry. display is the device name
+// without /dev/, with the type added when several entries share a device,
+// as drives behind a RAID controller do.
+type smartctlDevice struct {
+	name    string
+	kind    string
+	display string
+}
+
+// parseSmartctlScan parses `smartctl --scan --json`.
+func parseSmartctlScan(output []byte) ([]smartctlDevice, error) {
+	var scan struct {
+		Devices []struct {
+			Name string `json:"name"`
+			Type string `json:"type"`
+		} `json:"devices"`
+	}
+	if err := json.Unmarshal(output, &scan); err != nil {
+		return nil, fmt.Errorf("failed to parse smartctl scan: %w", err)
+	}
+	counts := make(map[string]int)
+	for _, device := range scan.Devices {
+		counts[device.Name]++
+	}
+	devices := make([]smartctlDevice, 0, len(scan.Devices))
+	for _, device := range scan.Devices {
+		entry := smartctlDevice{name: device.Name, kind: device.Type, display: strings.TrimPrefix(device.Name, "/dev/")}
+		if entry.kind == "" {
+			entry.kind = "auto"
+		}
+		if counts[device.Name] > 1 {
+			entry.display += " (" + device.Type + ")"
+		}
+		devices = append(devices, entry)
+	}
+	return devices, nil
+}
+
+// smartctlOutput is the part of `smartctl --json -a` the banner uses.
+type smartctlOutput struct {
+	Smartctl struct {
+		ExitStatus int `json:"exit_status"`
+		Messages   []struct {
+			String string `json:"string"`
+		} `json:"messages"`
+	} `json:"smartctl"`
+	Device struct {
+		Name string `json:"name"`
+	} `json:"device"`
+	ModelName    string `json:"model_name"`
+	SerialNumber string `json:"serial_number"`
+	SmartStatus  *struct {
+		Passed bool `json:"passed"`
+	} `json:"smart_status"`
+	PowerOnTime *struct {
+		Hours uint64 `json:"hours"`
+	} `json:"power_on_time"`
+	ATASmartAttributes *struct {
+		Table []struct {
+			ID  int `json:"id"`
+			Raw struct {
+				Value uint64 `json:"value"`
+			} `json:"raw"`
+		} `json:"table"`
+	} `json:"ata_smart_attributes"`
+	NVMeHealth *struct {
+		PercentageUsed *float64 `json:"percentage_used"`
+	} `json:"nvme_smart_health_information_log"`
+}
+
+// parseSmartctl parses `smartctl --json -a` for one drive. A drive in
+// standby is reported as such; any other failure to open the drive is an
+// error carrying smartctl's own message.
+func parseSmartctl(output []byte) (SMARTDrive, error) {
+	var out smartctlOutput
+	if err := json.Unmarshal(output, &out); err != nil {
+		return SMARTDrive{}, fmt.Errorf("failed to parse smartctl output: %w", err)
+	}
+	drive := SMARTDrive{
+		Device: strings.TrimPrefix(out.Device.Name, "/dev/"),
+		Model:  out.ModelName,
+		Serial: out.SerialNumber,
+	}
+	if out.Smartctl.ExitStatus&(smartctlExitUsage|smartctlExitOpen) != 0 {
+		var messages []string
+		for _, message := range out.Smartctl.Messages {
+			if strings.Contains(strings.ToUpper(message.String), "STANDBY") {
+				drive.Standby = true
+				return drive, nil
+			}
+			messages = append(messages, message.String)
+		}
+		if len(messages) == 0 {
+			return SMARTDrive{}, fmt.Errorf("smartctl exit status %d", out.Smartctl.ExitStatus)
+		}
+		return SMARTDrive{}, errors.New(strings.Join(messages, "; "))
+	}
+
+	if out.SmartStatus != nil {
+		passed := out.SmartStatus.Passed
+		drive.Passed = &passed
+	}
+	if out.PowerOnTime != nil {
+		hours := out.PowerOnTime.Hours
+		drive.PowerOnHours = &hours
+	}
+	if out.ATASmartAttributes != nil {
+		for _, attribute := range out.ATASmartAttributes.Table {
+			value := attribute.Raw.Value
+			switch attribute.ID {
+			case smartReallocatedSectors:
+				drive.ReallocatedSectors = &value
+			case smartPendingSectors:
+				drive.PendingSectors = &value
+			}
+		}
+	}
+	if out.NVMeHealth != nil {
+		drive.PercentageUsed = out.NVMeHealth.PercentageUsed
+	}
+	return drive, nil
+}
+
+// smartProblems lists what is wrong with a drive, such as "12 pending
+// sectors".
+func smartProblems(drive SMARTDrive) []string {
+	var problems []string
+	if drive.Error != "" {
+		problems = append(problems, "unreadable")
+	}
+	if drive.Passed != nil && !*drive.Passed {
+		problems = append(problems, "SMART failed")
+	}
+	if drive.PendingSectors != nil && *drive.PendingSectors > 0 {
+		problems = append(problems, fmt.Sprintf("%d pending sector%s", *drive.PendingSectors, util.PluralSuffix(int(*drive.PendingSectors))))
+	}
+	if drive.ReallocatedSectors != nil && *drive.ReallocatedSectors > 0 {
+		problems = append(problems, fmt.Sprintf("%d reallocated sector%s", *drive.ReallocatedSectors, util.PluralSuffix(int(*drive.ReallocatedSectors))))
+	}
+	if drive.PercentageUsed != nil && (drive.WearSeverity == display.SeverityWarning || drive.WearSeverity == display.SeverityCritical) {
+		problems = append(problems, fmt.Sprintf("%.0f%% worn", *drive.PercentageUsed))
+	}
+	return problems
+}
+
+// smartSeverity is critical for a drive that fails its overall assessment
+// and a warning for one with pending or reallocated sectors or that could
+// not be read, or worse if its NVMe wear is.
+func smartSeverity(drive SMARTDrive) display.Severity {
+	switch {
+	case drive.Passed != nil && !*drive.Passed:
+		return display.SeverityCritical
+	case drive.Error != "",
+		drive.PendingSectors != nil && *drive.PendingSectors > 0,
+		drive.ReallocatedSectors != nil && *drive.ReallocatedSectors > 0:
+		return display.WorstSeverity(display.SeverityWarning, drive.WearSeverity)
+	}
+	return display.WorstSeverity(display.SeverityOK, drive.WearSeverity)
+}
+
+// Summary renders the drives as "6 drives OK", or lists the drives that
+// need attention, such as "sdc: 12 pending sectors".
+func (d SMARTDriveList) Summary() string {
+	var problems []string
+	for _, drive := range d {
+		if details := smartProblems(drive); len(details) > 0 {
+			problems = append(problems, drive.Device+": "+strings.Join(details, ", "))
+		}
+	}
+	if len(problems) > 0 {
+		return strings.Join(problems, "; ")
+	}
+	summary := fmt.Sprintf("%d drive%s OK", len(d), util.PluralSuffix(len(d)))
+	standby := 0
+	for _, drive := range d {
+		if drive.Standby {
+			standby++
+		}
+	}
+	if standby > 0 {
+		summary += fmt.Sprintf(" (%d in standby)", standby)
+	}
+	return summary
+}
+
+// Severity is the worst severity of any drive.
+func (d SMARTDriveList) Severity() display.Severity {
+	severities := make([]display.Severity, 0, len(d))
+	for _, drive := range d {
+		severities = append(severities, drive.Severity)
+	}
+	return display.WorstSeverity(severities...)
+}
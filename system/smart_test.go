@@ -0,0 +1,177 @@
+package system
+
+import (
+	"testing"
+
+	"motd/config"
+	"motd/display"
+)
+
+const smartctlScanFixture = `{
+  "json_format_version": [1, 0],
+  "smartctl": {"version": [7, 4], "exit_status": 0},
+  "devices": [
+    {"name": "/dev/sda", "info_name": "/dev/sda [SAT]", "type": "sat", "protocol": "ATA"},
+    {"name": "/dev/nvme0", "info_name": "/dev/nvme0", "type": "nvme", "protocol": "NVMe"},
+    {"name": "/dev/bus/0", "info_name": "/dev/bus/0 [megaraid_disk_00]", "type": "megaraid,0", "protocol": "SCSI"},
+    {"name": "/dev/bus/0", "info_name": "/dev/bus/0 [megaraid_disk_01]", "type": "megaraid,1", "protocol": "SCSI"}
+  ]
+}`
+
+const smartctlATAFixture = `{
+  "smartctl": {"version": [7, 4], "exit_status": 0},
+  "device": {"name": "/dev/sdc", "info_name": "/dev/sdc [SAT]", "type": "sat", "protocol": "ATA"},
+  "model_name": "WDC WD80EFZZ-68BTXN0",
+  "serial_number": "WD-CA1B2C3D",
+  "smart_status": {"passed": true},
+  "ata_smart_attributes": {
+    "revision": 16,
+    "table": [
+      {"id": 1, "name": "Raw_Read_Error_Rate", "value": 200, "raw": {"value": 0, "string": "0"}},
+      {"id": 5, "name": "Reallocated_Sector_Ct", "value": 200, "raw": {"value": 3, "string": "3"}},
+      {"id": 9, "name": "Power_On_Hours", "value": 71, "raw": {"value": 21412, "string": "21412"}},
+      {"id": 197, "name": "Current_Pending_Sector", "value": 200, "raw": {"value": 12, "string": "12"}},
+      {"id": 198, "name": "Offline_Uncorrectable", "value": 100, "raw": {"value": 0, "string": "0"}}
+    ]
+  },
+  "power_on_time": {"hours": 21412},
+  "temperature": {"current": 34}
+}`
+
+const smartctlNVMeFixture = `{
+  "smartctl": {"version": [7, 4], "exit_status": 0},
+  "device": {"name": "/dev/nvme0", "info_name": "/dev/nvme0", "type": "nvme", "protocol": "NVMe"},
+  "model_name": "Samsung SSD 980 PRO 1TB",
+  "serial_number": "S5GXNF0R123456",
+  "smart_status": {"passed": true, "nvme": {"value": 0}},
+  "nvme_smart_health_information_log": {
+    "critical_warning": 0,
+    "temperature": 41,
+    "available_spare": 100,
+    "percentage_used": 91,
+    "media_errors": 0
+  },
+  "power_on_time": {"hours": 9120}
+}`
+
+const smartctlFailedFixture = `{
+  "smartctl": {"version": [7, 4], "exit_status": 8},
+  "device": {"name": "/dev/sdd", "type": "sat", "protocol": "ATA"},
+  "model_name": "ST4000DM004-2CV104",
+  "smart_status": {"passed": false},
+  "power_on_time": {"hours": 40211}
+}`
+
+const smartctlStandbyFixture = `{
+  "smartctl": {
+    "version": [7, 4],
+    "messages": [{"string": "Device is in STANDBY mode, exit(2)", "severity": "information"}],
+    "exit_status": 2
+  },
+  "device": {"name": "/dev/sde", "type": "sat", "protocol": "ATA"}
+}`
+
+const smartctlPermissionFixture = `{
+  "smartctl": {
+    "version": [7, 4],
+    "messages": [{"string": "Smartctl open device: /dev/sda failed: Permission denied", "severity": "error"}],
+    "exit_status": 2
+  }
+}`
+
+func TestParseSmartctlScan(t *testing.T) {
+	devices, err := parseSmartctlScan([]byte(smartctlScanFixture))
+	if err != nil {
+		t.Fatalf("parseSmartctlScan failed: %v", err)
+	}
+	want := []smartctlDevice{
+		{name: "/dev/sda", kind: "sat", display: "sda"},
+		{name: "/dev/nvme0", kind: "nvme", display: "nvme0"},
+		{name: "/dev/bus/0", kind: "megaraid,0", display: "bus/0 (megaraid,0)"},
+		{name: "/dev/bus/0", kind: "megaraid,1", display: "bus/0 (megaraid,1)"},
+	}
+	if len(devices) != len(want) {
+		t.Fatalf("expected %d devices, got %+v", len(want), devices)
+	}
+	for i := range want {
+		if devices[i] != want[i] {
+			t.Fatalf("device %d = %+v, want %+v", i, devices[i], want[i])
+		}
+	}
+}
+
+func TestParseSmartctl(t *testing.T) {
+	ata, err := parseSmartctl([]byte(smartctlATAFixture))
+	if err != nil {
+		t.Fatalf("parseSmartctl failed: %v", err)
+	}
+	if ata.Device != "sdc" || ata.Model != "WDC WD80EFZZ-68BTXN0" || ata.Passed == nil || !*ata.Passed {
+		t.Fatalf("unexpected ATA drive: %+v", ata)
+	}
+	if *ata.ReallocatedSectors != 3 || *ata.PendingSectors != 12 || *ata.PowerOnHours != 21412 || ata.PercentageUsed != nil {
+		t.Fatalf("unexpected ATA counters: %+v", ata)
+	}
+
+	nvme, err := parseSmartctl([]byte(smartctlNVMeFixture))
+	if err != nil {
+		t.Fatalf("parseSmartctl failed: %v", err)
+	}
+	if nvme.PercentageUsed == nil || *nvme.PercentageUsed != 91 || nvme.PendingSectors != nil || *nvme.PowerOnHours != 9120 {
+		t.Fatalf("unexpected NVMe drive: %+v", nvme)
+	}
+
+	failed, err := parseSmartctl([]byte(smartctlFailedFixture))
+	if err != nil {
+		t.Fatalf("expected a failing drive to parse, got %v", err)
+	}
+	if failed.Passed == nil || *failed.Passed {
+		t.Fatalf("expected a failed assessment, got %+v", failed)
+	}
+
+	standby, err := parseSmartctl([]byte(smartctlStandbyFixture))
+	if err != nil || !standby.Standby || standby.Passed != nil {
+		t.Fatalf("expected a standby drive without readings, got %+v, %v", standby, err)
+	}
+
+	if _, err := parseSmartctl([]byte(smartctlPermissionFixture)); err == nil || err.Error() != "Smartctl open device: /dev/sda failed: Permission denied" {
+		t.Fatalf("expected smartctl's message as the error, got %v", err)
+	}
+}
+
+func TestSMARTDriveSummary(t *testing.T) {
+	parse := func(fixture string) SMARTDrive {
+		drive, err := parseSmartctl([]byte(fixture))
+		if err != nil {
+			t.Fatalf("parseSmartctl failed: %v", err)
+		}
+		return drive
+	}
+	passed := true
+	healthy := SMARTDrive{Device: "sda", Passed: &passed}
+
+	tests := []struct {
+		name     string
+		drives   SMARTDriveList
+		want     string
+		severity display.Severity
+	}{
+		{"all healthy", SMARTDriveList{healthy, healthy, healthy, healthy, healthy, healthy}, "6 drives OK", display.SeverityOK},
+		{"standby", SMARTDriveList{healthy, parse(smartctlStandbyFixture)}, "2 drives OK (1 in standby)", display.SeverityOK},
+		{"pending sectors", SMARTDriveList{healthy, parse(smartctlATAFixture)}, "sdc: 12 pending sectors, 3 reallocated sectors", display.SeverityWarning},
+		{"worn NVMe", SMARTDriveList{parse(smartctlNVMeFixture)}, "nvme0: 91% worn", display.SeverityWarning},
+		{"failed", SMARTDriveList{parse(smartctlFailedFixture), {Device: "sdf", Error: "open failed"}}, "sdd: SMART failed; sdf: unreadable", display.SeverityCritical},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			snap := SystemSnapshot{SMARTDrives: tt.drives}
+			snap.Evaluate(config.ThresholdsConfig{})
+			line := snap.SMARTDrives.Lines()[0]
+			if line.Label != "Drive Health" || line.Value != tt.want {
+				t.Fatalf("line = %q: %q, want %q", line.Label, line.Value, tt.want)
+			}
+			if got := snap.SMARTDrives.Severity(); got != tt.severity {
+				t.Fatalf("severity = %q, want %q", got, tt.severity)
+			}
+		})
+	}
+}
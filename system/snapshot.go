@@ -0,0 +1,461 @@
+package system
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	"motd/config"
+	"motd/display"
+	"motd/util"
+)
+
+// SystemSnapshot holds the typed readings behind the System Information and
+// Services & Resources sections. Nil fields were unavailable on this host.
+type SystemSnapshot struct {
+	OS           *OSInfo             `json:"os,omitempty"`
+	Uptime       *UptimeInfo         `json:"uptime,omitempty"`
+	Updates      *UpdateInfo         `json:"updates,omitempty"`
+	Load         *LoadInfo           `json:"load,omitempty"`
+	Memory       *MemoryInfo         `json:"memory,omitempty"`
+	Pressure     *PressureInfo       `json:"pressure,omitempty"`
+	Bandwidth    *BandwidthInfo      `json:"bandwidth,omitempty"`
+	Interfaces   InterfaceList       `json:"interfaces,omitempty"`
+	Processes    *ProcessInfo        `json:"processes,omitempty"`
+	Users        *UserInfo           `json:"users,omitempty"`
+	FailedLogins *FailedLogins       `json:"failed_logins,omitempty"`
+	Disks        DiskList            `json:"disks,omitempty"`
+	ZFSPools     ZFSPoolList         `json:"zfs_pools,omitempty"`
+	RAIDArrays   RAIDArrayList       `json:"md_arrays,omitempty"`
+	Btrfs        BtrfsFilesystemList `json:"btrfs,omitempty"`
+	SMARTDrives  SMARTDriveList      `json:"smart_drives,omitempty"`
+	Temperature  *TemperatureInfo    `json:"temperature,omitempty"`
+
+	// TimedOut names the collectors that did not finish within the render
+	// budget.
+	TimedOut []string `json:"timed_out,omitempty"`
+
+	// Containers is reported separately from the system object in JSON.
+	Containers *ContainerStatus `json:"-"`
+
+	// Units is reported separately from the system object in JSON, like
+	// Containers.
+	Units *UnitStatus `json:"-"`
+}
+
+type OSInfo struct {
+	Name    string `json:"name"`
+	Edition string `json:"edition,omitempty"`
+	Build   string `json:"build,omitempty"`
+}
+
+type UptimeInfo struct {
+	Seconds float64 `json:"seconds"`
+}
+
+func (u UptimeInfo) Duration() time.Duration {
+	return time.Duration(u.Seconds * float64(time.Second))
+}
+
+// LoadInfo carries 1/5/15 minute load averages on Unix and an overall CPU
+// percentage on Windows, where load averages do not exist. On Linux the CPU,
+// iowait and steal percentages come from a short /proc/stat sample.
+type LoadInfo struct {
+	Averages      []float64        `json:"averages,omitempty"`
+	Cores         int              `json:"cores,omitempty"`
+	CPUPercent    *float64         `json:"cpu_percent,omitempty"`
+	IOWaitPercent *float64         `json:"iowait_percent,omitempty"`
+	StealPercent  *float64         `json:"steal_percent,omitempty"`
+	Severity      display.Severity `json:"severity,omitempty"`
+	UsageSeverity display.Severity `json:"usage_severity,omitempty"`
+}
+
+// PerCore returns the 1-minute load average divided by the core count.
+func (l LoadInfo) PerCore() (float64, bool) {
+	if len(l.Averages) == 0 || l.Cores <= 0 {
+		return 0, false
+	}
+	return l.Averages[0] / float64(l.Cores), true
+}
+
+// MemoryInfo is physical memory use. On Linux it also carries swap, each
+// zram device and the OOM kills since boot; OOMKills is nil on kernels
+// without the counter.
+type MemoryInfo struct {
+	TotalBytes     uint64           `json:"total_bytes"`
+	UsedBytes      uint64           `json:"used_bytes"`
+	UsedPercent    float64          `json:"used_percent"`
+	SwapTotalBytes uint64           `json:"swap_total_bytes,omitempty"`
+	SwapUsedBytes  uint64           `json:"swap_used_bytes,omitempty"`
+	ZRAM           []ZRAMDevice     `json:"zram,omitempty"`
+	OOMKills       *uint64          `json:"oom_kills,omitempty"`
+	Severity       display.Severity `json:"severity,omitempty"`
+}
+
+// BandwidthInfo is month-to-date traffic plus a linear end-of-month estimate.
+// Source is the accounting that produced it, vnstat or native.
+type BandwidthInfo struct {
+	Interface       string `json:"interface,omitempty"`
+	Source          string `json:"source,omitempty"`
+	RxBytes         uint64 `json:"rx_bytes"`
+	TxBytes         uint64 `json:"tx_bytes"`
+	RxEstimateBytes uint64 `json:"rx_estimate_bytes"`
+	TxEstimateBytes uint64 `json:"tx_estimate_bytes"`
+}
+
+type ProcessInfo struct {
+	Count int `json:"count"`
+}
+
+// UserInfo counts the unique logged-in users. On Linux it also lists each
+// session from utmp and the current user's previous login from wtmp.
+type UserInfo struct {
+	Count     int           `json:"count"`
+	Sessions  []UserSession `json:"sessions,omitempty"`
+	LastLogin *LastLogin    `json:"last_login,omitempty"`
+}
+
+// DiskList is the set of mounts reported by the disk collector.
+type DiskList []DiskUsage
+
+// DiskUsage is byte and inode usage of one mount. The inode fields are zero
+// on filesystems that allocate inodes dynamically, such as Btrfs and ZFS.
+type DiskUsage struct {
+	Label             string           `json:"label"`
+	Path              string           `json:"path"`
+	TotalBytes        uint64           `json:"total_bytes"`
+	UsedBytes         uint64           `json:"used_bytes"`
+	UsedPercent       float64          `json:"used_percent"`
+	Severity          display.Severity `json:"severity,omitempty"`
+	InodesTotal       uint64           `json:"inodes_total,omitempty"`
+	InodesUsed        uint64           `json:"inodes_used,omitempty"`
+	InodesUsedPercent float64          `json:"inodes_used_percent,omitempty"`
+	InodeSeverity     display.Severity `json:"inode_severity,omitempty"`
+}
+
+// TemperatureInfo is the CPU temperature, or with system.temperature.sensors
+// set, each selected hwmon sensor; Celsius is then the first of them.
+type TemperatureInfo struct {
+	Celsius  float64             `json:"celsius"`
+	Sensors  []TemperatureSensor `json:"sensors,omitempty"`
+	Severity display.Severity    `json:"severity,omitempty"`
+}
+
+// CollectSnapshot runs every system and resource collector supported on this
+// platform in pool, or in a pool of its own when pool is nil. Failures are
+// logged in debug mode and leave the matching field empty. Collectors still
+// queued or running when ctx is done are abandoned and listed in TimedOut.
+func CollectSnapshot(ctx context.Context, cfg ConfigAccessor, pool *util.Pool, debug bool) SystemSnapshot {
+	return collectMetrics(ctx, append(SystemCollectors(), ResourceCollectors()...), cfg, pool, debug)
+}
+
+// perUserCollectors read details that belong to the user running motd or
+// to other users' sessions. The daemon serves one snapshot to every local
+// user, so it leaves these out and each client collects them itself.
+var perUserCollectors = map[string]bool{"users": true, "failed_logins": true}
+
+// Shared returns s without the per-user details: the last login, the
+// remote host of each session and the failed logins since the last login.
+func (s SystemSnapshot) Shared() SystemSnapshot {
+	s.FailedLogins = nil
+	if s.Users != nil {
+		users := UserInfo{Count: s.Users.Count}
+		for _, session := range s.Users.Sessions {
+			session.Host = ""
+			users.Sessions = append(users.Sessions, session)
+		}
+		s.Users = &users
+	}
+	return s
+}
+
+// CollectPerUser runs the per-user collectors in the calling process, with
+// its own permissions, and replaces their results in snap.
+func CollectPerUser(ctx context.Context, cfg ConfigAccessor, snap *SystemSnapshot, debug bool) {
+	var collectors []Collector
+	for _, collector := range ResourceCollectors() {
+		if perUserCollectors[collector.Name()] {
+			collectors = append(collectors, collector)
+		}
+	}
+	local := collectMetrics(ctx, collectors, cfg, nil, debug)
+	snap.Users, snap.FailedLogins = local.Users, local.FailedLogins
+
+	var timedOut []string
+	for _, name := range snap.TimedOut {
+		if !perUserCollectors[name] {
+			timedOut = append(timedOut, name)
+		}
+	}
+	snap.TimedOut = append(timedOut, local.TimedOut...)
+}
+
+// collectMetrics schedules every enabled collector at once and applies the
+// results in collector order, so the snapshot does not depend on which
+// collector finished first. A collector that ignores ctx, such as a read
+// stuck on a hung network mount, is left running and never waited for.
+func collectMetrics(ctx context.Context, collectors []Collector, cfg ConfigAccessor, pool *util.Pool, debug bool) SystemSnapshot {
+	if pool == nil {
+		pool = util.NewPool(len(collectors))
+	}
+	enabled := make([]Collector, 0, len(collectors))
+	for _, collector := range collectors {
+		if collector.Enabled(cfg) {
+			enabled = append(enabled, collector)
+		}
+	}
+
+	type result struct {
+		index  int
+		metric Metric
+		err    error
+	}
+	results := make(chan result, len(enabled))
+	for i, collector := range enabled {
+		pool.Go(ctx, func() {
+			metric, err := collector.Collect(ctx, cfg)
+			if err != nil && ctx.Err() != nil {
+				err = ctx.Err()
+			}
+			results <- result{index: i, metric: metric, err: err}
+		})
+	}
+
+	finished := make([]*result, len(enabled))
+wait:
+	for remaining := len(enabled); remaining > 0; remaining-- {
+		select {
+		case r := <-results:
+			finished[r.index] = &r
+		case <-ctx.Done():
+			break wait
+		}
+	}
+
+	var snap SystemSnapshot
+	for i, collector := range enabled {
+		r := finished[i]
+		switch {
+		case r == nil || errors.Is(r.err, context.DeadlineExceeded) || errors.Is(r.err, context.Canceled):
+			display.DebugLog(debug, "%s collector timed out", collector.Name())
+			snap.TimedOut = append(snap.TimedOut, collector.Name())
+		case r.err != nil:
+			display.DebugLog(debug, "%s collector unavailable: %v", collector.Name(), r.err)
+		default:
+			snap.Set(r.metric)
+		}
+	}
+	snap.Evaluate(cfg.Thresholds)
+	return snap
+}
+
+// Evaluate sets the severity of every reading that has alert levels.
+func (s *SystemSnapshot) Evaluate(th config.ThresholdsConfig) {
+	if s.Updates != nil {
+		s.Updates.Severity = updatesSeverity(*s.Updates)
+	}
+	if s.Load != nil {
+		if perCore, ok := s.Load.PerCore(); ok {
+			s.Load.Severity = th.LoadPerCoreLevels().Evaluate(perCore)
+		}
+		var severities []display.Severity
+		if s.Load.IOWaitPercent != nil {
+			severities = append(severities, th.IOWaitLevels().Evaluate(*s.Load.IOWaitPercent))
+		}
+		if s.Load.StealPercent != nil {
+			severities = append(severities, th.StealLevels().Evaluate(*s.Load.StealPercent))
+		}
+		if len(severities) > 0 {
+			s.Load.UsageSeverity = display.WorstSeverity(severities...)
+		}
+	}
+	if s.Memory != nil {
+		s.Memory.Severity = th.MemoryLevels().Evaluate(s.Memory.UsedPercent)
+	}
+	if s.Pressure != nil {
+		var severities []display.Severity
+		for _, resource := range s.Pressure.Resources() {
+			resource.Stall.Severity = th.PressureLevels().Evaluate(resource.Stall.Some.Avg10)
+			severities = append(severities, resource.Stall.Severity)
+		}
+		s.Pressure.Severity = display.WorstSeverity(severities...)
+	}
+	for i := range s.Disks {
+		s.Disks[i].Severity = th.DiskLevels(s.Disks[i].Path).Evaluate(s.Disks[i].UsedPercent)
+		if s.Disks[i].InodesTotal > 0 {
+			s.Disks[i].InodeSeverity = th.InodeLevels().Evaluate(s.Disks[i].InodesUsedPercent)
+		}
+	}
+	for i := range s.ZFSPools {
+		pool := &s.ZFSPools[i]
+		pool.Severity = display.WorstSeverity(zfsHealthSeverity(pool.Health), th.ZFSCapacityLevels().Evaluate(pool.CapacityPercent))
+	}
+	for i := range s.RAIDArrays {
+		s.RAIDArrays[i].Severity = raidSeverity(s.RAIDArrays[i])
+	}
+	for i := range s.Btrfs {
+		s.Btrfs[i].Severity = btrfsSeverity(s.Btrfs[i])
+	}
+	for i := range s.SMARTDrives {
+		drive := &s.SMARTDrives[i]
+		if drive.PercentageUsed != nil {
+			drive.WearSeverity = th.DriveWearLevels().Evaluate(*drive.PercentageUsed)
+		}
+		drive.Severity = smartSeverity(*drive)
+	}
+	if s.Temperature != nil {
+		s.Temperature.Severity = th.TemperatureLevels().Evaluate(s.Temperature.Celsius)
+		if len(s.Temperature.Sensors) > 0 {
+			severities := make([]display.Severity, 0, len(s.Temperature.Sensors))
+			for i := range s.Temperature.Sensors {
+				sensor := &s.Temperature.Sensors[i]
+				sensor.Severity = sensor.Levels(th.TemperatureLevels()).Evaluate(sensor.Celsius)
+				severities = append(severities, sensor.Severity)
+			}
+			s.Temperature.Severity = display.WorstSeverity(severities...)
+		}
+	}
+}
+
+// Set stores metric in the snapshot field matching its type.
+func (s *SystemSnapshot) Set(metric Metric) {
+	switch value := metric.(type) {
+	case OSInfo:
+		s.OS = &value
+	case UptimeInfo:
+		s.Uptime = &value
+	case UpdateInfo:
+		s.Updates = &value
+	case LoadInfo:
+		s.Load = &value
+	case MemoryInfo:
+		s.Memory = &value
+	case PressureInfo:
+		s.Pressure = &value
+	case BandwidthInfo:
+		s.Bandwidth = &value
+	case InterfaceList:
+		s.Interfaces = value
+	case ProcessInfo:
+		s.Processes = &value
+	case UserInfo:
+		s.Users = &value
+	case FailedLogins:
+		s.FailedLogins = &value
+	case DiskList:
+		s.Disks = value
+	case ZFSPoolList:
+		s.ZFSPools = value
+	case RAIDArrayList:
+		s.RAIDArrays = value
+	case BtrfsFilesystemList:
+		s.Btrfs = value
+	case SMARTDriveList:
+		s.SMARTDrives = value
+	case TemperatureInfo:
+		s.Temperature = &value
+	case ContainerStatus:
+		s.Containers = &value
+	case UnitStatus:
+		s.Units = &value
+	}
+}
+
+// SystemMetrics returns the System Information metrics in display order.
+func (s SystemSnapshot) SystemMetrics() []Metric {
+	return s.metrics(SystemCollectors())
+}
+
+// ResourceMetrics returns the Services & Resources metrics in display order.
+func (s SystemSnapshot) ResourceMetrics() []Metric {
+	return s.metrics(ResourceCollectors())
+}
+
+// metrics returns the reading of each collector in order, with a
+// TimedOutMetric in place of collectors that ran out of time.
+func (s SystemSnapshot) metrics(collectors []Collector) []Metric {
+	metrics := make([]Metric, 0, len(collectors))
+	for _, collector := range collectors {
+		if metric := s.metric(collector.Name()); metric != nil {
+			metrics = append(metrics, metric)
+		} else if slices.Contains(s.TimedOut, collector.Name()) {
+			metrics = append(metrics, TimedOutMetric{Collector: collector.Name()})
+		}
+	}
+	return metrics
+}
+
+// metric returns the reading stored for the named collector, or nil.
+func (s SystemSnapshot) metric(name string) Metric {
+	switch {
+	case name == "os" && s.OS != nil:
+		return *s.OS
+	case name == "uptime" && s.Uptime != nil:
+		return *s.Uptime
+	case name == "updates" && s.Updates != nil:
+		return *s.Updates
+	case name == "load" && s.Load != nil:
+		return *s.Load
+	case name == "memory" && s.Memory != nil:
+		return *s.Memory
+	case name == "pressure" && s.Pressure != nil:
+		return *s.Pressure
+	case name == "bandwidth" && s.Bandwidth != nil:
+		return *s.Bandwidth
+	case name == "interfaces" && len(s.Interfaces) > 0:
+		return s.Interfaces
+	case name == "containers" && s.Containers != nil:
+		return *s.Containers
+	case name == "units" && s.Units != nil:
+		return *s.Units
+	case name == "processes" && s.Processes != nil:
+		return *s.Processes
+	case name == "users" && s.Users != nil:
+		return *s.Users
+	case name == "failed_logins" && s.FailedLogins != nil:
+		return *s.FailedLogins
+	case name == "disks" && len(s.Disks) > 0:
+		return s.Disks
+	case name == "zfs" && len(s.ZFSPools) > 0:
+		return s.ZFSPools
+	case name == "mdraid" && len(s.RAIDArrays) > 0:
+		return s.RAIDArrays
+	case name == "btrfs" && len(s.Btrfs) > 0:
+		return s.Btrfs
+	case name == "smart" && len(s.SMARTDrives) > 0:
+		return s.SMARTDrives
+	case name == "temperature" && s.Temperature != nil:
+		return *s.Temperature
+	}
+	return nil
+}
+
+func newMemoryInfo(totalBytes, usedBytes uint64) MemoryInfo {
+	info := MemoryInfo{TotalBytes: totalBytes, UsedBytes: usedBytes}
+	if totalBytes > 0 {
+		info.UsedPercent = float64(usedBytes) / float64(totalBytes) * 100
+	}
+	return info
+}
+
+func newDiskUsage(label, path string, totalBytes, usedBytes uint64) DiskUsage {
+	usage := DiskUsage{Label: label, Path: path, TotalBytes: totalBytes, UsedBytes: usedBytes}
+	if totalBytes > 0 {
+		usage.UsedPercent = float64(usedBytes) / float64(totalBytes) * 100
+	}
+	return usage
+}
+
+// setInodes records inode usage from a filesystem's total and free counts.
+func (d *DiskUsage) setInodes(total, free uint64) {
+	if total == 0 || free > total {
+		return
+	}
+	d.InodesTotal, d.InodesUsed = total, total-free
+	d.InodesUsedPercent = float64(d.InodesUsed) / float64(total) * 100
+}
+
+func bytesToGB(value uint64) float64 {
+	return float64(value) / float64(GB)
+}
@@ -1,6 +1,7 @@
 package system
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"time"
@@ -19,16 +20,34 @@ const (
 // ConfigAccessor provides system-relevant config values
 // without exposing the full Config struct to system functions.
 type ConfigAccessor struct {
-	ContainerStatus  *config.ContainerStatusConfig
-	TankMount        string
-	NetworkInterface string
+	ContainerStatus    *config.ContainerStatusConfig
+	TankMount          string
+	Disks              *config.DisksConfig
+	NetworkInterface   string
+	NetworkInterfaces  []string
+	BandwidthMode      string
+	BandwidthStateFile string
+	TemperatureSensors []string
+	FailedLogins       *config.FailedLoginsConfig
+	Updates            *config.UpdatesConfig
+	WatchedUnits       []string
+	Thresholds         config.ThresholdsConfig
 }
 
 func ConfigAccessorFrom(cfg config.Config) ConfigAccessor {
 	return ConfigAccessor{
-		ContainerStatus:  cfg.System.ContainerStatus,
-		TankMount:        cfg.System.TankMount,
-		NetworkInterface: cfg.System.Network.Interface,
+		ContainerStatus:    cfg.System.ContainerStatus,
+		TankMount:          cfg.System.TankMount,
+		Disks:              cfg.System.Disks,
+		NetworkInterface:   cfg.System.Network.Interface,
+		NetworkInterfaces:  cfg.System.Network.Interfaces,
+		BandwidthMode:      cfg.System.Network.Bandwidth,
+		BandwidthStateFile: cfg.System.Network.StateFile,
+		TemperatureSensors: cfg.System.Temperature.Sensors,
+		FailedLogins:       cfg.System.FailedLogins,
+		Updates:            cfg.System.Updates,
+		WatchedUnits:       cfg.System.Units.Watch,
+		Thresholds:         cfg.Thresholds,
 	}
 }
 
@@ -58,7 +77,7 @@ func FormatDuration(d time.Duration) string {
 // (the one with the default route). Returns "" if detection fails.
 // Each platform file provides its own implementation.
 func GetDefaultInterface() string {
-	return getDefaultInterface()
+	return getDefaultInterface(context.Background())
 }
 
 func daysInMonth(t time.Time) int {
@@ -0,0 +1,210 @@
+package system
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"motd/config"
+	"motd/display"
+	"motd/util"
+)
+
+// systemdRuntimePath exists only when systemd is the init system.
+const systemdRuntimePath = "/run/systemd/system"
+
+// UnitStatus is the state of the watched systemd units and every failed
+// unit. Active and Total count the watched units.
+type UnitStatus struct {
+	Active int         `json:"active"`
+	Total  int         `json:"total"`
+	Status string      `json:"status"`
+	Units  []UnitState `json:"units"`
+	Failed []UnitState `json:"failed"`
+}
+
+// UnitState is one unit as systemctl reports it. Active is set for the
+// active and reloading states.
+type UnitState struct {
+	Name        string `json:"name"`
+	LoadState   string `json:"load_state"`
+	ActiveState string `json:"active_state"`
+	SubState    string `json:"sub_state"`
+	Active      bool   `json:"active"`
+}
+
+// Severity is ok when every watched unit is active and none has failed,
+// and a warning otherwise.
+func (u UnitStatus) Severity() display.Severity {
+	if u.Active == u.Total && len(u.Failed) == 0 {
+		return display.SeverityOK
+	}
+	return display.SeverityWarning
+}
+
+// WatchSeverity grades the watched units alone.
+func (u UnitStatus) WatchSeverity() display.Severity {
+	if u.Active == u.Total {
+		return display.SeverityOK
+	}
+	return display.SeverityWarning
+}
+
+// FailedNames lists the failed units for the banner and `motd check`.
+func (u UnitStatus) FailedNames() string {
+	names := make([]string, 0, len(u.Failed))
+	for _, unit := range u.Failed {
+		names = append(names, unit.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+// SystemdAvailable reports whether systemd is the init system and
+// systemctl is in the trusted directories.
+func SystemdAvailable() bool {
+	if _, err := os.Stat(systemdRuntimePath); err != nil {
+		return false
+	}
+	return util.HasCommand("systemctl")
+}
+
+func unitsEnabled(cfg ConfigAccessor) bool {
+	return SystemdAvailable()
+}
+
+// ValidateUnitsConfig rejects empty watch entries and ones systemctl would
+// take for an option.
+func ValidateUnitsConfig(units config.UnitsConfig) error {
+	for i, name := range units.Watch {
+		if strings.TrimSpace(name) == "" || strings.ContainsAny(name, " \t\n") || strings.HasPrefix(name, "-") {
+			return fmt.Errorf("system.units.watch[%d] is not a unit name", i)
+		}
+	}
+	return nil
+}
+
+func readUnits(ctx context.Context, cfg ConfigAccessor) (UnitStatus, error) {
+	cmd, err := util.SafeCommandContext(ctx, "systemctl", "list-units", "--failed", "--all", "--plain", "--no-legend", "--full", "--no-pager", "--output=json")
+	if err != nil {
+		return UnitStatus{}, err
+	}
+	output, err := cmd.Output()
+	if err != nil {
+		return UnitStatus{}, fmt.Errorf("systemctl list-units failed: %w", err)
+	}
+	failed, err := parseFailedUnits(output)
+	if err != nil {
+		return UnitStatus{}, err
+	}
+
+	var watched []UnitState
+	if len(cfg.WatchedUnits) > 0 {
+		args := append([]string{"show", "--property=Id,LoadState,ActiveState,SubState", "--no-pager", "--"}, cfg.WatchedUnits...)
+		cmd, err := util.SafeCommandContext(ctx, "systemctl", args...)
+		if err != nil {
+			return UnitStatus{}, err
+		}
+		output, err := cmd.Output()
+		if err != nil {
+			return UnitStatus{}, fmt.Errorf("systemctl show failed: %w", err)
+		}
+		watched = parseUnitShow(output)
+	}
+	return newUnitStatus(watched, failed), nil
+}
+
+// newUnitStatus counts the active watched units.
+func newUnitStatus(watched, failed []UnitState) UnitStatus {
+	status := UnitStatus{Total: len(watched), Units: watched, Failed: failed}
+	if status.Units == nil {
+		status.Units = []UnitState{}
+	}
+	if status.Failed == nil {
+		status.Failed = []UnitState{}
+	}
+	for _, unit := range watched {
+		if unit.Active {
+			status.Active++
+		}
+	}
+	switch {
+	case status.Total == 0:
+		status.Status = "0 units"
+	case status.Active == status.Total:
+		status.Status = fmt.Sprintf("All %d unit%s active", status.Total, util.PluralSuffix(status.Total))
+	default:
+		status.Status = fmt.Sprintf("%d of %d active", status.Active, status.Total)
+	}
+	return status
+}
+
+// parseFailedUnits parses `systemctl list-units --failed --output=json`.
+// systemd before 246 ignores --output for tables and prints the plain
+// table instead, which is parsed as a fallback:
+//
+//	backup.service loaded failed failed Nightly backup
+func parseFailedUnits(output []byte) ([]UnitState, error) {
+	trimmed := bytes.TrimSpace(output)
+	if bytes.HasPrefix(trimmed, []byte("[")) {
+		var units []struct {
+			Unit   string `json:"unit"`
+			Load   string `json:"load"`
+			Active string `json:"active"`
+			Sub    string `json:"sub"`
+		}
+		if err := json.Unmarshal(trimmed, &units); err != nil {
+			return nil, fmt.Errorf("invalid systemctl output: %w", err)
+		}
+		failed := make([]UnitState, 0, len(units))
+		for _, unit := range units {
+			failed = append(failed, newUnitState(unit.Unit, unit.Load, unit.Active, unit.Sub))
+		}
+		return failed, nil
+	}
+
+	var failed []UnitState
+	scanner := bufio.NewScanner(bytes.NewReader(trimmed))
+	for scanner.Scan() {
+		fields := strings.Fields(strings.TrimPrefix(strings.TrimSpace(scanner.Text()), "●"))
+		if len(fields) < 4 {
+			continue
+		}
+		failed = append(failed, newUnitState(fields[0], fields[1], fields[2], fields[3]))
+	}
+	if len(trimmed) > 0 && len(failed) == 0 {
+		return nil, errors.New("unrecognized systemctl output")
+	}
+	return failed, nil
+}
+
+// parseUnitShow parses the blank-line separated property blocks of
+// `systemctl show`, one per unit in the order they were asked for.
+func parseUnitShow(output []byte) []UnitState {
+	var units []UnitState
+	properties := map[string]string{}
+	flush := func() {
+		if properties["Id"] != "" {
+			units = append(units, newUnitState(properties["Id"], properties["LoadState"], properties["ActiveState"], properties["SubState"]))
+		}
+		properties = map[string]string{}
+	}
+	for _, line := range strings.Split(string(output), "\n") {
+		key, value, ok := strings.Cut(strings.TrimSpace(line), "=")
+		if !ok {
+			flush()
+			continue
+		}
+		properties[key] = value
+	}
+	flush()
+	return units
+}
+
+func newUnitState(name, load, active, sub string) UnitState {
+	return UnitState{Name: name, LoadState: load, ActiveState: active, SubState: sub, Active: active == "active" || active == "reloading"}
+}
@@ -0,0 +1,153 @@
+package system
+
+import (
+	"testing"
+
+	"motd/config"
+)
+
+func TestParseFailedUnits(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   []string
+	}{
+		{
+			name:   "json",
+			output: `[{"unit":"backup.service","load":"loaded","active":"failed","sub":"failed","description":"Nightly backup"},{"unit":"mnt-tank.mount","load":"loaded","active":"failed","sub":"failed","description":"/mnt/tank"}]`,
+			want:   []string{"backup.service", "mnt-tank.mount"},
+		},
+		{
+			name:   "json without failed units",
+			output: "[]\n",
+		},
+		{
+			name:   "plain table from systemd before 246",
+			output: "backup.service loaded failed failed Nightly backup\n● mnt-tank.mount loaded failed failed /mnt/tank\n",
+			want:   []string{"backup.service", "mnt-tank.mount"},
+		},
+		{
+			name:   "plain table without failed units",
+			output: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			failed, err := parseFailedUnits([]byte(tt.output))
+			if err != nil {
+				t.Fatalf("parseFailedUnits failed: %v", err)
+			}
+			if len(failed) != len(tt.want) {
+				t.Fatalf("expected %d failed units, got %+v", len(tt.want), failed)
+			}
+			for i, name := range tt.want {
+				if failed[i].Name != name || failed[i].ActiveState != "failed" || failed[i].Active {
+					t.Fatalf("unit %d = %+v, want failed %s", i, failed[i], name)
+				}
+			}
+		})
+	}
+	if _, err := parseFailedUnits([]byte(`[{"unit":`)); err == nil {
+		t.Fatal("expected truncated JSON to be rejected")
+	}
+}
+
+const unitShowFixture = `Id=nginx.service
+LoadState=loaded
+ActiveState=active
+SubState=running
+
+Id=postgresql.service
+LoadState=loaded
+ActiveState=reloading
+SubState=reload
+
+Id=backup.timer
+LoadState=loaded
+ActiveState=inactive
+SubState=dead
+
+Id=missing.service
+LoadState=not-found
+ActiveState=inactive
+SubState=dead
+`
+
+func TestParseUnitShow(t *testing.T) {
+	units := parseUnitShow([]byte(unitShowFixture))
+	want := []struct {
+		name   string
+		active bool
+	}{
+		{"nginx.service", true},
+		{"postgresql.service", true},
+		{"backup.timer", false},
+		{"missing.service", false},
+	}
+	if len(units) != len(want) {
+		t.Fatalf("expected %d units, got %+v", len(want), units)
+	}
+	for i := range want {
+		if units[i].Name != want[i].name || units[i].Active != want[i].active {
+			t.Fatalf("unit %d = %+v, want %s active=%v", i, units[i], want[i].name, want[i].active)
+		}
+	}
+	if units[3].LoadState != "not-found" {
+		t.Fatalf("expected the missing unit to keep its load state, got %+v", units[3])
+	}
+}
+
+func TestUnitStatusLines(t *testing.T) {
+	watched := parseUnitShow([]byte(unitShowFixture))
+	failed := []UnitState{newUnitState("backup.service", "loaded", "failed", "failed")}
+	tests := []struct {
+		name    string
+		watched []UnitState
+		failed  []UnitState
+		want    []string
+	}{
+		{
+			name:    "all active",
+			watched: watched[:2],
+			want:    []string{"Units=All 2 units active"},
+		},
+		{
+			name:    "inactive and failed",
+			watched: watched,
+			failed:  failed,
+			want:    []string{"Units=2 of 4 active", "Failed units=backup.service"},
+		},
+		{
+			name:   "failed units only",
+			failed: failed,
+			want:   []string{"Failed units=backup.service"},
+		},
+		{
+			name: "nothing to report",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines := newUnitStatus(tt.watched, tt.failed).Lines()
+			if len(lines) != len(tt.want) {
+				t.Fatalf("expected %d lines, got %+v", len(tt.want), lines)
+			}
+			for i, line := range lines {
+				if got := line.Label + "=" + line.Value; got != tt.want[i] {
+					t.Fatalf("line %d = %q, want %q", i, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestValidateUnitsConfig(t *testing.T) {
+	for _, name := range []string{"", "--user", "nginx service"} {
+		if err := ValidateUnitsConfig(config.UnitsConfig{Watch: []string{"sshd.service", name}}); err == nil {
+			t.Fatalf("expected %q to be rejected", name)
+		}
+	}
+	if err := ValidateUnitsConfig(config.UnitsConfig{Watch: []string{"nginx", "backup.timer", "getty@tty1.service"}}); err != nil {
+		t.Fatalf("expected unit names to be accepted, got %v", err)
+	}
+}
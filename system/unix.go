@@ -3,160 +3,411 @@
 package system
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strconv"
 	"strings"
 	"sync"
 	"syscall"
 	"time"
 
-	"motd/display"
 	"motd/util"
 )
 
-func ShowOS(cfg ConfigAccessor, debug bool) {
-	release := "Unknown"
+func readOS(ctx context.Context, cfg ConfigAccessor) (OSInfo, error) {
 	data, err := os.ReadFile("/etc/os-release")
-	if err == nil {
-		for _, line := range strings.Split(string(data), "\n") {
-			if strings.HasPrefix(line, "PRETTY_NAME=") {
-				release = strings.Trim(strings.TrimPrefix(line, "PRETTY_NAME="), "\"")
-				break
-			}
+	if err != nil {
+		return OSInfo{}, err
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.HasPrefix(line, "PRETTY_NAME=") {
+			return OSInfo{Name: strings.Trim(strings.TrimPrefix(line, "PRETTY_NAME="), "\"")}, nil
 		}
 	}
-	display.DotLabel("OS Release")
-	fmt.Printf("%s%s%s\n", display.Blue, release, display.Reset)
+	return OSInfo{}, fmt.Errorf("PRETTY_NAME missing from /etc/os-release")
 }
 
-func ShowUptime(cfg ConfigAccessor, debug bool) {
-	uptime := "unknown"
+func readUptime(ctx context.Context, cfg ConfigAccessor) (UptimeInfo, error) {
 	data, err := os.ReadFile("/proc/uptime")
-	if err == nil {
-		fields := strings.Fields(string(data))
-		if len(fields) > 0 {
-			seconds, parseErr := strconv.ParseFloat(fields[0], 64)
-			if parseErr == nil {
-				uptime = FormatDuration(time.Duration(seconds) * time.Second)
-			}
-		}
+	if err != nil {
+		return UptimeInfo{}, err
 	}
-	display.DotLabel("Uptime")
-	fmt.Printf("%s%s%s\n", display.Blue, uptime, display.Reset)
+	fields := strings.Fields(string(data))
+	if len(fields) == 0 {
+		return UptimeInfo{}, fmt.Errorf("empty /proc/uptime")
+	}
+	seconds, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return UptimeInfo{}, err
+	}
+	return UptimeInfo{Seconds: seconds}, nil
 }
 
-func ShowLoad(cfg ConfigAccessor, debug bool) {
-	load := ""
+func readLoad(ctx context.Context, cfg ConfigAccessor) (LoadInfo, error) {
 	data, err := os.ReadFile("/proc/loadavg")
-	if err == nil {
-		fields := strings.Fields(string(data))
-		if len(fields) >= 3 {
-			load = fmt.Sprintf("%s, %s, %s", fields[0], fields[1], fields[2])
+	if err != nil {
+		return LoadInfo{}, err
+	}
+	averages, err := parseLoadAverages(strings.Fields(string(data)))
+	if err != nil {
+		return LoadInfo{}, err
+	}
+	load := LoadInfo{Averages: averages, Cores: runtime.NumCPU()}
+	if stat, err := os.ReadFile("/proc/stat"); err == nil {
+		if cores := countProcStatCPUs(stat); cores > 0 {
+			load.Cores = cores
 		}
 	}
-	display.DotLabel("CPU Load")
-	fmt.Printf("%s%s%s\n", display.Blue, load, display.Reset)
+
+	// Utilization is extra detail; a failed sample leaves it out.
+	if busy, iowait, steal, ok := sampleCPU(ctx); ok {
+		load.CPUPercent, load.IOWaitPercent, load.StealPercent = &busy, &iowait, &steal
+	}
+	return load, nil
 }
 
-func ShowMemory(cfg ConfigAccessor, debug bool) {
+// cpuSampleWindow is how long sampleCPU waits between /proc/stat reads.
+const cpuSampleWindow = 200 * time.Millisecond
+
+// sampleCPU measures utilization over cpuSampleWindow, giving up early when
+// ctx is done.
+func sampleCPU(ctx context.Context) (busy, iowait, steal float64, ok bool) {
+	read := func() (cpuTimes, error) {
+		data, err := os.ReadFile("/proc/stat")
+		if err != nil {
+			return cpuTimes{}, err
+		}
+		return parseProcStatCPU(data)
+	}
+	before, err := read()
+	if err != nil {
+		return 0, 0, 0, false
+	}
+	timer := time.NewTimer(cpuSampleWindow)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return 0, 0, 0, false
+	case <-timer.C:
+	}
+	after, err := read()
+	if err != nil {
+		return 0, 0, 0, false
+	}
+	return cpuUtilization(before, after)
+}
+
+func readMemory(ctx context.Context, cfg ConfigAccessor) (MemoryInfo, error) {
 	data, err := os.ReadFile("/proc/meminfo")
 	if err != nil {
-		return
+		return MemoryInfo{}, err
+	}
+	meminfo, err := parseMeminfo(data)
+	if err != nil {
+		return MemoryInfo{}, err
+	}
+	info := newMemoryInfo(meminfo.total, meminfo.total-meminfo.available)
+	info.SwapTotalBytes = meminfo.swapTotal
+	info.SwapUsedBytes = meminfo.swapTotal - meminfo.swapFree
+	info.ZRAM = scanZRAM(os.DirFS("/sys/block"))
+	if vmstat, err := os.ReadFile("/proc/vmstat"); err == nil {
+		if kills, ok := parseVmstatOOMKills(vmstat); ok {
+			info.OOMKills = &kills
+		}
 	}
+	return info, nil
+}
 
-	var totalKB, availKB uint64
-	for _, line := range strings.Split(string(data), "\n") {
-		if strings.HasPrefix(line, "MemTotal:") {
-			fields := strings.Fields(line)
-			if len(fields) >= 2 {
-				totalKB, _ = strconv.ParseUint(fields[1], 10, 64)
-			}
+const sysClassNet = "/sys/class/net"
+
+// readBandwidth reports month-to-date traffic from vnstat or from the
+// native accounting in bandwidthState, as network.bandwidth selects.
+func readBandwidth(ctx context.Context, cfg ConfigAccessor) (BandwidthInfo, error) {
+	interfaceName := strings.TrimSpace(cfg.NetworkInterface)
+	if interfaceName == "" {
+		interfaceName = getDefaultInterface(ctx)
+	}
+
+	switch bandwidthMode(cfg) {
+	case BandwidthVnstat:
+		return readVnstatBandwidth(ctx, cfg, interfaceName)
+	case BandwidthNative:
+		return readNativeBandwidth(cfg, interfaceName)
+	default:
+		if util.HasCommand("vnstat") {
+			return readVnstatBandwidth(ctx, cfg, interfaceName)
 		}
-		if strings.HasPrefix(line, "MemAvailable:") {
-			fields := strings.Fields(line)
-			if len(fields) >= 2 {
-				availKB, _ = strconv.ParseUint(fields[1], 10, 64)
-			}
+		return readNativeBandwidth(cfg, interfaceName)
+	}
+}
+
+func readVnstatBandwidth(ctx context.Context, cfg ConfigAccessor, interfaceName string) (BandwidthInfo, error) {
+	args := []string{"--json", "m"}
+	if interfaceName != "" {
+		args = append(args, "-i", interfaceName)
+	}
+	cmd, err := util.SafeCommandContext(ctx, "vnstat", args...)
+	if err != nil {
+		return BandwidthInfo{}, err
+	}
+	output, err := cmd.Output()
+	if err != nil && interfaceName != "" && strings.TrimSpace(cfg.NetworkInterface) == "" {
+		cmd2, cmdErr2 := util.SafeCommandContext(ctx, "vnstat", "--json", "m")
+		if cmdErr2 != nil {
+			return BandwidthInfo{}, cmdErr2
 		}
+		output, err = cmd2.Output()
 	}
 
-	if totalKB == 0 {
-		return
+	if err != nil {
+		return BandwidthInfo{}, fmt.Errorf("vnstat command failed: %w", err)
 	}
-	usedKB := totalKB - availKB
-	totalGB := float64(totalKB) / 1048576.0
-	usedGB := float64(usedKB) / 1048576.0
 
-	display.DotLabel("Memory")
-	fmt.Printf("%s%.2f GB / %.2f GB%s\n", display.Blue, usedGB, totalGB, display.Reset)
+	info, err := parseVnstatMonthlyUsage(output, interfaceName, time.Now())
+	if err != nil {
+		return BandwidthInfo{}, fmt.Errorf("failed to parse vnstat data for %s: %w", interfaceName, err)
+	}
+	return info, nil
 }
 
-func ShowBandwidth(cfg ConfigAccessor, debug bool) {
-	if !util.HasCommand("vnstat") {
-		return
+// readNativeBandwidth samples the kernel byte counters for interfaceName and
+// folds them into the bandwidth state file.
+func readNativeBandwidth(cfg ConfigAccessor, interfaceName string) (BandwidthInfo, error) {
+	if interfaceName == "" {
+		return BandwidthInfo{}, fmt.Errorf("no default network interface")
+	}
+	usage, err := readNativeInterfaceBandwidth(cfg, []string{interfaceName})
+	info, ok := usage[interfaceName]
+	if !ok {
+		return BandwidthInfo{}, err
 	}
+	return info, nil
+}
 
-	interfaceName := strings.TrimSpace(cfg.NetworkInterface)
-	if interfaceName == "" {
-		interfaceName = getDefaultInterface()
+// readNativeInterfaceBandwidth samples the kernel byte counters of every
+// named interface and folds them into the bandwidth state file at once.
+// Interfaces whose counters cannot be read are reported in the error and
+// left out of the result.
+func readNativeInterfaceBandwidth(cfg ConfigAccessor, names []string) (map[string]BandwidthInfo, error) {
+	var bootID string
+	if data, err := os.ReadFile("/proc/sys/kernel/random/boot_id"); err == nil {
+		bootID = strings.TrimSpace(string(data))
 	}
-	if interfaceName == "" {
-		interfaceName = "enp7s0"
+	now := time.Now()
+	var bootTime time.Time
+	if uptime, err := readUptime(context.Background(), cfg); err == nil {
+		bootTime = now.Add(-uptime.Duration())
 	}
 
-	cmd, cmdErr := util.SafeCommand("vnstat", "--json", "m", "-i", interfaceName)
-	if cmdErr != nil {
-		return
+	samples := make(map[string]counterSample, len(names))
+	var errs []error
+	for _, name := range names {
+		if !validInterfaceName(name) {
+			errs = append(errs, fmt.Errorf("invalid interface name %q", name))
+			continue
+		}
+		statistics := filepath.Join(sysClassNet, name, "statistics")
+		rx, rxErr := readCounterFile(filepath.Join(statistics, "rx_bytes"))
+		tx, txErr := readCounterFile(filepath.Join(statistics, "tx_bytes"))
+		if err := errors.Join(rxErr, txErr); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			continue
+		}
+		samples[name] = counterSample{BootID: bootID, BootTime: bootTime, Rx: rx, Tx: tx}
+	}
+	if len(samples) == 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	path, err := bandwidthStatePath(cfg)
+	if err != nil {
+		return nil, err
+	}
+	usage, err := recordBandwidthSamples(path, samples, now)
+	if err != nil {
+		return nil, err
+	}
+	return usage, errors.Join(errs...)
+}
+
+// readInterfaceBandwidth returns month-to-date traffic for each of names,
+// from vnstat or the native accounting as network.bandwidth selects.
+func readInterfaceBandwidth(ctx context.Context, cfg ConfigAccessor, names []string) (map[string]BandwidthInfo, error) {
+	mode := bandwidthMode(cfg)
+	if mode == BandwidthNative || (mode == BandwidthAuto && !util.HasCommand("vnstat")) {
+		return readNativeInterfaceBandwidth(cfg, names)
+	}
+	cmd, err := util.SafeCommandContext(ctx, "vnstat", "--json", "m")
+	if err != nil {
+		return nil, err
 	}
 	output, err := cmd.Output()
 	if err != nil {
-		if strings.TrimSpace(cfg.NetworkInterface) == "" {
-			cmd2, cmdErr2 := util.SafeCommand("vnstat", "--json", "m")
-			if cmdErr2 != nil {
-				return
-			}
-			output, err = cmd2.Output()
-		}
+		return nil, fmt.Errorf("vnstat command failed: %w", err)
 	}
+	return parseVnstatInterfaceUsage(output, names, time.Now())
+}
 
+// interfaceLink reads the operstate and speed the kernel reports under
+// /sys/class/net. An "unknown" operstate, common for tunnels and virtual
+// interfaces, is left to the caller's flag check.
+func interfaceLink(name string) (string, int, bool) {
+	if !validInterfaceName(name) {
+		return "", 0, false
+	}
+	data, err := os.ReadFile(filepath.Join(sysClassNet, name, "operstate"))
 	if err != nil {
-		display.DebugLog(debug, "vnstat command failed: %v", err)
-		return
+		return "", 0, false
+	}
+	state := strings.TrimSpace(string(data))
+	if state == "" || state == "unknown" {
+		return "", 0, false
 	}
+	speed := 0
+	if data, err := os.ReadFile(filepath.Join(sysClassNet, name, "speed")); err == nil {
+		if value, err := strconv.Atoi(strings.TrimSpace(string(data))); err == nil && value > 0 {
+			speed = value
+		}
+	}
+	return state, speed, true
+}
 
-	rxGB, txGB, rxEst, txEst, err := parseVnstatMonthlyUsage(output, interfaceName, time.Now())
+func readCounterFile(path string) (uint64, error) {
+	data, err := os.ReadFile(path)
 	if err != nil {
-		display.DebugLog(debug, "Failed to parse vnstat data for %s: %v", interfaceName, err)
-		return
+		return 0, err
 	}
+	return strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+}
 
-	display.DotLabel("Bandwidth (rx)")
-	fmt.Printf("%s%.2f GB / %.2f GB est%s\n", display.Blue, rxGB, rxEst, display.Reset)
-	display.DotLabel("Bandwidth (tx)")
-	fmt.Printf("%s%.2f GB / %.2f GB est%s\n", display.Blue, txGB, txEst, display.Reset)
+// readUsers reads the sessions from utmp and the last login from wtmp. A
+// missing utmp, as on Alpine and in most containers, counts no sessions; a
+// missing or unreadable wtmp only leaves the last login out.
+func readUsers(ctx context.Context, cfg ConfigAccessor) (UserInfo, error) {
+	sessions, err := readUtmpFile(utmpPath, utmpUserProcess)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return UserInfo{}, err
+	}
+	info := newUserInfo(sessions)
+	info.LastLogin = readLastLogin(info)
+	return info, nil
 }
 
-func ShowUser(cfg ConfigAccessor, debug bool) {
-	cmd, err := util.SafeCommand("who")
+// lastLoginCache keeps the result of the last wtmp scan, so the users and
+// failed_logins collectors of a pass decode wtmp once; the second caller
+// waits for the first. Every login appends to wtmp, so its size and
+// modification time tell when the result is out of date.
+var lastLoginCache struct {
+	sync.Mutex
+	key   lastLoginKey
+	last  *LastLogin
+	valid bool
+}
+
+type lastLoginKey struct {
+	username, tty    string
+	loginTime        int64
+	wtmpSize         int64
+	wtmpModification int64
+}
+
+// readLastLogin finds the current user's previous login in wtmp, or nil.
+func readLastLogin(info UserInfo) *LastLogin {
+	username := currentUsername()
+	if username == "" {
+		return nil
+	}
+	stat, err := os.Stat(wtmpPath)
 	if err != nil {
-		return
+		return nil
 	}
-	output, err := cmd.Output()
+	current := info.currentSession(username, controllingTTY())
+	key := lastLoginKey{username: username, wtmpSize: stat.Size(), wtmpModification: stat.ModTime().UnixNano()}
+	if current != nil {
+		key.tty, key.loginTime = current.TTY, current.LoginTime.UnixNano()
+	}
+
+	lastLoginCache.Lock()
+	defer lastLoginCache.Unlock()
+	if lastLoginCache.valid && lastLoginCache.key == key {
+		return lastLoginCache.last
+	}
+	logins, err := readUtmpFile(wtmpPath, utmpUserProcess)
 	if err != nil {
-		return
+		return nil
 	}
+	lastLoginCache.key, lastLoginCache.last, lastLoginCache.valid = key, lastLogin(logins, username, current), true
+	return lastLoginCache.last
+}
 
-	count := countUniqueWhoUsers(output)
-	display.DotLabel("Logged in users")
-	fmt.Printf("%s%d%s\n", display.Blue, count, display.Reset)
+// readFailedLogins counts the failed logins since the current user's
+// previous login, from btmp when it is readable and from the sshd lines of
+// the auth log otherwise.
+func readFailedLogins(ctx context.Context, cfg ConfigAccessor) (FailedLogins, error) {
+	now := time.Now()
+	since, sinceLastLogin := now.Add(-failedLoginWindow), false
+	sessions, _ := readUtmpFile(utmpPath, utmpUserProcess)
+	if last := readLastLogin(newUserInfo(sessions)); last != nil {
+		since, sinceLastLogin = last.Time, true
+	}
+	attempts, source, err := readFailedLoginAttempts(now)
+	if err != nil {
+		return FailedLogins{}, err
+	}
+	top := cfg.FailedLogins.Top
+	if top == 0 {
+		top = defaultFailedLoginSources
+	}
+	summary := summarizeFailedLogins(attempts, since, top)
+	summary.SinceLastLogin = sinceLastLogin
+	summary.Source = source
+	return summary, nil
+}
+
+// readFailedLoginAttempts reads the first of btmp and the auth logs that
+// opens, returning the attempts and the path they came from.
+func readFailedLoginAttempts(now time.Time) ([]failedLoginAttempt, string, error) {
+	records, err := readUtmpFile(btmpPath, utmpLoginProcess)
+	if err == nil {
+		return btmpAttempts(records), btmpPath, nil
+	}
+	for _, path := range authLogPaths {
+		file, openErr := os.Open(path)
+		if openErr != nil {
+			continue
+		}
+		attempts, parseErr := parseAuthLog(file, now)
+		file.Close()
+		if parseErr != nil {
+			return nil, "", parseErr
+		}
+		return attempts, path, nil
+	}
+	return nil, "", fmt.Errorf("failed logins unavailable: %w", err)
 }
 
-func ShowProcesses(cfg ConfigAccessor, debug bool) {
+// controllingTTY returns the terminal on standard input as utmp names it,
+// such as "pts/0", or "" when motd is not run from a terminal.
+func controllingTTY() string {
+	target, err := os.Readlink("/proc/self/fd/0")
+	if err != nil {
+		return ""
+	}
+	tty, ok := strings.CutPrefix(target, "/dev/")
+	if !ok {
+		return ""
+	}
+	return tty
+}
+
+func readProcesses(ctx context.Context, cfg ConfigAccessor) (ProcessInfo, error) {
 	entries, err := os.ReadDir("/proc")
 	if err != nil {
-		return
+		return ProcessInfo{}, err
 	}
 
 	count := 0
@@ -167,37 +418,48 @@ func ShowProcesses(cfg ConfigAccessor, debug bool) {
 			}
 		}
 	}
+	return ProcessInfo{Count: count}, nil
+}
 
-	display.DotLabel("Processes")
-	fmt.Printf("%s%d%s\n", display.Blue, count, display.Reset)
+// readDisks reports every target that can be read; one unreadable mount,
+// such as a stale network share, does not hide the others.
+func readDisks(ctx context.Context, cfg ConfigAccessor) (DiskList, error) {
+	targets, err := diskTargets(cfg, discoverMounts)
+	var disks DiskList
+	errs := []error{err}
+	for _, target := range targets {
+		disk, err := readDiskNative(target.Path, target.Label)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", target.Path, err))
+			continue
+		}
+		disks = append(disks, disk)
+	}
+	if len(disks) > 0 {
+		return disks, nil
+	}
+	return nil, errors.Join(errs...)
 }
 
-func ShowDisk(cfg ConfigAccessor, debug bool) {
-	showDiskNative("/", "Disk (/)")
-	if cfg.TankMount != "" {
-		showDiskNative(cfg.TankMount, fmt.Sprintf("Disk (%s)", cfg.TankMount))
+func discoverMounts() ([]string, error) {
+	data, err := os.ReadFile("/proc/self/mountinfo")
+	if err != nil {
+		return nil, fmt.Errorf("disk discovery: %w", err)
 	}
+	return parseMountinfo(data)
 }
 
-func showDiskNative(path, label string) {
+func readDiskNative(path, label string) (DiskUsage, error) {
 	var stat syscall.Statfs_t
 	if err := syscall.Statfs(path, &stat); err != nil {
-		return
+		return DiskUsage{}, err
 	}
 
 	totalBytes := stat.Blocks * uint64(stat.Bsize)
 	freeBytes := stat.Bavail * uint64(stat.Bsize)
-	usedBytes := totalBytes - freeBytes
-
-	totalGB := float64(totalBytes) / float64(GB)
-	usedGB := float64(usedBytes) / float64(GB)
-	pct := 0.0
-	if totalBytes > 0 {
-		pct = float64(usedBytes) / float64(totalBytes) * 100
-	}
-
-	display.DotLabel(label)
-	fmt.Printf("%s%.2f GB / %.2f GB (%.0f%% used)%s\n", display.Blue, usedGB, totalGB, pct, display.Reset)
+	usage := newDiskUsage(label, path, totalBytes, totalBytes-freeBytes)
+	usage.setInodes(stat.Files, stat.Ffree)
+	return usage, nil
 }
 
 var (
@@ -221,10 +483,25 @@ func scanThermalZones() {
 	}
 }
 
-func ShowTemp(cfg ConfigAccessor, debug bool) {
+// readTemperature reports the configured hwmon sensors, or the CPU package
+// alone. Hosts whose CPU has no hwmon driver fall back to the first thermal
+// zone.
+func readTemperature(ctx context.Context, cfg ConfigAccessor) (TemperatureInfo, error) {
+	sensors, _ := scanHwmon(os.DirFS(hwmonPath))
+	if len(cfg.TemperatureSensors) > 0 {
+		selected := selectSensors(sensors, cfg.TemperatureSensors)
+		if len(selected) == 0 {
+			return TemperatureInfo{}, fmt.Errorf("no hwmon sensor matches system.temperature.sensors")
+		}
+		return TemperatureInfo{Celsius: selected[0].Celsius, Sensors: selected}, nil
+	}
+	if cpu := selectSensors(sensors, []string{CPUPackageSensor}); len(cpu) > 0 {
+		return TemperatureInfo{Celsius: cpu[0].Celsius}, nil
+	}
+
 	tempZonesOnce.Do(scanThermalZones)
 	if len(tempZones) == 0 {
-		return
+		return TemperatureInfo{}, fmt.Errorf("no thermal zones found")
 	}
 
 	for _, zonePath := range tempZones {
@@ -240,13 +517,12 @@ func ShowTemp(cfg ConfigAccessor, debug bool) {
 		if celsius < 0 || celsius > 150 {
 			continue
 		}
-		display.DotLabel("CPU Temperature")
-		fmt.Printf("%s%.0f°C%s\n", display.Red, celsius, display.Reset)
-		return
+		return TemperatureInfo{Celsius: celsius}, nil
 	}
+	return TemperatureInfo{}, fmt.Errorf("no thermal zone reported a valid temperature")
 }
 
-func getDefaultInterface() string {
+func getDefaultInterface(ctx context.Context) string {
 	data, err := os.ReadFile("/proc/net/route")
 	if err != nil {
 		return ""
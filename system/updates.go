@@ -0,0 +1,555 @@
+package system
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"motd/config"
+	"motd/display"
+	"motd/util"
+)
+
+const (
+	rebootRequiredPath = "/var/run/reboot-required"
+	updateNotifierPath = "/var/lib/update-notifier/updates-available"
+	kernelModulesPath  = "/lib/modules"
+	kernelReleasePath  = "/proc/sys/kernel/osrelease"
+
+	defaultUpdatesCacheTTL = time.Hour
+
+	// UpdatesRefreshTimeout bounds a detached package check; dnf and yum
+	// may fetch repository metadata from a slow mirror first.
+	UpdatesRefreshTimeout    = 10 * time.Minute
+	updatesRefreshLockFile   = "updates-refresh.lock"
+	updatesRefreshLockMaxAge = UpdatesRefreshTimeout + time.Minute
+)
+
+// Package managers the updates collector can check.
+const (
+	PackageManagerAPT    = "apt"
+	PackageManagerDNF    = "dnf"
+	PackageManagerYum    = "yum"
+	PackageManagerPacman = "pacman"
+)
+
+// packageDatabases are the files each package manager rewrites when
+// packages change; a cached count older than them is checked again.
+var packageDatabases = map[string][]string{
+	PackageManagerAPT:    {"/var/lib/dpkg/status"},
+	PackageManagerDNF:    {"/var/lib/rpm/rpmdb.sqlite", "/var/lib/rpm/Packages"},
+	PackageManagerYum:    {"/var/lib/rpm/rpmdb.sqlite", "/var/lib/rpm/Packages"},
+	PackageManagerPacman: {"/var/lib/pacman/local"},
+}
+
+// UpdateInfo is the pending package updates and whether the host needs a
+// reboot. Pending and Security are nil until the package manager has been
+// checked once; Security is also nil for pacman, which has no security
+// metadata. Stale marks counts past cache_ttl that are being refreshed.
+// NewestKernel is the newest installed kernel of the running kernel's
+// flavor.
+type UpdateInfo struct {
+	Manager        string           `json:"manager,omitempty"`
+	Pending        *int             `json:"pending,omitempty"`
+	Security       *int             `json:"security,omitempty"`
+	CheckedAt      time.Time        `json:"checked_at,omitzero"`
+	Stale          bool             `json:"stale,omitempty"`
+	RebootRequired bool             `json:"reboot_required"`
+	RebootPackages []string         `json:"reboot_packages,omitempty"`
+	RunningKernel  string           `json:"running_kernel,omitempty"`
+	NewestKernel   string           `json:"newest_kernel,omitempty"`
+	Severity       display.Severity `json:"severity,omitempty"`
+}
+
+// KernelOutdated reports whether a newer kernel is installed than the one
+// running.
+func (u UpdateInfo) KernelOutdated() bool {
+	return u.RunningKernel != "" && u.NewestKernel != "" && compareKernelVersions(u.NewestKernel, u.RunningKernel) > 0
+}
+
+// RebootNeeded reports whether the package manager asked for a reboot or
+// the running kernel is outdated.
+func (u UpdateInfo) RebootNeeded() bool {
+	return u.RebootRequired || u.KernelOutdated()
+}
+
+// updatesSeverity is empty before the first package check when no reboot is
+// needed, and otherwise warns about pending security updates and a needed
+// reboot; other pending updates are informational.
+func updatesSeverity(u UpdateInfo) display.Severity {
+	if u.Pending == nil && !u.RebootNeeded() {
+		return ""
+	}
+	if u.RebootNeeded() || (u.Security != nil && *u.Security > 0) {
+		return display.SeverityWarning
+	}
+	return display.SeverityOK
+}
+
+// PendingValue renders the package count as "12 pending (3 security)" or
+// "up to date".
+func (u UpdateInfo) PendingValue() string {
+	if u.Pending == nil {
+		return ""
+	}
+	if *u.Pending == 0 {
+		return "up to date"
+	}
+	value := fmt.Sprintf("%d pending", *u.Pending)
+	if u.Security != nil && *u.Security > 0 {
+		value += fmt.Sprintf(" (%d security)", *u.Security)
+	}
+	return value
+}
+
+// RebootValue says why a reboot is needed: the newer kernel, then the
+// packages listed in reboot-required.pkgs.
+func (u UpdateInfo) RebootValue() string {
+	var reasons []string
+	if u.KernelOutdated() {
+		reasons = append(reasons, fmt.Sprintf("kernel %s installed, %s running", u.NewestKernel, u.RunningKernel))
+	}
+	if len(u.RebootPackages) > 0 {
+		reasons = append(reasons, "for "+strings.Join(u.RebootPackages, ", "))
+	}
+	if len(reasons) == 0 {
+		return "yes"
+	}
+	return strings.Join(reasons, "; ")
+}
+
+// Summary combines both for `motd check`.
+func (u UpdateInfo) Summary() string {
+	var parts []string
+	if value := u.PendingValue(); value != "" {
+		parts = append(parts, value)
+	}
+	if u.RebootNeeded() {
+		parts = append(parts, "reboot required")
+	}
+	return strings.Join(parts, ", ")
+}
+
+// updatesEnabled runs the collector only when system.updates is configured,
+// since the package manager checks can take seconds.
+func updatesEnabled(cfg ConfigAccessor) bool {
+	return cfg.Updates != nil
+}
+
+// ValidateUpdatesConfig rejects a cache_ttl that is not a positive duration.
+func ValidateUpdatesConfig(updates *config.UpdatesConfig) error {
+	_, err := updatesCacheTTL(updates)
+	return err
+}
+
+func updatesCacheTTL(updates *config.UpdatesConfig) (time.Duration, error) {
+	if updates == nil || strings.TrimSpace(updates.CacheTTL) == "" {
+		return defaultUpdatesCacheTTL, nil
+	}
+	ttl, err := time.ParseDuration(strings.TrimSpace(updates.CacheTTL))
+	if err != nil || ttl <= 0 {
+		return 0, errors.New("system.updates.cache_ttl must be a positive duration")
+	}
+	return ttl, nil
+}
+
+// readUpdates reads the reboot flags live and the package counts from the
+// cache, so a login never waits on the package manager. Counts past
+// cache_ttl are still served and marked Stale for the caller to start
+// RefreshUpdatesCache in the background.
+func readUpdates(ctx context.Context, cfg ConfigAccessor) (UpdateInfo, error) {
+	ttl, err := updatesCacheTTL(cfg.Updates)
+	if err != nil {
+		return UpdateInfo{}, err
+	}
+	info := UpdateInfo{}
+	if _, err := os.Stat(rebootRequiredPath); err == nil {
+		info.RebootRequired = true
+		if data, err := os.ReadFile(rebootRequiredPath + ".pkgs"); err == nil {
+			info.RebootPackages = parseRebootPackages(data)
+		}
+	}
+	if release, err := os.ReadFile(kernelReleasePath); err == nil {
+		info.RunningKernel = strings.TrimSpace(string(release))
+		info.NewestKernel = newestKernel(os.DirFS(kernelModulesPath), info.RunningKernel)
+	}
+
+	info.Manager = detectPackageManager()
+	if info.Manager == "" {
+		if !info.RebootNeeded() {
+			return UpdateInfo{}, errors.New("no supported package manager found")
+		}
+		return info, nil
+	}
+	info.Stale = true
+	if cachePath, err := updatesCachePath(); err == nil {
+		if cached, ok := loadPackageCheck(cachePath); ok && cached.Manager == info.Manager {
+			info.Pending, info.Security, info.CheckedAt = &cached.Pending, cached.Security, cached.CheckedAt
+			info.Stale = !cached.fresh(info.Manager, ttl, packageDatabaseChanged(info.Manager), time.Now())
+		}
+	}
+	return info, nil
+}
+
+// detectPackageManager returns the first package manager found in the
+// trusted directories. Arch needs checkupdates from pacman-contrib, which
+// checks against a copy of the sync database instead of touching the real
+// one.
+func detectPackageManager() string {
+	for _, candidate := range []struct{ command, manager string }{
+		{"apt-get", PackageManagerAPT},
+		{"dnf", PackageManagerDNF},
+		{"yum", PackageManagerYum},
+		{"checkupdates", PackageManagerPacman},
+	} {
+		if util.HasCommand(candidate.command) {
+			return candidate.manager
+		}
+	}
+	return ""
+}
+
+// packageCheck is one package manager result, as cached on disk.
+type packageCheck struct {
+	CheckedAt time.Time `json:"checked_at"`
+	Manager   string    `json:"manager"`
+	Pending   int       `json:"pending"`
+	Security  *int      `json:"security,omitempty"`
+}
+
+// fresh reports whether the cached check needs no refresh: it is for the
+// same manager, younger than ttl, and newer than the last change to the
+// package database.
+func (c packageCheck) fresh(manager string, ttl time.Duration, databaseChanged, now time.Time) bool {
+	if c.Manager != manager || c.CheckedAt.IsZero() || c.CheckedAt.After(now) {
+		return false
+	}
+	return now.Sub(c.CheckedAt) < ttl && c.CheckedAt.After(databaseChanged)
+}
+
+// updatesCachePath returns updates.json in the user cache directory next to
+// the media and bandwidth caches.
+func updatesCachePath() (string, error) {
+	cache, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cache, "motd", "updates.json"), nil
+}
+
+// RefreshUpdatesCache checks the package manager and stores the result for
+// readUpdates. It runs in the detached process StartUpdatesCacheRefresh
+// spawns and releases the refresh lock when done.
+func RefreshUpdatesCache(ctx context.Context) error {
+	cachePath, err := updatesCachePath()
+	if err != nil {
+		return err
+	}
+	defer os.Remove(filepath.Join(filepath.Dir(cachePath), updatesRefreshLockFile))
+
+	manager := detectPackageManager()
+	if manager == "" {
+		return errors.New("no supported package manager found")
+	}
+	check, err := checkPackageUpdates(ctx, manager)
+	if err != nil {
+		return err
+	}
+	check.CheckedAt, check.Manager = time.Now(), manager
+	return storePackageCheck(cachePath, check)
+}
+
+// StartUpdatesCacheRefresh takes the refresh lock and runs spawn, which
+// should start a detached process that calls RefreshUpdatesCache. It does
+// nothing when another refresh holds a recent lock.
+func StartUpdatesCacheRefresh(spawn func() error) error {
+	cachePath, err := updatesCachePath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(cachePath), 0o755); err != nil {
+		return err
+	}
+	lockPath := filepath.Join(filepath.Dir(cachePath), updatesRefreshLockFile)
+	if !util.AcquireLockFile(lockPath, time.Now(), updatesRefreshLockMaxAge) {
+		return nil
+	}
+	if err := spawn(); err != nil {
+		os.Remove(lockPath)
+		return err
+	}
+	return nil
+}
+
+func loadPackageCheck(path string) (packageCheck, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return packageCheck{}, false
+	}
+	var check packageCheck
+	if err := json.Unmarshal(data, &check); err != nil {
+		return packageCheck{}, false
+	}
+	return check, true
+}
+
+func storePackageCheck(path string, check packageCheck) error {
+	data, err := json.Marshal(check)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return config.AtomicWriteFile(path, data, 0o644)
+}
+
+// packageDatabaseChanged returns the modification time of the manager's
+// package database, or the zero time when it cannot be found.
+func packageDatabaseChanged(manager string) time.Time {
+	for _, path := range packageDatabases[manager] {
+		if info, err := os.Stat(path); err == nil {
+			return info.ModTime()
+		}
+	}
+	return time.Time{}
+}
+
+// checkPackageUpdates asks the package manager for pending updates. On apt
+// hosts the update-notifier stamp is read instead when it is newer than the
+// dpkg database.
+func checkPackageUpdates(ctx context.Context, manager string) (packageCheck, error) {
+	switch manager {
+	case PackageManagerAPT:
+		if stamp, err := os.Stat(updateNotifierPath); err == nil && !stamp.ModTime().Before(packageDatabaseChanged(manager)) {
+			if data, err := os.ReadFile(updateNotifierPath); err == nil {
+				if check, ok := parseUpdateNotifier(data); ok {
+					return check, nil
+				}
+			}
+		}
+		output, err := runUpdateCheck(ctx, nil, "apt-get", "-s", "upgrade")
+		if err != nil {
+			return packageCheck{}, err
+		}
+		return parseAptSimulation(output), nil
+	case PackageManagerDNF, PackageManagerYum:
+		output, err := runUpdateCheck(ctx, []int{100}, manager, "-q", "check-update")
+		if err != nil {
+			return packageCheck{}, err
+		}
+		check := packageCheck{Pending: countCheckUpdate(output)}
+		if output, err := runUpdateCheck(ctx, []int{100}, manager, "-q", "--security", "check-update"); err == nil {
+			security := countCheckUpdate(output)
+			check.Security = &security
+		}
+		return check, nil
+	case PackageManagerPacman:
+		output, err := runUpdateCheck(ctx, []int{2}, "checkupdates")
+		if err != nil {
+			return packageCheck{}, err
+		}
+		return packageCheck{Pending: countCheckupdates(output)}, nil
+	}
+	return packageCheck{}, fmt.Errorf("unsupported package manager %q", manager)
+}
+
+// runUpdateCheck runs name through util.SafeCommandContext. Exit codes in
+// okCodes report a result rather than a failure: dnf and yum exit 100 when
+// updates are pending, checkupdates 2 when none are.
+func runUpdateCheck(ctx context.Context, okCodes []int, name string, arg ...string) ([]byte, error) {
+	cmd, err := util.SafeCommandContext(ctx, name, arg...)
+	if err != nil {
+		return nil, err
+	}
+	output, err := cmd.Output()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && slices.Contains(okCodes, exitErr.ExitCode()) {
+		return output, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s failed: %w", name, err)
+	}
+	return output, nil
+}
+
+// parseAptSimulation counts the Inst lines of `apt-get -s upgrade`, and as
+// security updates those whose target release is a security pocket:
+//
+//	Inst openssl [3.0.2-0ubuntu1.15] (3.0.2-0ubuntu1.16 Ubuntu:22.04/jammy-updates, Ubuntu:22.04/jammy-security [amd64])
+func parseAptSimulation(output []byte) packageCheck {
+	security := 0
+	check := packageCheck{Security: &security}
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "Inst ") {
+			continue
+		}
+		check.Pending++
+		if _, origin, ok := strings.Cut(line, "("); ok && strings.Contains(strings.ToLower(origin), "security") {
+			security++
+		}
+	}
+	return check
+}
+
+var (
+	notifierPending  = regexp.MustCompile(`^(\d+) (?:updates?|packages?) can be (?:applied immediately|updated|installed immediately)`)
+	notifierSecurity = regexp.MustCompile(`^(\d+) (?:of these )?updates? (?:are|is)(?: a)? (?:standard )?security updates?`)
+)
+
+// parseUpdateNotifier reads the update-notifier stamp Ubuntu shows in its
+// own MOTD:
+//
+//	12 updates can be applied immediately.
+//	3 of these updates are standard security updates.
+func parseUpdateNotifier(data []byte) (packageCheck, bool) {
+	check := packageCheck{}
+	found := false
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if match := notifierPending.FindStringSubmatch(line); match != nil {
+			check.Pending, _ = strconv.Atoi(match[1])
+			found = true
+		} else if match := notifierSecurity.FindStringSubmatch(line); match != nil {
+			security, _ := strconv.Atoi(match[1])
+			check.Security = &security
+		}
+	}
+	if found && check.Security == nil {
+		security := 0
+		check.Security = &security
+	}
+	return check, found
+}
+
+// countCheckUpdate counts the "name.arch version repo" lines of `dnf -q
+// check-update`. Obsoleted packages follow a heading and are not counted;
+// a name too long for its column is printed on a line of its own.
+func countCheckUpdate(output []byte) int {
+	count := 0
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "Obsoleting Packages") {
+			break
+		}
+		if line == "" || line[0] == ' ' || line[0] == '\t' {
+			continue
+		}
+		fields := strings.Fields(line)
+		if strings.Contains(fields[0], ".") && (len(fields) == 1 || len(fields) == 3) {
+			count++
+		}
+	}
+	return count
+}
+
+// countCheckupdates counts the "name old -> new" lines of checkupdates.
+func countCheckupdates(output []byte) int {
+	count := 0
+	for _, line := range strings.Split(string(output), "\n") {
+		if strings.Contains(line, " -> ") {
+			count++
+		}
+	}
+	return count
+}
+
+// parseRebootPackages reads reboot-required.pkgs, which lists a package
+// once per upgrade that asked for the reboot.
+func parseRebootPackages(data []byte) []string {
+	var packages []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if line = strings.TrimSpace(line); line != "" && !slices.Contains(packages, line) {
+			packages = append(packages, line)
+		}
+	}
+	return packages
+}
+
+// newestKernel returns the newest kernel under /lib/modules, given as fsys,
+// with the running kernel's flavor. Directories without a modules.dep are
+// left behind by removed kernels and are skipped.
+func newestKernel(fsys fs.FS, running string) string {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return ""
+	}
+	flavor := kernelFlavor(running)
+	newest := ""
+	for _, entry := range entries {
+		name := entry.Name()
+		if kernelFlavor(name) != flavor {
+			continue
+		}
+		if _, err := fs.Stat(fsys, path.Join(name, "modules.dep")); err != nil {
+			continue
+		}
+		if newest == "" || compareKernelVersions(name, newest) > 0 {
+			newest = name
+		}
+	}
+	return newest
+}
+
+// kernelFlavor is everything after the ABI number of a Debian or Ubuntu
+// kernel release, such as "generic" in 6.8.0-45-generic or "cloud-amd64" in
+// 6.1.0-25-cloud-amd64, or "" for releases that end in a build number.
+func kernelFlavor(release string) string {
+	fields := strings.Split(release, "-")
+	for i := 1; i < len(fields); i++ {
+		if _, err := strconv.ParseUint(fields[i], 10, 64); err == nil {
+			return strings.Join(fields[i+1:], "-")
+		}
+	}
+	return ""
+}
+
+// compareKernelVersions compares kernel releases such as 6.8.0-45-generic
+// run by run, digits numerically and everything else as text.
+func compareKernelVersions(a, b string) int {
+	for a != "" && b != "" {
+		aRun, aRest := versionRun(a)
+		bRun, bRest := versionRun(b)
+		aNumber, aErr := strconv.ParseUint(aRun, 10, 64)
+		bNumber, bErr := strconv.ParseUint(bRun, 10, 64)
+		switch {
+		case aErr == nil && bErr == nil:
+			if aNumber != bNumber {
+				if aNumber < bNumber {
+					return -1
+				}
+				return 1
+			}
+		case aRun != bRun:
+			return strings.Compare(aRun, bRun)
+		}
+		a, b = aRest, bRest
+	}
+	return strings.Compare(a, b)
+}
+
+// versionRun splits off the leading run of digits or of non-digits.
+func versionRun(s string) (string, string) {
+	digit := s[0] >= '0' && s[0] <= '9'
+	i := 1
+	for i < len(s) && (s[i] >= '0' && s[i] <= '9') == digit {
+		i++
+	}
+	return s[:i], s[i:]
+}
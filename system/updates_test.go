@@ -0,0 +1,267 @@
+package system
+
+import (
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"motd/config"
+	"motd/display"
+)
+
+const aptSimulationFixture = `NOTE: This is only a simulation!
+      apt-get needs root privileges for real execution.
+Reading package lists...
+Building dependency tree...
+Calculating upgrade...
+The following packages will be upgraded:
+  libssl3 openssl tzdata
+3 upgraded, 0 newly installed, 0 to remove and 0 not upgraded.
+Inst libssl3 [3.0.2-0ubuntu1.15] (3.0.2-0ubuntu1.16 Ubuntu:22.04/jammy-updates, Ubuntu:22.04/jammy-security [amd64])
+Inst openssl [3.0.2-0ubuntu1.15] (3.0.2-0ubuntu1.16 Ubuntu:22.04/jammy-updates, Ubuntu:22.04/jammy-security [amd64])
+Inst tzdata [2024a-0ubuntu0.22.04] (2024a-0ubuntu0.22.04.1 Ubuntu:22.04/jammy-updates [all])
+Conf libssl3 (3.0.2-0ubuntu1.16 Ubuntu:22.04/jammy-updates, Ubuntu:22.04/jammy-security [amd64])
+Conf openssl (3.0.2-0ubuntu1.16 Ubuntu:22.04/jammy-updates, Ubuntu:22.04/jammy-security [amd64])
+Conf tzdata (2024a-0ubuntu0.22.04.1 Ubuntu:22.04/jammy-updates [all])
+`
+
+const dnfCheckUpdateFixture = `
+kernel.x86_64                         6.10.12-200.fc40             updates
+openssl-libs.x86_64                   1:3.2.2-3.fc40               updates
+python3-very-long-package-name-for-wrapping.noarch
+                                      2.1.0-1.fc40                 updates
+Obsoleting Packages
+grub2-tools.x86_64                    1:2.06-120.fc40              updates
+    grub2-tools.x86_64                1:2.06-116.fc40              @updates
+`
+
+func TestParsePackageManagerOutput(t *testing.T) {
+	aptSecurity := 2
+	tests := []struct {
+		name     string
+		parse    func() packageCheck
+		pending  int
+		security *int
+	}{
+		{
+			name:     "apt-get simulation",
+			parse:    func() packageCheck { return parseAptSimulation([]byte(aptSimulationFixture)) },
+			pending:  3,
+			security: &aptSecurity,
+		},
+		{
+			name:    "dnf check-update",
+			parse:   func() packageCheck { return packageCheck{Pending: countCheckUpdate([]byte(dnfCheckUpdateFixture))} },
+			pending: 3,
+		},
+		{
+			name: "checkupdates",
+			parse: func() packageCheck {
+				return packageCheck{Pending: countCheckupdates([]byte("linux 6.10.1.arch1-1 -> 6.10.2.arch1-1\nopenssl 3.3.1-1 -> 3.3.2-1\n"))}
+			},
+			pending: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check := tt.parse()
+			if check.Pending != tt.pending {
+				t.Fatalf("expected %d pending, got %d", tt.pending, check.Pending)
+			}
+			if (check.Security == nil) != (tt.security == nil) || (check.Security != nil && *check.Security != *tt.security) {
+				t.Fatalf("expected security %v, got %v", tt.security, check.Security)
+			}
+		})
+	}
+}
+
+func TestParseUpdateNotifier(t *testing.T) {
+	tests := []struct {
+		name     string
+		stamp    string
+		found    bool
+		pending  int
+		security int
+	}{
+		{
+			name:     "jammy",
+			stamp:    "\n12 updates can be applied immediately.\n3 of these updates are standard security updates.\nTo see these additional updates run: apt list --upgradable\n\n",
+			found:    true,
+			pending:  12,
+			security: 3,
+		},
+		{
+			name:     "focal",
+			stamp:    "\n1 package can be updated.\n1 update is a security update.\n",
+			found:    true,
+			pending:  1,
+			security: 1,
+		},
+		{
+			name:  "no security updates",
+			stamp: "Expanded Security Maintenance for Applications is not enabled.\n\n0 updates can be applied immediately.\n\n5 additional security updates can be applied with ESM Apps.\n",
+			found: true,
+		},
+		{
+			name:  "empty stamp",
+			stamp: "\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check, found := parseUpdateNotifier([]byte(tt.stamp))
+			if found != tt.found {
+				t.Fatalf("expected found %v, got %v", tt.found, found)
+			}
+			if !found {
+				return
+			}
+			if check.Pending != tt.pending || check.Security == nil || *check.Security != tt.security {
+				t.Fatalf("expected %d pending (%d security), got %+v", tt.pending, tt.security, check)
+			}
+		})
+	}
+}
+
+func TestNewestKernel(t *testing.T) {
+	modules := fstest.MapFS{
+		"6.8.0-45-generic/modules.dep":    {Data: []byte{}},
+		"6.8.0-47-generic/modules.dep":    {Data: []byte{}},
+		"6.8.0-49-generic/modules.order":  {Data: []byte{}},
+		"6.8.0-48-lowlatency/modules.dep": {Data: []byte{}},
+	}
+	if got := newestKernel(modules, "6.8.0-45-generic"); got != "6.8.0-47-generic" {
+		t.Fatalf("expected 6.8.0-47-generic, got %q", got)
+	}
+
+	debian := fstest.MapFS{
+		"6.1.0-25-cloud-amd64/modules.dep": {Data: []byte{}},
+		"6.1.0-26-amd64/modules.dep":       {Data: []byte{}},
+		"6.1.0-27-rt-amd64/modules.dep":    {Data: []byte{}},
+	}
+	if got := newestKernel(debian, "6.1.0-25-cloud-amd64"); got != "6.1.0-25-cloud-amd64" {
+		t.Fatalf("expected the cloud kernel to ignore other flavors, got %q", got)
+	}
+	if got := newestKernel(debian, "6.1.0-24-amd64"); got != "6.1.0-26-amd64" {
+		t.Fatalf("expected 6.1.0-26-amd64, got %q", got)
+	}
+
+	arch := fstest.MapFS{
+		"6.9.7-arch1-1/modules.dep":  {Data: []byte{}},
+		"6.10.2-arch1-1/modules.dep": {Data: []byte{}},
+	}
+	info := UpdateInfo{RunningKernel: "6.9.7-arch1-1", NewestKernel: newestKernel(arch, "6.9.7-arch1-1")}
+	if !info.KernelOutdated() {
+		t.Fatalf("expected 6.10.2 to be newer than 6.9.7, got %+v", info)
+	}
+	if got := info.RebootValue(); got != "kernel 6.10.2-arch1-1 installed, 6.9.7-arch1-1 running" {
+		t.Fatalf("RebootValue() = %q", got)
+	}
+}
+
+func TestUpdateInfoLines(t *testing.T) {
+	pending, security, none := 12, 3, 0
+	tests := []struct {
+		name     string
+		info     UpdateInfo
+		want     []string
+		severity display.Severity
+	}{
+		{
+			name:     "security updates and reboot",
+			info:     UpdateInfo{Pending: &pending, Security: &security, RebootRequired: true, RebootPackages: parseRebootPackages([]byte("libc6\nlinux-image-6.8.0-47-generic\nlibc6\n"))},
+			want:     []string{"Updates=12 pending (3 security)", "Reboot required=for libc6, linux-image-6.8.0-47-generic"},
+			severity: display.SeverityWarning,
+		},
+		{
+			name:     "up to date",
+			info:     UpdateInfo{Pending: &none, Security: &none, RunningKernel: "6.8.0-47-generic", NewestKernel: "6.8.0-47-generic"},
+			want:     []string{"Updates=up to date"},
+			severity: display.SeverityOK,
+		},
+		{
+			name:     "package check failed",
+			info:     UpdateInfo{RebootRequired: true},
+			want:     []string{"Reboot required=yes"},
+			severity: display.SeverityWarning,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := updatesSeverity(tt.info); got != tt.severity {
+				t.Fatalf("expected severity %q, got %q", tt.severity, got)
+			}
+			tt.info.Severity = tt.severity
+			lines := tt.info.Lines()
+			if len(lines) != len(tt.want) {
+				t.Fatalf("expected %d lines, got %+v", len(tt.want), lines)
+			}
+			for i, line := range lines {
+				if got := line.Label + "=" + line.Value; got != tt.want[i] {
+					t.Fatalf("line %d = %q, want %q", i, got, tt.want[i])
+				}
+			}
+			if tt.info.Pending != nil && lines[0].Color != tt.severity.Color() {
+				t.Fatalf("expected the updates row in the %q color", tt.severity)
+			}
+		})
+	}
+}
+
+func TestPackageCheckCache(t *testing.T) {
+	now := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	path := filepath.Join(t.TempDir(), "motd", "updates.json")
+	security := 2
+	_ = storePackageCheck(path, packageCheck{CheckedAt: now.Add(-10 * time.Minute), Manager: PackageManagerAPT, Pending: 5, Security: &security})
+
+	cached, ok := loadPackageCheck(path)
+	if !ok || cached.Pending != 5 || cached.Security == nil || *cached.Security != 2 {
+		t.Fatalf("expected the stored check back, got %+v, %v", cached, ok)
+	}
+	tests := []struct {
+		name            string
+		manager         string
+		ttl             time.Duration
+		databaseChanged time.Time
+		fresh           bool
+	}{
+		{name: "within ttl", manager: PackageManagerAPT, ttl: time.Hour, databaseChanged: now.Add(-24 * time.Hour), fresh: true},
+		{name: "expired", manager: PackageManagerAPT, ttl: 5 * time.Minute, databaseChanged: now.Add(-24 * time.Hour)},
+		{name: "packages changed since", manager: PackageManagerAPT, ttl: time.Hour, databaseChanged: now.Add(-time.Minute)},
+		{name: "other manager", manager: PackageManagerDNF, ttl: time.Hour},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cached.fresh(tt.manager, tt.ttl, tt.databaseChanged, now); got != tt.fresh {
+				t.Fatalf("fresh() = %v, want %v", got, tt.fresh)
+			}
+		})
+	}
+}
+
+func TestStartUpdatesCacheRefreshTakesTheLock(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	spawned := 0
+	spawn := func() error {
+		spawned++
+		return nil
+	}
+	for range 2 {
+		if err := StartUpdatesCacheRefresh(spawn); err != nil {
+			t.Fatalf("StartUpdatesCacheRefresh failed: %v", err)
+		}
+	}
+	if spawned != 1 {
+		t.Fatalf("expected a held lock to block a second refresh, spawned %d", spawned)
+	}
+}
+
+func TestValidateUpdatesConfig(t *testing.T) {
+	if err := ValidateUpdatesConfig(&config.UpdatesConfig{CacheTTL: "-1h"}); err == nil {
+		t.Fatal("expected a negative cache_ttl to be rejected")
+	}
+	if err := ValidateUpdatesConfig(&config.UpdatesConfig{CacheTTL: "6h"}); err != nil {
+		t.Fatalf("expected 6h to be accepted, got %v", err)
+	}
+}
@@ -0,0 +1,150 @@
+package system
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"os/user"
+	"time"
+)
+
+const (
+	utmpPath = "/var/run/utmp"
+	wtmpPath = "/var/log/wtmp"
+
+	// utmpRecordSize is sizeof(struct utmp) in glibc, which keeps 32-bit
+	// times so the layout is the same on 32 and 64-bit hosts.
+	utmpRecordSize = 384
+	// utmpLoginProcess is ut_type for a failed login in btmp, and
+	// utmpUserProcess for a login session.
+	utmpLoginProcess = 6
+	utmpUserProcess  = 7
+)
+
+// UserSession is one login session from utmp. Host is empty for local
+// logins.
+type UserSession struct {
+	User      string    `json:"user"`
+	TTY       string    `json:"tty"`
+	Host      string    `json:"host,omitempty"`
+	LoginTime time.Time `json:"login_time"`
+}
+
+// LastLogin is the current user's previous login from wtmp, as
+// pam_lastlog showed it. LoginsSince counts the logins by any user after
+// it, the current session aside.
+type LastLogin struct {
+	User        string    `json:"user"`
+	TTY         string    `json:"tty"`
+	Host        string    `json:"host,omitempty"`
+	Time        time.Time `json:"time"`
+	LoginsSince int       `json:"logins_since"`
+}
+
+// readUtmpFile returns the records of kind in a utmp, wtmp or btmp file.
+func readUtmpFile(path string, kind int16) ([]UserSession, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return parseUtmp(bufio.NewReader(file), binary.NativeEndian, kind)
+}
+
+// parseUtmp reads struct utmp records and keeps those of kind: sessions
+// are USER_PROCESS, and btmp records failed logins as LOGIN_PROCESS. Boot,
+// run level and logout records are skipped, as is a truncated last record
+// left by a writer mid-append.
+func parseUtmp(r io.Reader, order binary.ByteOrder, kind int16) ([]UserSession, error) {
+	var sessions []UserSession
+	record := make([]byte, utmpRecordSize)
+	for {
+		if _, err := io.ReadFull(r, record); err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				return sessions, nil
+			}
+			return nil, err
+		}
+		if int16(order.Uint16(record[0:2])) != kind {
+			continue
+		}
+		session := UserSession{
+			TTY:  utmpString(record[8:40]),
+			User: utmpString(record[44:76]),
+			Host: utmpString(record[76:332]),
+		}
+		if session.User == "" {
+			continue
+		}
+		seconds := int64(int32(order.Uint32(record[340:344])))
+		micros := int64(int32(order.Uint32(record[344:348])))
+		session.LoginTime = time.Unix(seconds, micros*int64(time.Microsecond))
+		sessions = append(sessions, session)
+	}
+}
+
+// utmpString trims a NUL-padded utmp field.
+func utmpString(field []byte) string {
+	if i := bytes.IndexByte(field, 0); i >= 0 {
+		field = field[:i]
+	}
+	return string(field)
+}
+
+// newUserInfo counts the unique users among the utmp sessions.
+func newUserInfo(sessions []UserSession) UserInfo {
+	users := make(map[string]bool)
+	for _, session := range sessions {
+		users[session.User] = true
+	}
+	return UserInfo{Count: len(users), Sessions: sessions}
+}
+
+// currentSession returns the session of username on tty, the terminal motd
+// runs on, if utmp has one.
+func (u UserInfo) currentSession(username, tty string) *UserSession {
+	for i := range u.Sessions {
+		if u.Sessions[i].User == username && u.Sessions[i].TTY == tty {
+			return &u.Sessions[i]
+		}
+	}
+	return nil
+}
+
+// lastLogin finds the latest login of username in wtmp other than current,
+// the session motd is rendering for, and counts the logins since it.
+func lastLogin(logins []UserSession, username string, current *UserSession) *LastLogin {
+	isCurrent := func(login UserSession) bool {
+		return current != nil && login.TTY == current.TTY && login.User == current.User && login.LoginTime.Equal(current.LoginTime)
+	}
+	var last *UserSession
+	for i, login := range logins {
+		if login.User != username || isCurrent(login) {
+			continue
+		}
+		if last == nil || !login.LoginTime.Before(last.LoginTime) {
+			last = &logins[i]
+		}
+	}
+	if last == nil {
+		return nil
+	}
+	result := &LastLogin{User: last.User, TTY: last.TTY, Host: last.Host, Time: last.LoginTime}
+	for _, login := range logins {
+		if login.LoginTime.After(last.LoginTime) && !isCurrent(login) {
+			result.LoginsSince++
+		}
+	}
+	return result
+}
+
+// currentUsername is the user motd runs as.
+func currentUsername() string {
+	if current, err := user.Current(); err == nil {
+		return current.Username
+	}
+	return os.Getenv("USER")
+}
@@ -0,0 +1,91 @@
+package system
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+)
+
+// utmpFixture encodes one glibc struct utmp record in little-endian order.
+func utmpFixture(kind int16, tty, user, host string, at time.Time) []byte {
+	record := make([]byte, utmpRecordSize)
+	binary.LittleEndian.PutUint16(record[0:2], uint16(kind))
+	binary.LittleEndian.PutUint32(record[4:8], 4242)
+	copy(record[8:40], tty)
+	copy(record[44:76], user)
+	copy(record[76:332], host)
+	binary.LittleEndian.PutUint32(record[340:344], uint32(at.Unix()))
+	return record
+}
+
+func TestParseUtmp(t *testing.T) {
+	boot := time.Date(2026, 10, 12, 6, 0, 0, 0, time.Local)
+	var data bytes.Buffer
+	data.Write(utmpFixture(2, "~", "reboot", "6.8.0-45-generic", boot))
+	data.Write(utmpFixture(6, "tty1", "LOGIN", "", boot))
+	data.Write(utmpFixture(7, "pts/0", "alice", "192.0.2.7", boot.Add(3*time.Hour)))
+	data.Write(utmpFixture(8, "pts/1", "", "", boot.Add(4*time.Hour)))
+	data.Write(utmpFixture(7, "tty1", "bob", "", boot.Add(5*time.Hour)))
+	data.Write(utmpFixture(7, "pts/2", "alice", "2001:db8::7", boot.Add(6*time.Hour))[:200])
+
+	sessions, err := parseUtmp(&data, binary.LittleEndian, utmpUserProcess)
+	if err != nil {
+		t.Fatalf("parseUtmp failed: %v", err)
+	}
+	want := []UserSession{
+		{User: "alice", TTY: "pts/0", Host: "192.0.2.7", LoginTime: boot.Add(3 * time.Hour)},
+		{User: "bob", TTY: "tty1", LoginTime: boot.Add(5 * time.Hour)},
+	}
+	if len(sessions) != len(want) {
+		t.Fatalf("expected %d sessions, got %+v", len(want), sessions)
+	}
+	for i := range want {
+		if sessions[i].User != want[i].User || sessions[i].TTY != want[i].TTY || sessions[i].Host != want[i].Host || !sessions[i].LoginTime.Equal(want[i].LoginTime) {
+			t.Fatalf("session %d = %+v, want %+v", i, sessions[i], want[i])
+		}
+	}
+}
+
+func TestLastLogin(t *testing.T) {
+	start := time.Date(2026, 10, 13, 9, 12, 0, 0, time.Local)
+	logins := []UserSession{
+		{User: "alice", TTY: "pts/0", Host: "198.51.100.4", LoginTime: start.Add(-48 * time.Hour)},
+		{User: "alice", TTY: "pts/1", Host: "192.0.2.7", LoginTime: start},
+		{User: "bob", TTY: "tty1", LoginTime: start.Add(time.Hour)},
+		{User: "carol", TTY: "pts/3", Host: "203.0.113.9", LoginTime: start.Add(2 * time.Hour)},
+		{User: "alice", TTY: "pts/2", Host: "192.0.2.7", LoginTime: start.Add(3 * time.Hour)},
+	}
+	info := newUserInfo([]UserSession{logins[3], logins[4]})
+	if info.Count != 2 {
+		t.Fatalf("expected 2 unique users, got %d", info.Count)
+	}
+
+	last := lastLogin(logins, "alice", info.currentSession("alice", "pts/2"))
+	if last == nil || last.TTY != "pts/1" || last.LoginsSince != 2 {
+		t.Fatalf("expected the pts/1 login with 2 logins since, got %+v", last)
+	}
+	info.LastLogin = last
+	lines := info.Lines()
+	want := []string{
+		"Logged in users=2",
+		"Session (pts/3)=carol from 203.0.113.9",
+		"Session (pts/2)=alice from 192.0.2.7",
+		"Last login=Tue Oct 13 09:12 from 192.0.2.7 (2 logins since)",
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("expected %d lines, got %+v", len(want), lines)
+	}
+	for i, line := range lines {
+		if got := line.Label + "=" + line.Value; got != want[i] {
+			t.Fatalf("line %d = %q, want %q", i, got, want[i])
+		}
+	}
+
+	if last := lastLogin(logins, "alice", nil); last == nil || last.TTY != "pts/2" || last.LoginsSince != 0 {
+		t.Fatalf("expected the latest login outside a terminal, got %+v", last)
+	}
+	if last := lastLogin(logins, "dave", nil); last != nil {
+		t.Fatalf("expected no last login for a new user, got %+v", last)
+	}
+}
@@ -3,36 +3,31 @@
 package system
 
 import (
+	"context"
 	"fmt"
 	"strconv"
 	"strings"
 	"time"
 
-	"motd/display"
 	"motd/util"
 )
 
-func ShowOS(cfg ConfigAccessor, debug bool) {
-	info, ok := getWindowsOSInfo()
+func readOS(ctx context.Context, cfg ConfigAccessor) (OSInfo, error) {
+	info, ok := getWindowsOSInfo(ctx)
 	if !ok {
-		info = windowsOSInfo{Version: "Unknown", Edition: "Unknown", Build: "Unknown"}
+		return OSInfo{}, fmt.Errorf("Windows version unavailable")
 	}
 
-	display.DotLabel("OS")
 	osName := "Windows"
 	if version := strings.TrimSpace(info.Version); version != "" {
 		osName += " " + version
 	}
-	fmt.Printf("%s%s%s\n", display.Blue, osName, display.Reset)
-	display.DotLabel("Edition")
-	fmt.Printf("%s%s%s\n", display.Blue, valueOrUnknown(info.Edition), display.Reset)
-	display.DotLabel("Build")
-	fmt.Printf("%s%s%s\n", display.Blue, valueOrUnknown(info.Build), display.Reset)
+	return OSInfo{Name: osName, Edition: valueOrUnknown(info.Edition), Build: valueOrUnknown(info.Build)}, nil
 }
 
-func getWindowsOSInfo() (windowsOSInfo, bool) {
+func getWindowsOSInfo(ctx context.Context) (windowsOSInfo, bool) {
 	psCommand := "$os = Get-CimInstance Win32_OperatingSystem; $cv = Get-ItemProperty 'HKLM:\\SOFTWARE\\Microsoft\\Windows NT\\CurrentVersion'; $build = [string]$os.BuildNumber; if ($null -ne $cv.UBR) { $build = '{0}.{1}' -f $os.BuildNumber,$cv.UBR }; '{0}|{1}|{2}' -f $os.Caption,$os.BuildNumber,$build"
-	cmd, cmdErr := util.SafeCommand("powershell", "-NoProfile", "-Command", psCommand)
+	cmd, cmdErr := util.SafeCommandContext(ctx, "powershell", "-NoProfile", "-Command", psCommand)
 	if cmdErr == nil {
 		output, err := cmd.Output()
 		if err == nil {
@@ -42,7 +37,7 @@ func getWindowsOSInfo() (windowsOSInfo, bool) {
 		}
 	}
 
-	cmd, cmdErr = util.SafeCommand("wmic", "os", "get", "Caption,BuildNumber", "/value")
+	cmd, cmdErr = util.SafeCommandContext(ctx, "wmic", "os", "get", "Caption,BuildNumber", "/value")
 	if cmdErr != nil {
 		return windowsOSInfo{}, false
 	}
@@ -54,19 +49,16 @@ func getWindowsOSInfo() (windowsOSInfo, bool) {
 	return parseWindowsOSWMIC(output)
 }
 
-func ShowUptime(cfg ConfigAccessor, debug bool) {
-	bootTime, ok := getWindowsBootTime()
-	uptime := "unknown"
-	if ok {
-		uptime = FormatDuration(time.Since(bootTime))
+func readUptime(ctx context.Context, cfg ConfigAccessor) (UptimeInfo, error) {
+	bootTime, ok := getWindowsBootTime(ctx)
+	if !ok {
+		return UptimeInfo{}, fmt.Errorf("boot time unavailable")
 	}
-
-	display.DotLabel("Uptime")
-	fmt.Printf("%s%s%s\n", display.Blue, uptime, display.Reset)
+	return UptimeInfo{Seconds: time.Since(bootTime).Seconds()}, nil
 }
 
-func getWindowsBootTime() (time.Time, bool) {
-	cmd, cmdErr := util.SafeCommand("powershell", "-NoProfile", "-Command", "(Get-CimInstance Win32_OperatingSystem).LastBootUpTime")
+func getWindowsBootTime(ctx context.Context) (time.Time, bool) {
+	cmd, cmdErr := util.SafeCommandContext(ctx, "powershell", "-NoProfile", "-Command", "(Get-CimInstance Win32_OperatingSystem).LastBootUpTime")
 	if cmdErr == nil {
 		output, err := cmd.Output()
 		if err == nil {
@@ -76,7 +68,7 @@ func getWindowsBootTime() (time.Time, bool) {
 		}
 	}
 
-	cmd, cmdErr = util.SafeCommand("wmic", "os", "get", "LastBootUpTime", "/value")
+	cmd, cmdErr = util.SafeCommandContext(ctx, "wmic", "os", "get", "LastBootUpTime", "/value")
 	if cmdErr != nil {
 		return time.Time{}, false
 	}
@@ -92,18 +84,17 @@ func getWindowsBootTime() (time.Time, bool) {
 	return time.Time{}, false
 }
 
-func ShowLoad(cfg ConfigAccessor, debug bool) {
-	load, ok := getWindowsCPUPercent()
+func readLoad(ctx context.Context, cfg ConfigAccessor) (LoadInfo, error) {
+	load, ok := getWindowsCPUPercent(ctx)
 	if !ok {
-		return
+		return LoadInfo{}, fmt.Errorf("CPU load percentage unavailable")
 	}
-
-	display.DotLabel("CPU Load")
-	fmt.Printf("%s%d%%%s\n", display.Blue, load, display.Reset)
+	pct := float64(load)
+	return LoadInfo{CPUPercent: &pct}, nil
 }
 
-func getWindowsCPUPercent() (int, bool) {
-	cmd, cmdErr := util.SafeCommand("powershell", "-NoProfile", "-Command", "(Get-CimInstance Win32_Processor | Measure-Object -Property LoadPercentage -Average).Average")
+func getWindowsCPUPercent(ctx context.Context) (int, bool) {
+	cmd, cmdErr := util.SafeCommandContext(ctx, "powershell", "-NoProfile", "-Command", "(Get-CimInstance Win32_Processor | Measure-Object -Property LoadPercentage -Average).Average")
 	if cmdErr == nil {
 		output, err := cmd.Output()
 		if err == nil {
@@ -113,7 +104,7 @@ func getWindowsCPUPercent() (int, bool) {
 		}
 	}
 
-	cmd, cmdErr = util.SafeCommand("wmic", "cpu", "get", "LoadPercentage", "/value")
+	cmd, cmdErr = util.SafeCommandContext(ctx, "wmic", "cpu", "get", "LoadPercentage", "/value")
 	if cmdErr != nil {
 		return 0, false
 	}
@@ -125,20 +116,16 @@ func getWindowsCPUPercent() (int, bool) {
 	return parseWindowsCPUPercent(output)
 }
 
-func ShowMemory(cfg ConfigAccessor, debug bool) {
-	total, free, ok := getWindowsMemoryBytes()
-	if !ok {
-		return
+func readMemory(ctx context.Context, cfg ConfigAccessor) (MemoryInfo, error) {
+	total, free, ok := getWindowsMemoryBytes(ctx)
+	if !ok || total == 0 || free > total {
+		return MemoryInfo{}, fmt.Errorf("memory totals unavailable")
 	}
-
-	usedGB := float64(total-free) / float64(GB)
-	totalGB := float64(total) / float64(GB)
-	display.DotLabel("Memory")
-	fmt.Printf("%s%.2f GB / %.2f GB%s\n", display.Blue, usedGB, totalGB, display.Reset)
+	return newMemoryInfo(total, total-free), nil
 }
 
-func getWindowsMemoryBytes() (uint64, uint64, bool) {
-	cmd, cmdErr := util.SafeCommand("powershell", "-NoProfile", "-Command", "$os = Get-CimInstance Win32_OperatingSystem; '{0},{1}' -f $os.TotalVisibleMemorySize,$os.FreePhysicalMemory")
+func getWindowsMemoryBytes(ctx context.Context) (uint64, uint64, bool) {
+	cmd, cmdErr := util.SafeCommandContext(ctx, "powershell", "-NoProfile", "-Command", "$os = Get-CimInstance Win32_OperatingSystem; '{0},{1}' -f $os.TotalVisibleMemorySize,$os.FreePhysicalMemory")
 	if cmdErr == nil {
 		output, err := cmd.Output()
 		if err == nil {
@@ -148,7 +135,7 @@ func getWindowsMemoryBytes() (uint64, uint64, bool) {
 		}
 	}
 
-	cmd, cmdErr = util.SafeCommand("wmic", "os", "get", "TotalVisibleMemorySize,FreePhysicalMemory", "/value")
+	cmd, cmdErr = util.SafeCommandContext(ctx, "wmic", "os", "get", "TotalVisibleMemorySize,FreePhysicalMemory", "/value")
 	if cmdErr != nil {
 		return 0, 0, false
 	}
@@ -172,22 +159,37 @@ func getWindowsMemoryBytes() (uint64, uint64, bool) {
 	return totalKB * 1024, freeKB * 1024, true
 }
 
-func ShowBandwidth(cfg ConfigAccessor, debug bool) {}
+func readBandwidth(ctx context.Context, cfg ConfigAccessor) (BandwidthInfo, error) {
+	return BandwidthInfo{}, fmt.Errorf("bandwidth accounting is not supported on Windows")
+}
+
+func readInterfaceBandwidth(ctx context.Context, cfg ConfigAccessor, names []string) (map[string]BandwidthInfo, error) {
+	return nil, fmt.Errorf("bandwidth accounting is not supported on Windows")
+}
+
+// interfaceLink leaves the state to the interface flags on Windows.
+func interfaceLink(name string) (string, int, bool) {
+	return "", 0, false
+}
 
-func ShowUser(cfg ConfigAccessor, debug bool) {}
+func readUsers(ctx context.Context, cfg ConfigAccessor) (UserInfo, error) {
+	return UserInfo{}, fmt.Errorf("logged in users are not supported on Windows")
+}
 
-func ShowProcesses(cfg ConfigAccessor, debug bool) {
-	count, ok := getWindowsProcessCount()
+func readFailedLogins(ctx context.Context, cfg ConfigAccessor) (FailedLogins, error) {
+	return FailedLogins{}, fmt.Errorf("failed logins are not supported on Windows")
+}
+
+func readProcesses(ctx context.Context, cfg ConfigAccessor) (ProcessInfo, error) {
+	count, ok := getWindowsProcessCount(ctx)
 	if !ok {
-		return
+		return ProcessInfo{}, fmt.Errorf("process count unavailable")
 	}
-
-	display.DotLabel("Processes")
-	fmt.Printf("%s%d%s\n", display.Blue, count, display.Reset)
+	return ProcessInfo{Count: count}, nil
 }
 
-func getWindowsProcessCount() (int, bool) {
-	cmd, cmdErr := util.SafeCommand("powershell", "-NoProfile", "-Command", "(Get-CimInstance Win32_Process).Count")
+func getWindowsProcessCount(ctx context.Context) (int, bool) {
+	cmd, cmdErr := util.SafeCommandContext(ctx, "powershell", "-NoProfile", "-Command", "(Get-CimInstance Win32_Process).Count")
 	if cmdErr == nil {
 		output, err := cmd.Output()
 		if err == nil {
@@ -197,7 +199,7 @@ func getWindowsProcessCount() (int, bool) {
 		}
 	}
 
-	cmd, cmdErr = util.SafeCommand("tasklist", "/nh")
+	cmd, cmdErr = util.SafeCommandContext(ctx, "tasklist", "/nh")
 	if cmdErr != nil {
 		return 0, false
 	}
@@ -209,22 +211,21 @@ func getWindowsProcessCount() (int, bool) {
 	return countWindowsTasklistProcesses(output), true
 }
 
-func ShowDisk(cfg ConfigAccessor, debug bool) {
-	disks, ok := getWindowsDiskInfo()
+func readDisks(ctx context.Context, cfg ConfigAccessor) (DiskList, error) {
+	drives, ok := getWindowsDiskInfo(ctx)
 	if !ok {
-		return
+		return nil, fmt.Errorf("logical disk query failed")
 	}
 
-	for _, disk := range disks {
-		usedGB := float64(disk.UsedBytes) / float64(GB)
-		totalGB := float64(disk.TotalBytes) / float64(GB)
-		display.DotLabel(fmt.Sprintf("Disk (%s)", disk.Drive))
-		fmt.Printf("%s%.2f GB / %.2f GB%s\n", display.Blue, usedGB, totalGB, display.Reset)
+	disks := make(DiskList, 0, len(drives))
+	for _, drive := range drives {
+		disks = append(disks, newDiskUsage(fmt.Sprintf("Disk (%s)", drive.Drive), drive.Drive, drive.TotalBytes, drive.UsedBytes))
 	}
+	return disks, nil
 }
 
-func getWindowsDiskInfo() ([]windowsDiskInfo, bool) {
-	cmd, cmdErr := util.SafeCommand("powershell", "-NoProfile", "-Command", "Get-CimInstance Win32_LogicalDisk -Filter 'DriveType=3' | Select-Object DeviceID,Size,FreeSpace | Format-Csv -NoHeader")
+func getWindowsDiskInfo(ctx context.Context) ([]windowsDiskInfo, bool) {
+	cmd, cmdErr := util.SafeCommandContext(ctx, "powershell", "-NoProfile", "-Command", "Get-CimInstance Win32_LogicalDisk -Filter 'DriveType=3' | Select-Object DeviceID,Size,FreeSpace | Format-Csv -NoHeader")
 	if cmdErr == nil {
 		output, err := cmd.Output()
 		if err == nil {
@@ -232,7 +233,7 @@ func getWindowsDiskInfo() ([]windowsDiskInfo, bool) {
 		}
 	}
 
-	cmd, cmdErr = util.SafeCommand("wmic", "logicaldisk", "where", "drivetype=3", "get", "DeviceID,Size,FreeSpace", "/format:csv")
+	cmd, cmdErr = util.SafeCommandContext(ctx, "wmic", "logicaldisk", "where", "drivetype=3", "get", "DeviceID,Size,FreeSpace", "/format:csv")
 	if cmdErr != nil {
 		return nil, false
 	}
@@ -244,38 +245,36 @@ func getWindowsDiskInfo() ([]windowsDiskInfo, bool) {
 	return parseWindowsDiskWMIC(output), true
 }
 
-func getDefaultInterface() string {
+func getDefaultInterface(ctx context.Context) string {
 	return ""
 }
 
-func ShowTemp(cfg ConfigAccessor, debug bool) {
+func readTemperature(ctx context.Context, cfg ConfigAccessor) (TemperatureInfo, error) {
 	if !util.HasCommand("powershell") {
-		return
+		return TemperatureInfo{}, fmt.Errorf("powershell not available")
 	}
 
-	cmd, cmdErr := util.SafeCommand("powershell", "-NoProfile", "-Command", "Get-CimInstance MSAcpi_ThermalZoneTemperature -Namespace 'root/wmi' | Select-Object -ExpandProperty CurrentTemperature")
+	cmd, cmdErr := util.SafeCommandContext(ctx, "powershell", "-NoProfile", "-Command", "Get-CimInstance MSAcpi_ThermalZoneTemperature -Namespace 'root/wmi' | Select-Object -ExpandProperty CurrentTemperature")
 	if cmdErr == nil {
 		output, err := cmd.Output()
 		if err == nil {
 			if temp, ok := parseWindowsTemperature(output); ok {
-				display.DotLabel("CPU Temperature")
-				fmt.Printf("%s%.0f°C%s\n", display.Red, temp, display.Reset)
-				return
+				return TemperatureInfo{Celsius: temp}, nil
 			}
 		}
 	}
 
-	cmd, cmdErr = util.SafeCommand("wmic", "/namespace:\\\\root\\wmi", "path", "MSAcpi_ThermalZoneTemperature", "get", "CurrentTemperature", "/value")
+	cmd, cmdErr = util.SafeCommandContext(ctx, "wmic", "/namespace:\\\\root\\wmi", "path", "MSAcpi_ThermalZoneTemperature", "get", "CurrentTemperature", "/value")
 	if cmdErr != nil {
-		return
+		return TemperatureInfo{}, cmdErr
 	}
 	output, err := cmd.Output()
 	if err != nil {
-		return
+		return TemperatureInfo{}, err
 	}
 
 	if temp, ok := parseWindowsTemperature(output); ok {
-		display.DotLabel("CPU Temperature")
-		fmt.Printf("%s%.0f°C%s\n", display.Red, temp, display.Reset)
+		return TemperatureInfo{Celsius: temp}, nil
 	}
+	return TemperatureInfo{}, fmt.Errorf("no thermal zone reported a valid temperature")
 }
@@ -0,0 +1,167 @@
+package system
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"motd/display"
+	"motd/util"
+)
+
+// ZFSPoolList is every imported ZFS pool, in `zpool list` order.
+type ZFSPoolList []ZFSPool
+
+// ZFSPool is the health and allocation of one pool as zpool reports it,
+// which unlike statfs on a dataset accounts for snapshots and reservations.
+// FragmentationPercent is nil when the pool does not report it, and
+// LastScrub is nil when the pool has never finished a scrub.
+type ZFSPool struct {
+	Name                 string           `json:"name"`
+	Health               string           `json:"health"`
+	SizeBytes            uint64           `json:"size_bytes"`
+	AllocatedBytes       uint64           `json:"allocated_bytes"`
+	FreeBytes            uint64           `json:"free_bytes"`
+	CapacityPercent      float64          `json:"capacity_percent"`
+	FragmentationPercent *float64         `json:"fragmentation_percent,omitempty"`
+	LastScrub            *time.Time       `json:"last_scrub,omitempty"`
+	ScrubInProgress      bool             `json:"scrub_in_progress,omitempty"`
+	Severity             display.Severity `json:"severity,omitempty"`
+}
+
+// zfsEnabled skips the collector on hosts without the ZFS userland, so they
+// never report it as timed out.
+func zfsEnabled(cfg ConfigAccessor) bool {
+	return util.HasCommand("zpool")
+}
+
+func readZFSPools(ctx context.Context, cfg ConfigAccessor) (ZFSPoolList, error) {
+	cmd, err := util.SafeCommandContext(ctx, "zpool", "list", "-Hp", "-o", "name,health,size,alloc,free,cap,frag")
+	if err != nil {
+		return nil, err
+	}
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("zpool list failed: %w", err)
+	}
+	pools, err := parseZpoolList(output)
+	if err != nil {
+		return nil, err
+	}
+	if len(pools) == 0 {
+		return nil, errors.New("no ZFS pools imported")
+	}
+
+	// Scrub times are extra detail; a failing zpool status leaves them out.
+	if cmd, err := util.SafeCommandContext(ctx, "zpool", "status"); err == nil {
+		if output, err := cmd.Output(); err == nil {
+			applyZpoolScrubs(pools, parseZpoolStatusScrubs(output, time.Local))
+		}
+	}
+	return pools, nil
+}
+
+// parseZpoolList parses `zpool list -Hp -o name,health,size,alloc,free,cap,frag`.
+// A "-" fragmentation, reported by pools without spacemap histograms, is
+// left unset.
+func parseZpoolList(output []byte) (ZFSPoolList, error) {
+	var pools ZFSPoolList
+	for _, line := range strings.Split(string(output), "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		fields := strings.Split(line, "\t")
+		if len(fields) < 7 {
+			return nil, fmt.Errorf("unexpected zpool list line %q", line)
+		}
+		size, sizeErr := strconv.ParseUint(fields[2], 10, 64)
+		alloc, allocErr := strconv.ParseUint(fields[3], 10, 64)
+		free, freeErr := strconv.ParseUint(fields[4], 10, 64)
+		capacity, capErr := strconv.ParseFloat(strings.TrimSuffix(fields[5], "%"), 64)
+		if err := errors.Join(sizeErr, allocErr, freeErr, capErr); err != nil {
+			return nil, fmt.Errorf("pool %s: %w", fields[0], err)
+		}
+		pool := ZFSPool{
+			Name:            fields[0],
+			Health:          fields[1],
+			SizeBytes:       size,
+			AllocatedBytes:  alloc,
+			FreeBytes:       free,
+			CapacityPercent: capacity,
+		}
+		if frag, err := strconv.ParseFloat(strings.TrimSuffix(fields[6], "%"), 64); err == nil {
+			pool.FragmentationPercent = &frag
+		}
+		pools = append(pools, pool)
+	}
+	return pools, nil
+}
+
+// zpoolScrub is the scrub state of one pool from `zpool status`.
+type zpoolScrub struct {
+	finished   time.Time
+	inProgress bool
+}
+
+// parseZpoolStatusScrubs reads the "scan:" line of each pool in `zpool
+// status` output. Only scrubs count; a resilver says nothing about when the
+// pool's data was last verified. Timestamps are in the host's local time,
+// as zpool prints them.
+func parseZpoolStatusScrubs(output []byte, loc *time.Location) map[string]zpoolScrub {
+	scrubs := make(map[string]zpoolScrub)
+	var pool string
+	for _, line := range strings.Split(string(output), "\n") {
+		line = strings.TrimSpace(line)
+		if name, ok := strings.CutPrefix(line, "pool:"); ok {
+			pool = strings.TrimSpace(name)
+			continue
+		}
+		scan, ok := strings.CutPrefix(line, "scan:")
+		if !ok || pool == "" {
+			continue
+		}
+		scan = strings.TrimSpace(scan)
+		switch {
+		case strings.HasPrefix(scan, "scrub in progress"):
+			scrubs[pool] = zpoolScrub{inProgress: true}
+		case strings.HasPrefix(scan, "scrub repaired"):
+			if _, when, ok := strings.Cut(scan, " on "); ok {
+				if finished, err := time.ParseInLocation("Mon Jan 2 15:04:05 2006", strings.Join(strings.Fields(when), " "), loc); err == nil {
+					scrubs[pool] = zpoolScrub{finished: finished}
+				}
+			}
+		}
+	}
+	return scrubs
+}
+
+func applyZpoolScrubs(pools ZFSPoolList, scrubs map[string]zpoolScrub) {
+	for i := range pools {
+		scrub, ok := scrubs[pools[i].Name]
+		if !ok {
+			continue
+		}
+		pools[i].ScrubInProgress = scrub.inProgress
+		if !scrub.finished.IsZero() {
+			finished := scrub.finished
+			pools[i].LastScrub = &finished
+		}
+	}
+}
+
+// zfsHealthSeverity grades a pool's health: anything but ONLINE means lost
+// redundancy or data and is critical, except a pool taken offline on
+// purpose.
+func zfsHealthSeverity(health string) display.Severity {
+	switch health {
+	case "ONLINE":
+		return display.SeverityOK
+	case "OFFLINE":
+		return display.SeverityWarning
+	default:
+		return display.SeverityCritical
+	}
+}
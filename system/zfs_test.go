@@ -0,0 +1,121 @@
+package system
+
+import (
+	"testing"
+	"time"
+
+	"motd/config"
+	"motd/display"
+)
+
+const zpoolListFixture = "backup\tONLINE\t3985729650688\t1203950653440\t2781779001344\t30\t-\n" +
+	"tank\tDEGRADED\t10995116277760\t9345848836096\t1649267441664\t85\t12\n"
+
+const zpoolStatusFixture = `  pool: backup
+ state: ONLINE
+  scan: scrub in progress since Sun Oct 11 00:24:01 2026
+	1.10T scanned at 1.20G/s, 512G issued at 560M/s, 1.10T total
+	0B repaired, 45.45% done, 00:18:20 to go
+config:
+
+	NAME        STATE     READ WRITE CKSUM
+	backup      ONLINE       0     0     0
+	  sdc       ONLINE       0     0     0
+
+errors: No known data errors
+
+  pool: tank
+ state: DEGRADED
+status: One or more devices could not be used because the label is missing or
+	invalid.  Sufficient replicas exist for the pool to continue
+	functioning in a degraded state.
+action: Replace the device using 'zpool replace'.
+   see: https://openzfs.github.io/openzfs-docs/msg/ZFS-8000-4J
+  scan: scrub repaired 0B in 05:12:44 with 0 errors on Sun Oct  4 05:36:45 2026
+config:
+
+	NAME                      STATE     READ WRITE CKSUM
+	tank                      DEGRADED     0     0     0
+	  raidz1-0                DEGRADED     0     0     0
+	    sda                   ONLINE       0     0     0
+	    11809413961463946436  UNAVAIL      0     0     0  was /dev/sdb1
+	    sdd                   ONLINE       0     0     0
+
+errors: No known data errors
+
+  pool: scratch
+ state: ONLINE
+  scan: none requested
+`
+
+func TestParseZpoolList(t *testing.T) {
+	pools, err := parseZpoolList([]byte(zpoolListFixture))
+	if err != nil {
+		t.Fatalf("parseZpoolList failed: %v", err)
+	}
+	if len(pools) != 2 {
+		t.Fatalf("expected 2 pools, got %+v", pools)
+	}
+	backup, tank := pools[0], pools[1]
+	if backup.Name != "backup" || backup.Health != "ONLINE" || backup.CapacityPercent != 30 || backup.FragmentationPercent != nil {
+		t.Fatalf("unexpected backup pool: %+v", backup)
+	}
+	if tank.Health != "DEGRADED" || tank.SizeBytes != 10995116277760 || tank.AllocatedBytes != 9345848836096 || tank.FragmentationPercent == nil || *tank.FragmentationPercent != 12 {
+		t.Fatalf("unexpected tank pool: %+v", tank)
+	}
+
+	if _, err := parseZpoolList([]byte("tank\tONLINE\t10\n")); err == nil {
+		t.Fatal("expected a short line to be rejected")
+	}
+}
+
+func TestParseZpoolStatusScrubs(t *testing.T) {
+	scrubs := parseZpoolStatusScrubs([]byte(zpoolStatusFixture), time.UTC)
+	if !scrubs["backup"].inProgress {
+		t.Fatalf("expected backup scrub in progress, got %+v", scrubs["backup"])
+	}
+	want := time.Date(2026, time.October, 4, 5, 36, 45, 0, time.UTC)
+	if !scrubs["tank"].finished.Equal(want) {
+		t.Fatalf("tank scrub finished %v, want %v", scrubs["tank"].finished, want)
+	}
+	if _, ok := scrubs["scratch"]; ok {
+		t.Fatal("expected no scrub for a pool that was never scrubbed")
+	}
+}
+
+func TestZFSPoolsSeverityAndLines(t *testing.T) {
+	pools, err := parseZpoolList([]byte(zpoolListFixture + "scratch\tONLINE\t1073741824\t107374182\t966367642\t10\t1\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	applyZpoolScrubs(pools, parseZpoolStatusScrubs([]byte(zpoolStatusFixture), time.UTC))
+	snap := SystemSnapshot{ZFSPools: pools}
+	snap.Evaluate(config.ThresholdsConfig{})
+
+	wantSeverity := []display.Severity{display.SeverityOK, display.SeverityCritical, display.SeverityOK}
+	wantLines := []string{
+		"ZFS (backup)=ONLINE, 1121.27 GB / 3712.00 GB (30% used), scrubbing",
+		"ZFS (tank)=DEGRADED, 8704.00 GB / 10240.00 GB (85% used, 12% frag), scrubbed 2026-10-04",
+		"ZFS (scratch)=ONLINE, 0.10 GB / 1.00 GB (10% used, 1% frag), never scrubbed",
+	}
+	lines := snap.ZFSPools.Lines()
+	for i, pool := range snap.ZFSPools {
+		if pool.Severity != wantSeverity[i] {
+			t.Fatalf("%s severity = %q, want %q", pool.Name, pool.Severity, wantSeverity[i])
+		}
+		if got := lines[i].Label + "=" + lines[i].Value; got != wantLines[i] {
+			t.Fatalf("line %d = %q, want %q", i, got, wantLines[i])
+		}
+	}
+	if lines[1].Color != display.Red {
+		t.Fatalf("expected a degraded pool in red, got %q", lines[1].Color)
+	}
+}
+
+func TestZFSCapacitySeverity(t *testing.T) {
+	snap := SystemSnapshot{ZFSPools: ZFSPoolList{{Name: "tank", Health: "ONLINE", CapacityPercent: 82}}}
+	snap.Evaluate(config.ThresholdsConfig{})
+	if snap.ZFSPools[0].Severity != display.SeverityWarning {
+		t.Fatalf("expected a healthy pool at 82%% to warn, got %q", snap.ZFSPools[0].Severity)
+	}
+}
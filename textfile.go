@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"motd/config"
+	"motd/display"
+)
+
+const defaultTextfilePath = "/var/lib/node_exporter/textfile/motd.prom"
+
+// writeTextfile renders export for the node_exporter textfile collector and
+// replaces path atomically so a scrape never sees a partial file.
+func writeTextfile(path string, export metricsExport) error {
+	var buf bytes.Buffer
+	if err := writeMetrics(&buf, export, formatPrometheusText); err != nil {
+		return err
+	}
+	return config.AtomicWriteFile(path, buf.Bytes(), 0o644)
+}
+
+func handleTextfile(args []string) {
+	fs := flagSet("textfile")
+	debug := fs.Bool("d", false, "Enable debug mode")
+	configPath := fs.String("config", "", "Load config from a specific JSON file")
+	noConfig := fs.Bool("no-config", false, "Skip config loading and export system metrics only")
+	servicesFilter := fs.String("services", "", "Only export selected media services (comma-separated)")
+	output := fs.String("output", defaultTextfilePath, "File to write; use - for stdout")
+	timeout := fs.Duration("timeout", 0, "Time budget for the collection (default: render_budget or 10s)")
+	if err := fs.Parse(args); err != nil {
+		os.Exit(2)
+	}
+	display.SetColorEnabled(false)
+
+	cfg, err := config.Load(*configPath, *noConfig, func(msg string, args ...interface{}) {
+		display.DebugLog(*debug, msg, args...)
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading configuration: %v\n", err)
+		os.Exit(1)
+	}
+	serviceSet, err := parseServiceFilter(*servicesFilter)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	ctx, cancel := budgetContext(cfg, *timeout, *debug)
+	export := collectMetricsExport(ctx, cfg, serviceSet, newHTTPClient(), *debug)
+	cancel()
+	if *output == "-" {
+		err = writeMetrics(os.Stdout, export, formatPrometheusText)
+	} else {
+		err = writeTextfile(*output, export)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing metrics: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"motd/system"
+)
+
+func TestWriteTextfileUsesPrometheusTextFormat(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "motd.prom")
+	oomKills := uint64(2)
+	export := metricsExport{Snapshot: system.SystemSnapshot{Memory: &system.MemoryInfo{TotalBytes: 4096, UsedBytes: 1024, OOMKills: &oomKills}}}
+	if err := writeTextfile(path, export); err != nil {
+		t.Fatalf("writeTextfile: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read textfile: %v", err)
+	}
+	out := string(data)
+	for _, want := range []string{
+		"# HELP motd_build_info motd build information.\n# TYPE motd_build_info gauge\nmotd_build_info{version=",
+		"# HELP motd_memory_used_bytes Used physical memory.\n# TYPE motd_memory_used_bytes gauge\nmotd_memory_used_bytes 1024\n",
+		"# HELP motd_oom_kills_total Processes killed by the OOM killer since boot.\n# TYPE motd_oom_kills_total counter\nmotd_oom_kills_total 2\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected %q in textfile:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "# EOF") || strings.Contains(out, "# UNIT") {
+		t.Fatalf("expected no OpenMetrics-only lines in textfile:\n%s", out)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("stat textfile: %v", err)
+	}
+	if info.Mode().Perm() != 0o644 {
+		t.Fatalf("expected mode 0644, got %v", info.Mode().Perm())
+	}
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 1 {
+		t.Fatalf("expected no leftover temp files, got %d entries", len(entries))
+	}
+}
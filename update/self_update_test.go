@@ -2,6 +2,7 @@ package update
 
 import (
 	"bytes"
+	"context"
 	"crypto/ed25519"
 	"crypto/rand"
 	"crypto/sha256"
@@ -19,7 +20,7 @@ import (
 )
 
 // testChecker creates a Checker with the given fetchFunc and a temp cache dir.
-func testChecker(t *testing.T, fetchFunc func(*http.Client) (string, error)) *Checker {
+func testChecker(t *testing.T, fetchFunc func(context.Context, *http.Client) (string, error)) *Checker {
 	t.Helper()
 	cacheDir := t.TempDir()
 	return &Checker{
@@ -30,7 +31,7 @@ func testChecker(t *testing.T, fetchFunc func(*http.Client) (string, error)) *Ch
 }
 
 // testCheckerWithKey creates a Checker with the given fetch func and signing key.
-func testCheckerWithKey(t *testing.T, fetchFunc func(*http.Client) (string, error), keyFunc func() (ed25519.PublicKey, error)) *Checker {
+func testCheckerWithKey(t *testing.T, fetchFunc func(context.Context, *http.Client) (string, error), keyFunc func() (ed25519.PublicKey, error)) *Checker {
 	t.Helper()
 	cacheDir := t.TempDir()
 	return &Checker{
@@ -254,7 +255,7 @@ func TestDefaultCachePath_CreatesDirectory(t *testing.T) {
 }
 
 func TestWriteAndReadCachedVersion(t *testing.T) {
-	ch := testChecker(t, func(*http.Client) (string, error) { return "", nil })
+	ch := testChecker(t, func(context.Context, *http.Client) (string, error) { return "", nil })
 
 	// Write a latest version
 	ch.writeCachedVersion("2.0.0")
@@ -267,7 +268,7 @@ func TestWriteAndReadCachedVersion(t *testing.T) {
 }
 
 func TestCachedVersionExpires(t *testing.T) {
-	ch := testChecker(t, func(*http.Client) (string, error) { return "", nil })
+	ch := testChecker(t, func(context.Context, *http.Client) (string, error) { return "", nil })
 
 	// Manually write an expired cache entry (25 min old)
 	expired := time.Now().Add(-25 * time.Minute).Unix()
@@ -295,7 +296,7 @@ func TestFetchLatestVersion(t *testing.T) {
 	defer server.Close()
 
 	client := server.Client()
-	version, err := fetchLatestVersionFromURL(server.URL, client)
+	version, err := fetchLatestVersionFromURL(context.Background(), server.URL, client)
 	if err != nil {
 		t.Fatalf("fetchLatestVersion failed: %v", err)
 	}
@@ -306,11 +307,11 @@ func TestFetchLatestVersion(t *testing.T) {
 
 func TestCheckUpdate_UpToDate(t *testing.T) {
 	callCount := 0
-	ch := testChecker(t, func(*http.Client) (string, error) {
+	ch := testChecker(t, func(context.Context, *http.Client) (string, error) {
 		callCount++
 		return "1.0.0", nil
 	})
-	msg := ch.CheckUpdate("1.0.0", nil)
+	msg := ch.CheckUpdate(context.Background(), "1.0.0", nil)
 	if msg != "" {
 		t.Fatalf("expected no update when versions match, got %q", msg)
 	}
@@ -318,7 +319,7 @@ func TestCheckUpdate_UpToDate(t *testing.T) {
 		t.Fatalf("expected 1 fetch call, got %d", callCount)
 	}
 	// Second call should use cache
-	msg = ch.CheckUpdate("1.0.0", nil)
+	msg = ch.CheckUpdate(context.Background(), "1.0.0", nil)
 	if msg != "" {
 		t.Fatalf("expected no update from cache, got %q", msg)
 	}
@@ -328,8 +329,8 @@ func TestCheckUpdate_UpToDate(t *testing.T) {
 }
 
 func TestCheckUpdate_NewVersionAvailable(t *testing.T) {
-	ch := testChecker(t, func(*http.Client) (string, error) { return "2.0.0", nil })
-	msg := ch.CheckUpdate("1.0.0", nil)
+	ch := testChecker(t, func(context.Context, *http.Client) (string, error) { return "2.0.0", nil })
+	msg := ch.CheckUpdate(context.Background(), "1.0.0", nil)
 	if msg == "" {
 		t.Fatal("expected update message")
 	}
@@ -340,13 +341,13 @@ func TestCheckUpdate_NewVersionAvailable(t *testing.T) {
 
 func TestCheckUpdate_CachesResult(t *testing.T) {
 	callCount := 0
-	ch := testChecker(t, func(*http.Client) (string, error) {
+	ch := testChecker(t, func(context.Context, *http.Client) (string, error) {
 		callCount++
 		return "2.0.0", nil
 	})
 
 	// First call — fetches
-	msg1 := ch.CheckUpdate("1.0.0", nil)
+	msg1 := ch.CheckUpdate(context.Background(), "1.0.0", nil)
 	if msg1 == "" {
 		t.Fatal("expected update message")
 	}
@@ -355,7 +356,7 @@ func TestCheckUpdate_CachesResult(t *testing.T) {
 	}
 
 	// Second call — should use cache, not call fetchLatestVersion
-	msg2 := ch.CheckUpdate("1.0.0", nil)
+	msg2 := ch.CheckUpdate(context.Background(), "1.0.0", nil)
 	if msg2 != msg1 {
 		t.Fatalf("expected cached message, got %q", msg2)
 	}
@@ -366,13 +367,13 @@ func TestCheckUpdate_CachesResult(t *testing.T) {
 
 func TestCheckUpdate_CachesUptodateResult(t *testing.T) {
 	callCount := 0
-	ch := testChecker(t, func(*http.Client) (string, error) {
+	ch := testChecker(t, func(context.Context, *http.Client) (string, error) {
 		callCount++
 		return "1.0.0", nil
 	})
 
 	// First call — fetches, discovers we're up-to-date
-	msg := ch.CheckUpdate("1.0.0", nil)
+	msg := ch.CheckUpdate(context.Background(), "1.0.0", nil)
 	if msg != "" {
 		t.Fatalf("expected empty for uptodate, got %q", msg)
 	}
@@ -381,7 +382,7 @@ func TestCheckUpdate_CachesUptodateResult(t *testing.T) {
 	}
 
 	// Second call — should use cache, not call fetchLatestVersion
-	msg = ch.CheckUpdate("1.0.0", nil)
+	msg = ch.CheckUpdate(context.Background(), "1.0.0", nil)
 	if msg != "" {
 		t.Fatalf("expected empty for uptodate (cached), got %q", msg)
 	}
@@ -392,12 +393,12 @@ func TestCheckUpdate_CachesUptodateResult(t *testing.T) {
 
 func TestCheckUpdate_CachedLatestRecomparesCurrentVersion(t *testing.T) {
 	callCount := 0
-	ch := testChecker(t, func(*http.Client) (string, error) {
+	ch := testChecker(t, func(context.Context, *http.Client) (string, error) {
 		callCount++
 		return "1.7.3", nil
 	})
 
-	msg := ch.CheckUpdate("1.7.1", nil)
+	msg := ch.CheckUpdate(context.Background(), "1.7.1", nil)
 	if msg == "" {
 		t.Fatal("expected update message")
 	}
@@ -408,7 +409,7 @@ func TestCheckUpdate_CachedLatestRecomparesCurrentVersion(t *testing.T) {
 		t.Fatalf("expected 1 fetch call, got %d", callCount)
 	}
 
-	msg = ch.CheckUpdate("1.7.3", nil)
+	msg = ch.CheckUpdate(context.Background(), "1.7.3", nil)
 	if msg != "" {
 		t.Fatalf("expected no update after current version catches up to cached latest, got %q", msg)
 	}
@@ -419,7 +420,7 @@ func TestCheckUpdate_CachedLatestRecomparesCurrentVersion(t *testing.T) {
 
 func TestCheckUpdate_TransitionFromUptodateToNewVersion(t *testing.T) {
 	callCount := 0
-	ch := testChecker(t, func(*http.Client) (string, error) {
+	ch := testChecker(t, func(context.Context, *http.Client) (string, error) {
 		callCount++
 		if callCount == 1 {
 			return "1.0.0", nil
@@ -428,7 +429,7 @@ func TestCheckUpdate_TransitionFromUptodateToNewVersion(t *testing.T) {
 	})
 
 	// First call — uptodate
-	msg := ch.CheckUpdate("1.0.0", nil)
+	msg := ch.CheckUpdate(context.Background(), "1.0.0", nil)
 	if msg != "" {
 		t.Fatalf("expected empty for uptodate, got %q", msg)
 	}
@@ -441,9 +442,9 @@ func TestCheckUpdate_TransitionFromUptodateToNewVersion(t *testing.T) {
 	ch.writeCachedVersion("")
 	// Now set up to return new version
 	origFetch := ch.fetchLatestVersion
-	ch.fetchLatestVersion = func(*http.Client) (string, error) { return "2.0.0", nil }
+	ch.fetchLatestVersion = func(context.Context, *http.Client) (string, error) { return "2.0.0", nil }
 
-	msg = ch.CheckUpdate("1.0.0", nil)
+	msg = ch.CheckUpdate(context.Background(), "1.0.0", nil)
 	if msg == "" {
 		t.Fatal("expected update message")
 	}
@@ -451,8 +452,8 @@ func TestCheckUpdate_TransitionFromUptodateToNewVersion(t *testing.T) {
 }
 
 func TestCheckUpdate_FetchError_ReturnsEmpty(t *testing.T) {
-	ch := testChecker(t, func(*http.Client) (string, error) { return "", fmt.Errorf("network error") })
-	msg := ch.CheckUpdate("1.0.0", nil)
+	ch := testChecker(t, func(context.Context, *http.Client) (string, error) { return "", fmt.Errorf("network error") })
+	msg := ch.CheckUpdate(context.Background(), "1.0.0", nil)
 	if msg != "" {
 		t.Fatalf("expected empty on fetch error, got %q", msg)
 	}
@@ -495,7 +496,7 @@ func TestVerifyChecksumsSignature_Valid(t *testing.T) {
 		t.Fatalf("failed to generate test key: %v", err)
 	}
 
-	ch := testCheckerWithKey(t, func(*http.Client) (string, error) { return "", nil },
+	ch := testCheckerWithKey(t, func(context.Context, *http.Client) (string, error) { return "", nil },
 		func() (ed25519.PublicKey, error) { return pubKey, nil })
 
 	data := []byte("sha256 abc  motd-linux-amd64\n")
@@ -512,7 +513,7 @@ func TestVerifyChecksumsSignature_Invalid(t *testing.T) {
 		t.Fatalf("failed to generate test key: %v", err)
 	}
 
-	ch := testCheckerWithKey(t, func(*http.Client) (string, error) { return "", nil },
+	ch := testCheckerWithKey(t, func(context.Context, *http.Client) (string, error) { return "", nil },
 		func() (ed25519.PublicKey, error) { return pubKey, nil })
 
 	data := []byte("sha256 abc  motd-linux-amd64\n")
@@ -530,7 +531,7 @@ func TestVerifyChecksumsSignature_TamperedData(t *testing.T) {
 		t.Fatalf("failed to generate test key: %v", err)
 	}
 
-	ch := testCheckerWithKey(t, func(*http.Client) (string, error) { return "", nil },
+	ch := testCheckerWithKey(t, func(context.Context, *http.Client) (string, error) { return "", nil },
 		func() (ed25519.PublicKey, error) { return pubKey, nil })
 
 	data := []byte("sha256 abc  motd-linux-amd64\n")
@@ -624,7 +625,7 @@ func TestVerifyChecksumsSignature_WrongLengthSig(t *testing.T) {
 		t.Fatalf("failed to generate test key: %v", err)
 	}
 
-	ch := testCheckerWithKey(t, func(*http.Client) (string, error) { return "", nil },
+	ch := testCheckerWithKey(t, func(context.Context, *http.Client) (string, error) { return "", nil },
 		func() (ed25519.PublicKey, error) { return pubKey, nil })
 
 	data := []byte("sha256 abc  motd-linux-amd64\n")
@@ -854,7 +855,7 @@ func TestGetLatestRelease_OversizedResponse(t *testing.T) {
 	client := server.Client()
 	// We need to call the underlying logic. getLatestRelease uses a fixed URL,
 	// so we test via fetchLatestVersionFromURL which also reads JSON.
-	_, err := fetchLatestVersionFromURL(server.URL, client)
+	_, err := fetchLatestVersionFromURL(context.Background(), server.URL, client)
 	if err == nil || !strings.Contains(err.Error(), "too large") {
 		t.Fatalf("expected 'too large' error, got: %v", err)
 	}
@@ -935,7 +936,7 @@ func TestGetLatestRelease_NormalSizedResponse(t *testing.T) {
 	defer server.Close()
 
 	client := server.Client()
-	version, err := fetchLatestVersionFromURL(server.URL, client)
+	version, err := fetchLatestVersionFromURL(context.Background(), server.URL, client)
 	if err != nil {
 		t.Fatalf("expected success, got: %v", err)
 	}
@@ -1048,7 +1049,7 @@ func TestPerformUpdateIntegration_HappyPath(t *testing.T) {
 	release.Assets[2].URL = server.URL + "/binary"
 
 	ch := &Checker{
-		fetchLatestVersion: func(*http.Client) (string, error) { return "", nil },
+		fetchLatestVersion: func(context.Context, *http.Client) (string, error) { return "", nil },
 		cachePath:          func() string { return filepath.Join(t.TempDir(), "cache") },
 		signingPublicKey:   func() (ed25519.PublicKey, error) { return pubKey, nil },
 	}
@@ -1139,7 +1140,7 @@ func TestPerformUpdateIntegration_InvalidSignature(t *testing.T) {
 	}
 
 	ch := &Checker{
-		fetchLatestVersion: func(*http.Client) (string, error) { return "", nil },
+		fetchLatestVersion: func(context.Context, *http.Client) (string, error) { return "", nil },
 		cachePath:          func() string { return filepath.Join(t.TempDir(), "cache") },
 		signingPublicKey:   func() (ed25519.PublicKey, error) { return pubKey, nil },
 	}
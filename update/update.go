@@ -1,6 +1,7 @@
 package update
 
 import (
+	"context"
 	"crypto/ed25519"
 	"crypto/sha256"
 	"encoding/hex"
@@ -72,7 +73,7 @@ func defaultSigningPublicKey() (ed25519.PublicKey, error) {
 // Checker holds the dependencies for checking and performing updates.
 // Tests should create isolated Checker values instead of mutating globals.
 type Checker struct {
-	fetchLatestVersion func(client *http.Client) (string, error)
+	fetchLatestVersion func(ctx context.Context, client *http.Client) (string, error)
 	cachePath          func() string
 	signingPublicKey   func() (ed25519.PublicKey, error)
 }
@@ -80,8 +81,8 @@ type Checker struct {
 // NewChecker returns a Checker with production defaults.
 func NewChecker() *Checker {
 	return &Checker{
-		fetchLatestVersion: func(client *http.Client) (string, error) {
-			return fetchLatestVersionFromURL("https://api.github.com/repos/thewildhive/go-motd/releases/latest", client)
+		fetchLatestVersion: func(ctx context.Context, client *http.Client) (string, error) {
+			return fetchLatestVersionFromURL(ctx, "https://api.github.com/repos/thewildhive/go-motd/releases/latest", client)
 		},
 		cachePath:        defaultCachePath,
 		signingPublicKey: defaultSigningPublicKey,
@@ -717,8 +718,12 @@ type cacheEntry struct {
 const cacheFile = "motd-version-check"
 const cacheInterval = 15 * time.Minute
 
-func fetchLatestVersionFromURL(url string, client *http.Client) (string, error) {
-	resp, err := client.Get(url)
+func fetchLatestVersionFromURL(ctx context.Context, url string, client *http.Client) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := client.Do(req)
 	if err != nil {
 		return "", err
 	}
@@ -746,12 +751,13 @@ func fetchLatestVersionFromURL(url string, client *http.Client) (string, error)
 
 // CheckUpdate returns a non-empty update message if a newer version of motd
 // is available. Results are cached for cacheInterval to avoid hammering the
-// GitHub API on every motd invocation.
-func CheckUpdate(currentVersion string, client *http.Client) string {
-	return NewChecker().CheckUpdate(currentVersion, client)
+// GitHub API on every motd invocation. The GitHub request is abandoned when
+// ctx is done.
+func CheckUpdate(ctx context.Context, currentVersion string, client *http.Client) string {
+	return NewChecker().CheckUpdate(ctx, currentVersion, client)
 }
 
-func (ch *Checker) CheckUpdate(currentVersion string, client *http.Client) string {
+func (ch *Checker) CheckUpdate(ctx context.Context, currentVersion string, client *http.Client) string {
 	if latest := ch.readCachedVersion(); latest != "" {
 		if CompareVersions(currentVersion, latest) >= 0 {
 			return ""
@@ -759,7 +765,7 @@ func (ch *Checker) CheckUpdate(currentVersion string, client *http.Client) strin
 		return updateMessage(currentVersion, latest)
 	}
 
-	latest, err := ch.fetchLatestVersion(client)
+	latest, err := ch.fetchLatestVersion(ctx, client)
 	if err != nil || latest == "" {
 		return ""
 	}
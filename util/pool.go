@@ -0,0 +1,36 @@
+package util
+
+import "context"
+
+// Pool bounds how many tasks run at once. Callers that share a Pool queue
+// for the same slots, so system collectors and media checks scheduled
+// together never exceed its size between them.
+type Pool struct {
+	slots chan struct{}
+}
+
+// NewPool returns a Pool that runs at most size tasks at a time.
+func NewPool(size int) *Pool {
+	if size < 1 {
+		size = 1
+	}
+	return &Pool{slots: make(chan struct{}, size)}
+}
+
+// Go runs task in its own goroutine once a slot is free. A task still
+// queued when ctx is done is dropped; callers learn about it by watching
+// ctx rather than waiting for a result.
+func (p *Pool) Go(ctx context.Context, task func()) {
+	go func() {
+		select {
+		case p.slots <- struct{}{}:
+		case <-ctx.Done():
+			return
+		}
+		defer func() { <-p.slots }()
+		if ctx.Err() != nil {
+			return
+		}
+		task()
+	}()
+}
@@ -0,0 +1,56 @@
+package util
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPoolLimitsConcurrency(t *testing.T) {
+	pool := NewPool(2)
+	var running, peak atomic.Int32
+	done := make(chan struct{}, 6)
+	for i := 0; i < 6; i++ {
+		pool.Go(context.Background(), func() {
+			now := running.Add(1)
+			for {
+				old := peak.Load()
+				if now <= old || peak.CompareAndSwap(old, now) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+			running.Add(-1)
+			done <- struct{}{}
+		})
+	}
+	for i := 0; i < 6; i++ {
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for task %d", i)
+		}
+	}
+	if got := peak.Load(); got != 2 {
+		t.Fatalf("expected at most 2 concurrent tasks, peak was %d", got)
+	}
+}
+
+func TestPoolDropsQueuedTasksWhenContextIsDone(t *testing.T) {
+	pool := NewPool(1)
+	release := make(chan struct{})
+	defer close(release)
+	pool.Go(context.Background(), func() { <-release })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ran := make(chan struct{}, 1)
+	pool.Go(ctx, func() { ran <- struct{}{} })
+	cancel()
+
+	select {
+	case <-ran:
+		t.Fatal("expected the queued task to be dropped")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
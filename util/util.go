@@ -1,6 +1,8 @@
 package util
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -9,13 +11,14 @@ import (
 	"runtime"
 	"strings"
 	"sync"
+	"time"
 )
 
 // trustedUnixDirs are the trusted directories for command resolution on Linux.
 var trustedUnixDirs = []string{"/usr/bin", "/usr/sbin", "/bin", "/sbin"}
 
 // trustedMacDirs includes standard Unix paths and Homebrew paths.
-var trustedMacDirs = []string{"/usr/bin", "/usr/sbin", "/bin", "/sbin", "/usr/local/bin", "/opt/homebrew/bin"}
+var trustedMacDirs = []string{"/usr/bin", "/usr/sbin", "/bin", "/sbin", "/usr/local/bin", "/usr/local/sbin", "/opt/homebrew/bin", "/opt/homebrew/sbin"}
 
 // trustedWindowsDirs are the trusted directories on Windows.
 var trustedWindowsDirs = []string{
@@ -83,11 +86,17 @@ func HasCommand(name string) bool {
 // SafeCommand returns an exec.Cmd for name resolved from trusted directories
 // with a minimal trusted PATH. Returns an error if name is not found.
 func SafeCommand(name string, arg ...string) (*exec.Cmd, error) {
+	return SafeCommandContext(context.Background(), name, arg...)
+}
+
+// SafeCommandContext is SafeCommand with a process that is killed when ctx
+// is done.
+func SafeCommandContext(ctx context.Context, name string, arg ...string) (*exec.Cmd, error) {
 	resolved := ResolveCommand(name)
 	if resolved == "" {
 		return nil, fmt.Errorf("command not found in trusted directories: %s", name)
 	}
-	cmd := exec.Command(resolved, arg...)
+	cmd := exec.CommandContext(ctx, resolved, arg...)
 	env := os.Environ()
 	filtered := make([]string, 0, len(env))
 	for _, e := range env {
@@ -155,3 +164,24 @@ func PluralSuffix(count int) string {
 	}
 	return "s"
 }
+
+// AcquireLockFile creates path exclusively. A lock older than maxAge is
+// assumed to belong to a process that died and is replaced.
+func AcquireLockFile(path string, now time.Time, maxAge time.Duration) bool {
+	for attempt := 0; attempt < 2; attempt++ {
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err == nil {
+			file.Close()
+			return true
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return false
+		}
+		info, statErr := os.Stat(path)
+		if statErr != nil || now.Sub(info.ModTime()) < maxAge {
+			return false
+		}
+		os.Remove(path)
+	}
+	return false
+}